@@ -16,6 +16,39 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/health/ready": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Metrics"
+                ],
+                "summary": "Readiness of all services. Calls readiness endpoint of every upstream, the result is cached for 5 seconds. Status matrix with upstream URLs and errors is returned only to admin and service tokens",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer token of admin or service to get the status matrix",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.ReadyResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.ReadyResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/userapi/heartbeat": {
             "get": {
                 "tags": [
@@ -32,6 +65,62 @@ const docTemplate = `{
                 }
             }
         }
+    },
+    "definitions": {
+        "metrics.CheckResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "metrics.ReadyResponse": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string"
+                },
+                "upstreams": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/metrics.UpstreamResult"
+                    }
+                }
+            }
+        },
+        "metrics.UpstreamResult": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/metrics.CheckResult"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                },
+                "routes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        }
     }
 }`
 
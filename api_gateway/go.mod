@@ -3,6 +3,7 @@ module api_gateway
 go 1.18
 
 require (
+	github.com/golang-jwt/jwt/v4 v4.4.2
 	github.com/ilyakaznacheev/cleanenv v1.3.0
 	github.com/julienschmidt/httprouter v1.3.0
 	github.com/rs/cors v1.8.2
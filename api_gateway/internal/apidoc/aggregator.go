@@ -0,0 +1,247 @@
+package apidoc
+
+import (
+	"api_gateway/internal/config"
+	"api_gateway/internal/proxy"
+	"api_gateway/pkg/logging"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	docURL         = "/swagger/doc.json"
+	retryDelay     = 30 * time.Second
+	definitionsRef = "#/definitions/"
+)
+
+// Aggregator merges swagger documents of upstream services into one document.
+// Only paths that are routed by the gateway to the same upstream and aren't internal get into the document.
+// It implements swag.Swagger, so it can be registered as the gateway swagger document
+type Aggregator struct {
+	logger        logging.Logger
+	routes        []proxy.Route
+	policies      []proxy.Policy
+	defaultPolicy string
+	client        http.Client
+	ttl           time.Duration
+
+	mu        sync.Mutex
+	doc       string
+	expiresAt time.Time
+}
+
+type upstreamDoc struct {
+	Paths       map[string]json.RawMessage `json:"paths"`
+	Definitions map[string]json.RawMessage `json:"definitions"`
+}
+
+type document struct {
+	Swagger     string                     `json:"swagger"`
+	Info        info                       `json:"info"`
+	BasePath    string                     `json:"basePath"`
+	Paths       map[string]json.RawMessage `json:"paths"`
+	Definitions map[string]json.RawMessage `json:"definitions,omitempty"`
+}
+
+type info struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+func NewAggregator(cfg *config.Config, logger logging.Logger) (*Aggregator, error) {
+	routes, err := proxy.NewRoutes(cfg.Proxy.Routes)
+	if err != nil {
+		return nil, err
+	}
+	policies, err := proxy.NewPolicies(cfg.Proxy.Policies)
+	if err != nil {
+		return nil, err
+	}
+	return &Aggregator{
+		logger:        logger,
+		routes:        routes,
+		policies:      policies,
+		defaultPolicy: cfg.Proxy.DefaultPolicy,
+		client:        http.Client{Timeout: cfg.Proxy.Timeout},
+		ttl:           cfg.Docs.TTL,
+	}, nil
+}
+
+// ReadDoc returns merged document. Document is cached for Docs.TTL,
+// if some upstream was unavailable it's fetched again in 30 seconds
+func (a *Aggregator) ReadDoc() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.doc != "" && time.Now().Before(a.expiresAt) {
+		return a.doc
+	}
+	doc, complete := a.build(context.Background())
+	a.doc = doc
+	if complete {
+		a.expiresAt = time.Now().Add(a.ttl)
+	} else {
+		a.expiresAt = time.Now().Add(retryDelay)
+	}
+	return a.doc
+}
+
+// build fetches documents of all upstreams and merges them.
+// It returns false if some of the documents weren't fetched
+func (a *Aggregator) build(ctx context.Context) (string, bool) {
+	upstreams := make(map[string]struct{})
+	for _, route := range a.routes {
+		upstreams[route.Upstream.String()] = struct{}{}
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		docs = make(map[string]upstreamDoc, len(upstreams))
+	)
+	for upstream := range upstreams {
+		wg.Add(1)
+		go func(upstream string) {
+			defer wg.Done()
+			doc, err := a.fetch(ctx, upstream)
+			if err != nil {
+				a.logger.Warnf("failed to fetch swagger document of %s due to: %v", upstream, err)
+				return
+			}
+			mu.Lock()
+			docs[upstream] = doc
+			mu.Unlock()
+		}(upstream)
+	}
+	wg.Wait()
+
+	merged := document{
+		Swagger: "2.0",
+		Info: info{
+			Title:       "Game server API",
+			Description: "Public API of all services available through the API gateway",
+			Version:     "1.0",
+		},
+		BasePath:    "/",
+		Paths:       make(map[string]json.RawMessage),
+		Definitions: make(map[string]json.RawMessage),
+	}
+
+	// services use the same definition names (apperror.AppError, user.User), so definitions of every upstream
+	// are prefixed with its namespace and references to them are rewritten
+	namespaces := a.namespaces()
+	for upstream, doc := range docs {
+		namespace := namespaces[upstream]
+		for path, item := range doc.Paths {
+			route, found := proxy.Match(a.routes, path)
+			if !found || route.Upstream.String() != upstream {
+				continue
+			}
+			if proxy.MatchPolicy(a.policies, path, a.defaultPolicy) == proxy.PolicyInternal {
+				continue
+			}
+			item, err := namespaceRefs(item, namespace)
+			if err != nil {
+				a.logger.Warnf("failed to merge path %s of %s due to: %v", path, upstream, err)
+				continue
+			}
+			merged.Paths[path] = item
+		}
+		for name, definition := range doc.Definitions {
+			definition, err := namespaceRefs(definition, namespace)
+			if err != nil {
+				a.logger.Warnf("failed to merge definition %s of %s due to: %v", name, upstream, err)
+				continue
+			}
+			merged.Definitions[namespace+"."+name] = definition
+		}
+	}
+
+	bytes, err := json.Marshal(merged)
+	if err != nil {
+		a.logger.Errorf("failed to marshal merged swagger document due to: %v", err)
+		return "", false
+	}
+	return string(bytes), len(docs) == len(upstreams)
+}
+
+// namespaces names every upstream after the last segment of its first route prefix, /api/users gives users.
+// If the name is taken by another upstream, the whole prefix is used
+func (a *Aggregator) namespaces() map[string]string {
+	routes := make([]proxy.Route, len(a.routes))
+	copy(routes, a.routes)
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].Prefix < routes[j].Prefix
+	})
+
+	namespaces := make(map[string]string)
+	taken := make(map[string]bool)
+	for _, route := range routes {
+		upstream := route.Upstream.String()
+		if _, found := namespaces[upstream]; found {
+			continue
+		}
+		namespace := path.Base(route.Prefix)
+		if taken[namespace] {
+			namespace = strings.ReplaceAll(strings.Trim(route.Prefix, "/"), "/", "_")
+		}
+		namespaces[upstream] = namespace
+		taken[namespace] = true
+	}
+	return namespaces
+}
+
+// namespaceRefs rewrites references to definitions in the part of the document, so they point
+// to the definitions prefixed with the namespace
+func namespaceRefs(raw json.RawMessage, namespace string) (json.RawMessage, error) {
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(rewriteRefs(value, namespace))
+}
+
+func rewriteRefs(value interface{}, namespace string) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			if ref, ok := item.(string); ok && key == "$ref" && strings.HasPrefix(ref, definitionsRef) {
+				value[key] = definitionsRef + namespace + "." + strings.TrimPrefix(ref, definitionsRef)
+				continue
+			}
+			value[key] = rewriteRefs(item, namespace)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = rewriteRefs(item, namespace)
+		}
+	}
+	return value
+}
+
+func (a *Aggregator) fetch(ctx context.Context, upstream string) (doc upstreamDoc, err error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, upstream+docURL, nil)
+	if err != nil {
+		return doc, fmt.Errorf("failed to build a request due to: %v", err)
+	}
+	response, err := a.client.Do(request)
+	if err != nil {
+		return doc, fmt.Errorf("failed to do a request due to: %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return doc, fmt.Errorf("got wrong status code: %d", response.StatusCode)
+	}
+	if err = json.NewDecoder(response.Body).Decode(&doc); err != nil {
+		return doc, fmt.Errorf("failed to decode swagger document due to: %v", err)
+	}
+	return doc, nil
+}
@@ -1,7 +1,9 @@
 package app
 
 import (
+	"api_gateway/internal/apidoc"
 	"api_gateway/internal/config"
+	"api_gateway/internal/proxy"
 	"api_gateway/pkg/logging"
 	"api_gateway/pkg/metrics"
 	"context"
@@ -10,6 +12,7 @@ import (
 	"github.com/julienschmidt/httprouter"
 	"github.com/rs/cors"
 	httpSwagger "github.com/swaggo/http-swagger"
+	"github.com/swaggo/swag"
 	"net"
 	"net/http"
 	"os"
@@ -30,11 +33,28 @@ func NewApp(config *config.Config, logger *logging.Logger) (App, error) {
 	router := httprouter.New()
 
 	logger.Println("swagger docs initialization")
+	aggregator, err := apidoc.NewAggregator(config, *logger)
+	if err != nil {
+		return App{}, err
+	}
+	swag.Register(swag.Name, aggregator)
 	router.Handler(http.MethodGet, "/swagger", http.RedirectHandler("/swagger/index.html", http.StatusMovedPermanently))
 	router.Handler(http.MethodGet, "/swagger/*any", httpSwagger.WrapHandler)
 
+	logger.Println("proxy routes initializing")
+	proxyHandler, err := proxy.NewHandler(config, *logger)
+	if err != nil {
+		return App{}, err
+	}
+	proxyHandler.Register(router)
+
 	logger.Println("heartbeat metric initializing")
-	metricHandler := metrics.Handler{}
+	metricHandler := metrics.Handler{
+		Routes: config.Proxy.Routes,
+		Authorized: func(r *http.Request) bool {
+			return proxyHandler.HasRole(r, proxy.RoleAdmin, proxy.RoleService)
+		},
+	}
 	metricHandler.Register(router)
 
 	return App{
@@ -0,0 +1,52 @@
+package apperror
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+var (
+	ErrNotFound        = NewAppError(nil, "not found", "NS-000003", "")
+	ErrWrongToken      = NewAppError(nil, "wrong token", "NS-000004", "")
+	ErrForbidden       = NewAppError(nil, "forbidden", "NS-000007", "route is available for internal calls only")
+	ErrTooManyRequests = NewAppError(nil, "too many requests", "NS-000008", "rate limit exceeded, retry after the time in Retry-After header")
+	ErrBadGateway      = NewAppError(nil, "bad gateway", "NS-000005", "upstream service is unavailable")
+	ErrGatewayTimeout  = NewAppError(nil, "gateway timeout", "NS-000006", "upstream service did not respond in time")
+)
+
+type AppError struct {
+	Err              error  `json:"-"`
+	Message          string `json:"message,omitempty"`
+	DeveloperMessage string `json:"developer_message,omitempty"`
+	Code             string `json:"code,omitempty"`
+}
+
+func NewAppError(err error, message, code, developerMessage string) *AppError {
+	return &AppError{
+		Err:              err,
+		Code:             code,
+		Message:          message,
+		DeveloperMessage: developerMessage,
+	}
+}
+
+func (e *AppError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *AppError) Unwrap() error { return e.Err }
+
+func (e *AppError) Marshal() []byte {
+	bytes, err := json.Marshal(e)
+	if err != nil {
+		return nil
+	}
+	return bytes
+}
+
+// WriteError writes app error with the given status code as a JSON response
+func WriteError(w http.ResponseWriter, statusCode int, err *AppError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(err.Marshal())
+}
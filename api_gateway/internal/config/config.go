@@ -4,6 +4,7 @@ import (
 	"github.com/ilyakaznacheev/cleanenv"
 	"log"
 	"sync"
+	"time"
 )
 
 type Config struct {
@@ -22,6 +23,30 @@ type Config struct {
 			Password string `env:"ADMIN_PWD" env-default:"admin"`
 		}
 	}
+	// Proxy.Routes maps request path prefix to the base URL of the upstream service.
+	// Proxy.Policies maps request path prefix to the access policy: public, authenticated or internal.
+	// Proxy.RateLimits maps request path prefix to the limit in format "amount/unit", unit is one of s, m, h.
+	// Proxy.IPRateLimit is applied per client IP to every request before the token is verified
+	Proxy struct {
+		Timeout       time.Duration     `env:"PROXY_TIMEOUT" env-default:"10s"`
+		DefaultPolicy string            `env:"PROXY_DEFAULT_POLICY" env-default:"authenticated"`
+		Policies      map[string]string `env:"PROXY_POLICIES" env-default:"/api/auth:public,/api/auth/revocations:internal,/api/lobbies/time:internal,/api/tickets/use:internal,/api/tickets/release:internal,/api/manager:internal,/api/qualifications/time:internal,/api/training/time:internal,/api/users/internal:internal,/api/users/password/reset:public,/api/tickets/users:internal,/api/qualifications/users:internal,/api/training/users:internal"`
+		RateLimits    map[string]string `env:"PROXY_RATE_LIMITS" env-default:"/api:600/m,/api/auth/sign-in:5/m,/api/auth/sign-up:5/m,/api/auth/guest:5/m,/api/auth/claim:5/m,/api/users/password:5/m,/api/snake/res:30/m,/api/quiz/res:30/m"`
+		IPRateLimit   string            `env:"PROXY_IP_RATE_LIMIT" env-default:"1200/m"`
+		RateLimitTTL  time.Duration     `env:"PROXY_RATE_LIMIT_TTL" env-default:"1h"`
+		Routes        map[string]string `env:"PROXY_ROUTES" env-default:"/api/auth:http://localhost:10001,/api/users:http://localhost:10002,/api/training:http://localhost:10003,/api/tickets:http://localhost:10004,/api/prizes:http://localhost:10005,/api/lobbies:http://localhost:10006,/api/manager:http://localhost:10007,/api/snake:http://localhost:10008,/api/quiz:http://localhost:10009,/api/qualifications:http://localhost:10011"`
+	}
+	Docs struct {
+		TTL time.Duration `env:"DOCS_TTL" env-default:"5m"`
+	}
+	Keys struct {
+		JWKSURL string `env:"JWKS_URL" env-default:"http://localhost:10001/.well-known/jwks.json"`
+		// RevocationsURL is polled every RevocationsInterval, revoked tokens are rejected
+		RevocationsURL      string        `env:"REVOCATIONS_URL" env-default:"http://localhost:10001/api/auth/revocations"`
+		RevocationsInterval time.Duration `env:"REVOCATIONS_REFRESH_INTERVAL" env-default:"10s"`
+		// InternalKey signs identity headers forwarded to upstreams, it has no default
+		InternalKey string `env:"INTERNAL_KEY" env-required:"true"`
+	}
 }
 
 var instance *Config
@@ -39,6 +64,9 @@ func GetConfig() *Config {
 			log.Println(help)
 			log.Fatal(err)
 		}
+		if instance.Keys.InternalKey == "" {
+			log.Fatal("INTERNAL_KEY is empty")
+		}
 	})
 	return instance
 }
@@ -0,0 +1,230 @@
+package proxy
+
+import (
+	"api_gateway/internal/apperror"
+	"api_gateway/internal/config"
+	"api_gateway/internal/ratelimit"
+	jwt_setup "api_gateway/pkg/jwt-setup"
+	"api_gateway/pkg/logging"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const apiURL = "/api/*path"
+
+const (
+	RoleAdmin   = "admin"
+	RoleService = "service"
+)
+
+var methods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+type Handler struct {
+	Logger        logging.Logger
+	routes        []Route
+	policies      []Policy
+	defaultPolicy string
+	rateLimits    []RateLimit
+	ipRateLimit   ratelimit.Limit
+	limiter       ratelimit.Limiter
+	keys          keys
+	proxies       map[string]*httputil.ReverseProxy
+}
+
+type keys struct {
+	jwks        *jwt_setup.JWKS
+	revocations *jwt_setup.Revocations
+	internalKey string
+}
+
+// NewHandler creates reverse proxy for every route of the routing table.
+// Proxy.Timeout limits time the gateway waits for upstream to connect and to send response headers
+func NewHandler(cfg *config.Config, logger logging.Logger) (*Handler, error) {
+	routes, err := NewRoutes(cfg.Proxy.Routes)
+	if err != nil {
+		return nil, err
+	}
+	policies, err := NewPolicies(cfg.Proxy.Policies)
+	if err != nil {
+		return nil, err
+	}
+	if !validAccess(cfg.Proxy.DefaultPolicy) {
+		return nil, fmt.Errorf("unknown default access policy: %s", cfg.Proxy.DefaultPolicy)
+	}
+	rateLimits, err := NewRateLimits(cfg.Proxy.RateLimits)
+	if err != nil {
+		return nil, err
+	}
+	ipRateLimit, err := ratelimit.ParseLimit(cfg.Proxy.IPRateLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ip rate limit due to: %v", err)
+	}
+	timeout := cfg.Proxy.Timeout
+
+	h := &Handler{
+		Logger:        logger,
+		routes:        routes,
+		policies:      policies,
+		defaultPolicy: cfg.Proxy.DefaultPolicy,
+		rateLimits:    rateLimits,
+		ipRateLimit:   ipRateLimit,
+		limiter:       ratelimit.NewMemoryLimiter(cfg.Proxy.RateLimitTTL),
+		keys: keys{
+			jwks:        jwt_setup.NewJWKS(cfg.Keys.JWKSURL),
+			revocations: jwt_setup.NewRevocations(cfg.Keys.RevocationsURL, cfg.Keys.RevocationsInterval),
+			internalKey: cfg.Keys.InternalKey,
+		},
+		proxies: make(map[string]*httputil.ReverseProxy, len(routes)),
+	}
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   timeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		ResponseHeaderTimeout: timeout,
+	}
+	for _, route := range routes {
+		p := httputil.NewSingleHostReverseProxy(route.Upstream)
+		director := p.Director
+		p.Director = func(r *http.Request) {
+			director(r)
+			r.Header.Set("X-Forwarded-Host", r.Host)
+			if r.TLS != nil {
+				r.Header.Set("X-Forwarded-Proto", "https")
+			} else {
+				r.Header.Set("X-Forwarded-Proto", "http")
+			}
+		}
+		p.Transport = transport
+		p.ModifyResponse = stripCORSHeaders
+		p.ErrorHandler = h.errorHandler
+		h.proxies[route.Prefix] = p
+	}
+	return h, nil
+}
+
+func (h *Handler) Register(router *httprouter.Router) {
+	for _, method := range methods {
+		router.Handler(method, apiURL, h)
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route, found := Match(h.routes, r.URL.Path)
+	if !found {
+		apperror.WriteError(w, http.StatusNotFound, apperror.ErrNotFound)
+		return
+	}
+
+	// floods of anonymous requests and requests with invalid tokens are limited before the token is verified
+	if !h.allow(w, ipRateLimitKey(r), h.ipRateLimit) {
+		return
+	}
+
+	clearIdentity(r)
+	var userID string
+	switch MatchPolicy(h.policies, r.URL.Path, h.defaultPolicy) {
+	case PolicyInternal:
+		apperror.WriteError(w, http.StatusForbidden, apperror.ErrForbidden)
+		return
+	case PolicyAuthenticated:
+		var err error
+		userID, err = h.authenticate(r)
+		if err != nil {
+			h.Logger.Debugf("reject %s %s: %v", r.Method, r.URL.Path, err)
+			apperror.WriteError(w, http.StatusUnauthorized, apperror.ErrWrongToken)
+			return
+		}
+	case PolicyPublic:
+		if id, err := h.authenticate(r); err == nil {
+			userID = id
+		}
+	}
+	setIdentity(r, h.keys.internalKey, userID)
+
+	if rl, found := MatchRateLimit(h.rateLimits, r.URL.Path); found {
+		if !h.allow(w, rateLimitKey(rl.Prefix, userID, r), rl.Limit) {
+			return
+		}
+	}
+
+	h.Logger.Tracef("proxy %s %s to %s", r.Method, r.URL.Path, route.Upstream)
+	h.proxies[route.Prefix].ServeHTTP(w, r)
+}
+
+// allow takes a token from the bucket of the key, the request is answered with 429 if the bucket is empty
+func (h *Handler) allow(w http.ResponseWriter, key string, limit ratelimit.Limit) bool {
+	allowed, retryAfter := h.limiter.Allow(key, limit)
+	if !allowed {
+		h.Logger.Debugf("rate limit exceeded: %s", key)
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		apperror.WriteError(w, http.StatusTooManyRequests, apperror.ErrTooManyRequests)
+	}
+	return allowed
+}
+
+// authenticate verifies bearer token of the request and returns id of the user
+func (h *Handler) authenticate(r *http.Request) (string, error) {
+	claims, err := h.claims(r)
+	if err != nil {
+		return "", err
+	}
+	return claims.Id, nil
+}
+
+// HasRole reports whether the request has a valid bearer token with one of the roles
+func (h *Handler) HasRole(r *http.Request, roles ...string) bool {
+	claims, err := h.claims(r)
+	return err == nil && claims.HasRole(roles...)
+}
+
+func (h *Handler) claims(r *http.Request) (*jwt_setup.RegisteredClaims, error) {
+	headerVal := r.Header.Get("Authorization")
+	if headerVal == "" {
+		return nil, fmt.Errorf("no authorization header")
+	}
+	authHeaderArr := strings.Split(headerVal, " ")
+	if len(authHeaderArr) != 2 {
+		return nil, fmt.Errorf("malformed authorization header")
+	}
+	return jwt_setup.ParseClaims(authHeaderArr[1], h.keys.jwks, h.keys.revocations)
+}
+
+func (h *Handler) errorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	h.Logger.Errorf("failed to proxy %s %s due to: %v", r.Method, r.URL.Path, err)
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		apperror.WriteError(w, http.StatusGatewayTimeout, apperror.ErrGatewayTimeout)
+		return
+	}
+	apperror.WriteError(w, http.StatusBadGateway, apperror.ErrBadGateway)
+}
+
+// stripCORSHeaders removes CORS headers set by upstream services, the gateway sets its own ones
+func stripCORSHeaders(response *http.Response) error {
+	for header := range response.Header {
+		if strings.HasPrefix(header, "Access-Control-") {
+			response.Header.Del(header)
+		}
+	}
+	return nil
+}
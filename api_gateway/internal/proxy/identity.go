@@ -0,0 +1,50 @@
+package proxy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// UserIDHeader carries id of the user whose token was verified by the gateway
+	UserIDHeader = "X-User-ID"
+	// ClientIPHeader carries IP the gateway got the request from
+	ClientIPHeader = "X-Client-IP"
+	// SignatureHeader carries HMAC of user id, client IP and timestamp, signed with the internal key
+	SignatureHeader = "X-Gateway-Signature"
+	// TimestampHeader carries unix time the identity was signed at
+	TimestampHeader = "X-Gateway-Timestamp"
+)
+
+// SignIdentity returns hex encoded HMAC-SHA256 of "userID.clientIP.timestamp", user id is empty for
+// anonymous requests. Upstreams that know the internal key can recompute it to trust X-User-ID and X-Client-IP
+func SignIdentity(key, userID, clientIP string, timestamp int64) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(fmt.Sprintf("%s.%s.%d", userID, clientIP, timestamp)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// setIdentity sets signed identity headers of the request, X-User-ID is set only for the verified user
+func setIdentity(r *http.Request, key, userID string) {
+	timestamp := time.Now().Unix()
+	ip := clientIP(r)
+	if userID != "" {
+		r.Header.Set(UserIDHeader, userID)
+	}
+	r.Header.Set(ClientIPHeader, ip)
+	r.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	r.Header.Set(SignatureHeader, SignIdentity(key, userID, ip, timestamp))
+}
+
+// clearIdentity removes identity headers sent by the client, so they can't be spoofed
+func clearIdentity(r *http.Request) {
+	r.Header.Del(UserIDHeader)
+	r.Header.Del(ClientIPHeader)
+	r.Header.Del(TimestampHeader)
+	r.Header.Del(SignatureHeader)
+}
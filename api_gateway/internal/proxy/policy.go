@@ -0,0 +1,59 @@
+package proxy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// PolicyPublic routes are proxied without token. If the token is valid, identity is still forwarded
+	PolicyPublic = "public"
+	// PolicyAuthenticated routes require valid bearer token
+	PolicyAuthenticated = "authenticated"
+	// PolicyInternal routes are called by services directly and are never proxied
+	PolicyInternal = "internal"
+)
+
+// Policy sets access policy for every request path starting with Prefix
+type Policy struct {
+	Prefix string
+	Access string
+}
+
+// NewPolicies builds a policy table from prefix -> access pairs.
+// Policies are sorted by prefix length so the longest prefix wins
+func NewPolicies(table map[string]string) ([]Policy, error) {
+	policies := make([]Policy, 0, len(table))
+	for prefix, access := range table {
+		if !validAccess(access) {
+			return nil, fmt.Errorf("unknown access policy %s of route %s", access, prefix)
+		}
+		policies = append(policies, Policy{
+			Prefix: strings.TrimSuffix(prefix, "/"),
+			Access: access,
+		})
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		return len(policies[i].Prefix) > len(policies[j].Prefix)
+	})
+	return policies, nil
+}
+
+// MatchPolicy returns access policy of the path or defaultAccess if no policy matches
+func MatchPolicy(policies []Policy, path, defaultAccess string) string {
+	for _, policy := range policies {
+		if matchPrefix(policy.Prefix, path) {
+			return policy.Access
+		}
+	}
+	return defaultAccess
+}
+
+func validAccess(access string) bool {
+	switch access {
+	case PolicyPublic, PolicyAuthenticated, PolicyInternal:
+		return true
+	}
+	return false
+}
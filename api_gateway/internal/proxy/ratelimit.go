@@ -0,0 +1,68 @@
+package proxy
+
+import (
+	"api_gateway/internal/ratelimit"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// RateLimit limits requests to every path starting with Prefix.
+// Requests are counted per user for authenticated requests and per client IP for anonymous ones
+type RateLimit struct {
+	Prefix string
+	Limit  ratelimit.Limit
+}
+
+// NewRateLimits builds a rate limit table from prefix -> "amount/unit" pairs.
+// Rate limits are sorted by prefix length so the longest prefix wins
+func NewRateLimits(table map[string]string) ([]RateLimit, error) {
+	limits := make([]RateLimit, 0, len(table))
+	for prefix, value := range table {
+		limit, err := ratelimit.ParseLimit(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse rate limit of route %s due to: %v", prefix, err)
+		}
+		limits = append(limits, RateLimit{
+			Prefix: strings.TrimSuffix(prefix, "/"),
+			Limit:  limit,
+		})
+	}
+	sort.Slice(limits, func(i, j int) bool {
+		return len(limits[i].Prefix) > len(limits[j].Prefix)
+	})
+	return limits, nil
+}
+
+// MatchRateLimit finds the rate limit with the longest prefix matching the path
+func MatchRateLimit(limits []RateLimit, path string) (RateLimit, bool) {
+	for _, limit := range limits {
+		if matchPrefix(limit.Prefix, path) {
+			return limit, true
+		}
+	}
+	return RateLimit{}, false
+}
+
+// rateLimitKey returns key of the bucket: user id if the request is authenticated, client IP otherwise
+func rateLimitKey(prefix, userID string, r *http.Request) string {
+	if userID != "" {
+		return fmt.Sprintf("%s|user:%s", prefix, userID)
+	}
+	return fmt.Sprintf("%s|ip:%s", prefix, clientIP(r))
+}
+
+// ipRateLimitKey returns key of the bucket of the client IP checked before authentication
+func ipRateLimitKey(r *http.Request) string {
+	return fmt.Sprintf("ip:%s", clientIP(r))
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
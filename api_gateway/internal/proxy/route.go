@@ -0,0 +1,57 @@
+package proxy
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Route binds a request path prefix to an upstream service
+type Route struct {
+	Prefix   string
+	Upstream *url.URL
+}
+
+// Matches reports whether the path belongs to the route.
+// Prefix is matched on path segment boundary, so /api/users does not match /api/usersettings
+func (r Route) Matches(path string) bool {
+	return matchPrefix(r.Prefix, path)
+}
+
+func matchPrefix(prefix, path string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// NewRoutes builds a routing table from prefix -> upstream URL pairs.
+// Routes are sorted by prefix length so the longest prefix wins
+func NewRoutes(table map[string]string) ([]Route, error) {
+	routes := make([]Route, 0, len(table))
+	for prefix, upstream := range table {
+		u, err := url.Parse(upstream)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse upstream url of route %s due to: %v", prefix, err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("upstream url of route %s must be absolute: %s", prefix, upstream)
+		}
+		routes = append(routes, Route{
+			Prefix:   strings.TrimSuffix(prefix, "/"),
+			Upstream: u,
+		})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return len(routes[i].Prefix) > len(routes[j].Prefix)
+	})
+	return routes, nil
+}
+
+// Match finds the route with the longest prefix matching the path
+func Match(routes []Route, path string) (Route, bool) {
+	for _, route := range routes {
+		if route.Matches(path) {
+			return route, true
+		}
+	}
+	return Route{}, false
+}
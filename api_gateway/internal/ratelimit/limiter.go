@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limiter decides whether request identified by the key may pass.
+// In-memory implementation works for a single gateway instance,
+// a shared store implementation is needed when the gateway is scaled
+type Limiter interface {
+	// Allow takes a token from the bucket of the key.
+	// If the bucket is empty it returns false and time after which the next token is available
+	Allow(key string, limit Limit) (allowed bool, retryAfter time.Duration)
+}
+
+// Limit describes token bucket: it holds up to Burst tokens and is refilled with Rate tokens per second
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// ParseLimit parses limit in format "amount/unit", unit is one of s, m, h. E.g. "5/m" allows 5 requests per minute
+func ParseLimit(s string) (Limit, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 {
+		return Limit{}, fmt.Errorf("wrong limit format: %s", s)
+	}
+	amount, err := strconv.Atoi(parts[0])
+	if err != nil || amount <= 0 {
+		return Limit{}, fmt.Errorf("wrong limit amount: %s", s)
+	}
+	var period time.Duration
+	switch parts[1] {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		return Limit{}, fmt.Errorf("wrong limit unit: %s", s)
+	}
+	return Limit{
+		Rate:  float64(amount) / period.Seconds(),
+		Burst: amount,
+	}, nil
+}
@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+var _ Limiter = &memoryLimiter{}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type memoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	idleTTL time.Duration
+}
+
+// NewMemoryLimiter creates in-memory token bucket limiter.
+// Buckets which were not used for idleTTL are removed, an absent bucket is considered full
+func NewMemoryLimiter(idleTTL time.Duration) Limiter {
+	l := &memoryLimiter{
+		buckets: make(map[string]*bucket),
+		idleTTL: idleTTL,
+	}
+	go l.cleanup()
+	return l
+}
+
+func (l *memoryLimiter) Allow(key string, limit Limit) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b, found := l.buckets[key]
+	if !found {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	retryAfter := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	return false, retryAfter
+}
+
+func (l *memoryLimiter) cleanup() {
+	ticker := time.NewTicker(l.idleTTL)
+	defer ticker.Stop()
+	for range ticker.C {
+		l.mu.Lock()
+		for key, b := range l.buckets {
+			if time.Since(b.last) > l.idleTTL {
+				delete(l.buckets, key)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
@@ -0,0 +1,54 @@
+package jwt_setup
+
+import (
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+type RegisteredClaims struct {
+	jwt.RegisteredClaims
+	Id    string   `json:"id"`
+	Roles []string `json:"roles"`
+}
+
+// HasRole reports whether the token has one of the roles
+func (c *RegisteredClaims) HasRole(roles ...string) bool {
+	for _, role := range roles {
+		for _, r := range c.Roles {
+			if r == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ParseToken validates token signature with public keys of the auth service, expiration and revocation,
+// returns id of the user the token was issued to
+func ParseToken(tokenString string, keys *JWKS, revocations *Revocations) (userId string, err error) {
+	claims, err := ParseClaims(tokenString, keys, revocations)
+	if err != nil {
+		return "", err
+	}
+	return claims.Id, nil
+}
+
+// ParseClaims validates the token like ParseToken and returns its claims
+func ParseClaims(tokenString string, keys *JWKS, revocations *Revocations) (*RegisteredClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &RegisteredClaims{}, keys.Keyfunc)
+	if err != nil {
+		return nil, fmt.Errorf("wrong token: %v", err)
+	}
+
+	claims, ok := token.Claims.(*RegisteredClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("wrong token")
+	}
+	if revocations.Revoked(claims.RegisteredClaims) {
+		return nil, fmt.Errorf("wrong token: revoked")
+	}
+	if claims.Id == "" {
+		return nil, fmt.Errorf("wrong token: no user id")
+	}
+	return claims, nil
+}
@@ -1,17 +1,36 @@
 package metrics
 
 import (
+	"context"
 	"github.com/julienschmidt/httprouter"
 	"net/http"
+	"sync"
+	"time"
 )
 
-const HeartbeatURL = "/userapi/heartbeat"
+const (
+	HeartbeatURL = "/userapi/heartbeat"
+	ReadyURL     = "/health/ready"
 
+	// readyTTL limits how often upstreams are checked, however many requests the endpoint gets
+	readyTTL = 5 * time.Second
+)
+
+// Handler serves liveness and readiness metrics.
+// Routes maps route prefix to the base URL of the upstream service, readiness of every upstream is checked.
+// Authorized reports whether the caller may see the status matrix, others get only the status
 type Handler struct {
+	Routes     map[string]string
+	Authorized func(r *http.Request) bool
+
+	mu        sync.Mutex
+	ready     ReadyResponse
+	checkedAt time.Time
 }
 
 func (h *Handler) Register(router *httprouter.Router) {
 	router.HandlerFunc(http.MethodGet, HeartbeatURL, h.Heartbeat)
+	router.HandlerFunc(http.MethodGet, ReadyURL, h.Ready)
 }
 
 // Heartbeat
@@ -24,3 +43,38 @@ func (h *Handler) Heartbeat(w http.ResponseWriter, r *http.Request) {
 	// Check if the server is up
 	w.WriteHeader(204)
 }
+
+// Ready
+// @Summary Readiness of all services. Calls readiness endpoint of every upstream, the result is cached for 5 seconds. Status matrix with upstream URLs and errors is returned only to admin and service tokens
+// @Tags Metrics
+// @Produce json
+// @Param Authorization header string false "Bearer token of admin or service to get the status matrix"
+// @Success 200 {object} ReadyResponse
+// @Failure 503 {object} ReadyResponse
+// @Router /health/ready [get]
+func (h *Handler) Ready(w http.ResponseWriter, r *http.Request) {
+	response := h.check()
+	if h.Authorized == nil || !h.Authorized(r) {
+		response = ReadyResponse{Status: response.Status}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if response.Status != StatusOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.Write(response.Marshal())
+}
+
+// check returns the cached result or checks upstreams again once it's older than readyTTL.
+// Upstreams are checked without the request context, so a canceled request doesn't cache a failure
+func (h *Handler) check() ReadyResponse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if time.Since(h.checkedAt) < readyTTL {
+		return h.ready
+	}
+	h.ready = CheckUpstreams(context.Background(), h.Routes)
+	h.checkedAt = time.Now()
+	return h.ready
+}
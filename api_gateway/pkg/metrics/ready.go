@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+
+	checkTimeout = 5 * time.Second
+)
+
+type CheckResult struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// UpstreamResult is readiness of the upstream service together with its own checks
+type UpstreamResult struct {
+	Status    string                 `json:"status"`
+	LatencyMS int64                  `json:"latency_ms"`
+	Routes    []string               `json:"routes"`
+	Error     string                 `json:"error,omitempty"`
+	Checks    map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type ReadyResponse struct {
+	Status    string                    `json:"status"`
+	Upstreams map[string]UpstreamResult `json:"upstreams,omitempty"`
+}
+
+func (r ReadyResponse) Marshal() []byte {
+	bytes, err := json.Marshal(r)
+	if err != nil {
+		return nil
+	}
+	return bytes
+}
+
+// CheckUpstreams calls readiness endpoint of every upstream concurrently.
+// Upstreams serving several routes are called once
+func CheckUpstreams(ctx context.Context, routes map[string]string) ReadyResponse {
+	upstreams := make(map[string][]string)
+	for prefix, upstream := range routes {
+		upstreams[upstream] = append(upstreams[upstream], prefix)
+	}
+
+	response := ReadyResponse{
+		Status:    StatusOK,
+		Upstreams: make(map[string]UpstreamResult, len(upstreams)),
+	}
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	client := http.Client{Timeout: checkTimeout}
+	for upstream, prefixes := range upstreams {
+		wg.Add(1)
+		go func(upstream string, prefixes []string) {
+			defer wg.Done()
+			sort.Strings(prefixes)
+
+			start := time.Now()
+			checks, err := checkUpstream(ctx, client, upstream)
+			result := UpstreamResult{
+				Status:    StatusOK,
+				LatencyMS: time.Since(start).Milliseconds(),
+				Routes:    prefixes,
+				Checks:    checks,
+			}
+			if err != nil {
+				result.Status = StatusFail
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			response.Upstreams[upstream] = result
+			if err != nil {
+				response.Status = StatusFail
+			}
+		}(upstream, prefixes)
+	}
+	wg.Wait()
+	return response
+}
+
+func checkUpstream(ctx context.Context, client http.Client, upstream string) (map[string]CheckResult, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, upstream+ReadyURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build a request due to: %v", err)
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to do a request due to: %v", err)
+	}
+	defer response.Body.Close()
+
+	var dto struct {
+		Checks map[string]CheckResult `json:"checks"`
+	}
+	if err = json.NewDecoder(response.Body).Decode(&dto); err != nil {
+		return nil, fmt.Errorf("failed to decode readiness response due to: %v", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return dto.Checks, fmt.Errorf("service isn't ready, status code: %d", response.StatusCode)
+	}
+	return dto.Checks, nil
+}
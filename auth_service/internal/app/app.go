@@ -1,9 +1,19 @@
 package app
 
 import (
+	"auth_service/internal/attempt"
+	"auth_service/internal/client"
 	"auth_service/internal/config"
+	"auth_service/internal/jwks"
+	"auth_service/internal/oidc"
+	oidcdb "auth_service/internal/oidc/db"
+	"auth_service/internal/token"
+	tokendb "auth_service/internal/token/db"
 	"auth_service/internal/user"
 	"auth_service/internal/user/userapi"
+	"auth_service/pkg/client/mongodb"
+	"auth_service/pkg/client/userservice"
+	jwt_setup "auth_service/pkg/jwt-setup"
 	"auth_service/pkg/logging"
 	"auth_service/pkg/metrics"
 	"context"
@@ -35,19 +45,97 @@ func NewApp(cfg *config.Config, logger *logging.Logger) (App, error) {
 	router.Handler(http.MethodGet, "/swagger", http.RedirectHandler("/swagger/index.html", http.StatusMovedPermanently))
 	router.Handler(http.MethodGet, "/swagger/*any", httpSwagger.WrapHandler)
 
+	mongodbClient, err := mongodb.NewClient(context.Background(), cfg.MongoDB.Host, cfg.MongoDB.Port,
+		cfg.MongoDB.Username, cfg.MongoDB.Password, cfg.MongoDB.Database, cfg.MongoDB.AuthDB)
+	if err != nil {
+		panic(err)
+	}
+
 	logger.Println("heartbeat metric initializing")
-	metricHandler := metrics.Handler{}
+	metricHandler := metrics.Handler{
+		Checks: map[string]metrics.Check{
+			"mongodb":      mongodb.Ping(mongodbClient),
+			"user_service": metrics.HTTPCheck(cfg.UserService.URL),
+		},
+	}
 	metricHandler.Register(router)
 
-	storage := userapi.NewStorage(logger)
-	service := user.NewService(storage, logger)
+	logger.Println("signing keys initializing")
+	keys, err := jwt_setup.LoadKeys(cfg.Keys.Dir)
+	if err != nil {
+		return App{}, err
+	}
+	if cfg.Keys.RotationInterval > 0 {
+		// retired key is kept until all access tokens signed by it expire
+		go keys.RunRotation(cfg.Keys.RotationInterval, cfg.Tokens.AccessTTL, func(err error) {
+			logger.Errorf("failed to rotate signing keys due to: %v", err)
+		})
+	}
+
+	jwksHandler := jwks.Handler{
+		Logger: logging.GetLogger(cfg.AppConfig.LogLevel),
+		Keys:   keys,
+	}
+	jwksHandler.Register(router)
+
+	clientService := client.NewService(keys, cfg, logger)
+	clientsHandler := client.Handler{
+		Logger:        logging.GetLogger(cfg.AppConfig.LogLevel),
+		ClientService: clientService,
+	}
+	clientsHandler.Register(router)
+
+	userServiceClient := userservice.NewClient(userservice.Config{
+		BaseURL: cfg.UserService.URL,
+		Timeout: cfg.UserService.Timeout,
+		Retries: cfg.UserService.Retries,
+		Backoff: cfg.UserService.Backoff,
+		Token:   client.NewSelfSource(keys, cfg).Token,
+	})
+	storage := userapi.NewStorage(userServiceClient, logger)
+	tokenStorage := tokendb.NewStorage(mongodbClient, "refresh_tokens", logger)
+	revocationStorage := tokendb.NewRevocationStorage(mongodbClient, "revocations", logger)
+	tokenService := token.NewService(tokenStorage, revocationStorage, storage, keys, cfg, logger)
+
+	tokensHandler := token.Handler{
+		Logger:       logging.GetLogger(cfg.AppConfig.LogLevel),
+		TokenService: tokenService,
+	}
+	tokensHandler.Register(router)
+
+	usernameAttempts := attempt.NewMemoryTracker(attempt.Policy{
+		MaxAttempts: cfg.Lockout.MaxAttempts,
+		BaseLockout: cfg.Lockout.BaseDuration,
+		MaxLockout:  cfg.Lockout.MaxDuration,
+	}, cfg.Lockout.ResetAfter)
+	ipAttempts := attempt.NewMemoryTracker(attempt.Policy{
+		MaxAttempts: cfg.Lockout.IPMaxAttempts,
+		BaseLockout: cfg.Lockout.BaseDuration,
+		MaxLockout:  cfg.Lockout.MaxDuration,
+	}, cfg.Lockout.ResetAfter)
+	service := user.NewService(storage, tokenService, cfg.AppConfig.LegacySignIn, usernameAttempts, ipAttempts, logger)
 
 	usersHandler := user.Handler{
 		Logger:      logging.GetLogger(cfg.AppConfig.LogLevel),
 		AuthService: service,
+		InternalKey: cfg.InternalKey,
 	}
 	usersHandler.Register(router)
 
+	providers := make([]oidc.Provider, 0, len(cfg.OIDC.Issuers))
+	for name, issuer := range cfg.OIDC.Issuers {
+		providers = append(providers, oidc.NewProvider(name, issuer, cfg.OIDC.ClientIDs[name], cfg.OIDC.ClientSecrets[name],
+			fmt.Sprintf("%s/%s/callback", cfg.OIDC.RedirectBaseURL, name)))
+		logger.Infof("oidc provider %s of issuer %s is enabled", name, issuer)
+	}
+	oidcStorage := oidcdb.NewStorage(mongodbClient, "oidc_states", logger)
+	oidcService := oidc.NewService(providers, oidcStorage, storage, tokenService, cfg.OIDC.StateTTL, logger)
+	oidcHandler := oidc.Handler{
+		Logger:      logging.GetLogger(cfg.AppConfig.LogLevel),
+		OIDCService: oidcService,
+	}
+	oidcHandler.Register(router)
+
 	return App{
 		cfg,
 		logger,
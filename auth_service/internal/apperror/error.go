@@ -2,10 +2,19 @@ package apperror
 
 import (
 	"encoding/json"
+	"errors"
 )
 
 var (
-	ErrNotFound = NewAppError(nil, "not found", "NS-000003", "")
+	ErrNotFound      = NewAppError(nil, "not found", "NS-000003", "")
+	ErrWrongToken    = NewAppError(nil, "wrong token", "NS-000004", "")
+	ErrUnauthorized  = NewAppError(nil, "wrong username or password", "NS-000009", "")
+	ErrConflict      = NewAppError(nil, "already exists", "NS-000010", "")
+	ErrInvalidClient = NewAppError(nil, "invalid client", "NS-000011", "unknown client id or wrong client secret")
+	ErrForbidden     = NewAppError(nil, "forbidden", "NS-000007", "token has no role required for the request")
+	ErrBadGateway    = NewAppError(nil, "bad gateway", "NS-000005", "identity provider is unavailable or responded with invalid data")
+	ErrTooManyTries  = NewAppError(nil, "too many failed attempts", "NS-000008", "sign in is locked, retry after the time in Retry-After header")
+	ErrBanned        = NewAppError(errors.New("user is banned"), "user is banned", "NS-000013", "")
 )
 
 type AppError struct {
@@ -45,3 +54,8 @@ func BadRequestError(message string) *AppError {
 func systemError(developerMessage string) *AppError {
 	return NewAppError(nil, "system error", "NS-000001", developerMessage)
 }
+
+// BannedError tells the user why and until when the user is banned
+func BannedError(message string) *AppError {
+	return NewAppError(ErrBanned, message, ErrBanned.Code, "")
+}
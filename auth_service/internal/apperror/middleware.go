@@ -20,6 +20,46 @@ func Middleware(h appHandler) http.HandlerFunc {
 					w.Write(ErrNotFound.Marshal())
 					return
 				}
+				if errors.Is(err, ErrWrongToken) {
+					w.WriteHeader(http.StatusUnauthorized)
+					w.Write(ErrWrongToken.Marshal())
+					return
+				}
+				if errors.Is(err, ErrUnauthorized) {
+					w.WriteHeader(http.StatusUnauthorized)
+					w.Write(ErrUnauthorized.Marshal())
+					return
+				}
+				if errors.Is(err, ErrInvalidClient) {
+					w.WriteHeader(http.StatusUnauthorized)
+					w.Write(ErrInvalidClient.Marshal())
+					return
+				}
+				if errors.Is(err, ErrForbidden) {
+					w.WriteHeader(http.StatusForbidden)
+					w.Write(ErrForbidden.Marshal())
+					return
+				}
+				if errors.Is(err, ErrBadGateway) {
+					w.WriteHeader(http.StatusBadGateway)
+					w.Write(ErrBadGateway.Marshal())
+					return
+				}
+				if errors.Is(err, ErrTooManyTries) {
+					w.WriteHeader(http.StatusTooManyRequests)
+					w.Write(ErrTooManyTries.Marshal())
+					return
+				}
+				if errors.Is(err, ErrConflict) {
+					w.WriteHeader(http.StatusConflict)
+					w.Write(ErrConflict.Marshal())
+					return
+				}
+				if errors.Is(err, ErrBanned) {
+					w.WriteHeader(http.StatusForbidden)
+					w.Write(appErr.Marshal())
+					return
+				}
 				err := err.(*AppError)
 				w.WriteHeader(http.StatusBadRequest)
 				w.Write(err.Marshal())
@@ -0,0 +1,89 @@
+package attempt
+
+import (
+	"sync"
+	"time"
+)
+
+var _ Tracker = &memoryTracker{}
+
+type entry struct {
+	failures    int
+	lockouts    int
+	lockedUntil time.Time
+	lastFailure time.Time
+}
+
+type memoryTracker struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+	policy  Policy
+	// resetAfter is how long a key without failures keeps its lockout count
+	resetAfter time.Duration
+}
+
+// NewMemoryTracker creates in-memory tracker. Keys without failures for resetAfter are forgotten,
+// so their next lockout starts from BaseLockout again
+func NewMemoryTracker(policy Policy, resetAfter time.Duration) Tracker {
+	t := &memoryTracker{
+		entries:    make(map[string]*entry),
+		policy:     policy,
+		resetAfter: resetAfter,
+	}
+	go t.cleanup()
+	return t
+}
+
+func (t *memoryTracker) Locked(key string) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e, found := t.entries[key]
+	if !found {
+		return 0, false
+	}
+	left := time.Until(e.lockedUntil)
+	return left, left > 0
+}
+
+func (t *memoryTracker) Fail(key string) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	e, found := t.entries[key]
+	if !found || now.Sub(e.lastFailure) > t.resetAfter {
+		e = &entry{}
+		t.entries[key] = e
+	}
+	e.lastFailure = now
+	e.failures++
+	if e.failures < t.policy.MaxAttempts {
+		return 0, false
+	}
+	e.failures = 0
+	e.lockouts++
+	lockout := t.policy.lockout(e.lockouts)
+	e.lockedUntil = now.Add(lockout)
+	return lockout, true
+}
+
+func (t *memoryTracker) Reset(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.entries, key)
+}
+
+func (t *memoryTracker) cleanup() {
+	ticker := time.NewTicker(t.resetAfter)
+	defer ticker.Stop()
+	for range ticker.C {
+		t.mu.Lock()
+		for key, e := range t.entries {
+			if time.Since(e.lastFailure) > t.resetAfter && time.Now().After(e.lockedUntil) {
+				delete(t.entries, key)
+			}
+		}
+		t.mu.Unlock()
+	}
+}
@@ -0,0 +1,53 @@
+package attempt
+
+import (
+	"fmt"
+	"time"
+)
+
+// Tracker counts failed sign in attempts per key (username or client IP) and locks the key
+// when there are too many of them. Every next lockout of the key is twice as long
+type Tracker interface {
+	// Locked returns time left until the key is unlocked
+	Locked(key string) (time.Duration, bool)
+	// Fail registers failed attempt and returns lockout duration if the key got locked
+	Fail(key string) (time.Duration, bool)
+	// Reset forgets failed attempts and lockouts of the key
+	Reset(key string)
+}
+
+// Policy is a number of failed attempts which locks the key and lockout duration bounds
+type Policy struct {
+	MaxAttempts int
+	BaseLockout time.Duration
+	MaxLockout  time.Duration
+}
+
+// lockout returns duration of n-th lockout: BaseLockout * 2^(n-1) but not more than MaxLockout
+func (p Policy) lockout(n int) time.Duration {
+	d := p.BaseLockout
+	for i := 1; i < n && d < p.MaxLockout; i++ {
+		d *= 2
+	}
+	if d > p.MaxLockout {
+		return p.MaxLockout
+	}
+	return d
+}
+
+// LockedError is returned when sign in is rejected because username or client IP is locked
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e LockedError) Error() string {
+	return fmt.Sprintf("too many failed attempts, retry after %s", e.RetryAfter)
+}
+
+func UsernameKey(username string) string {
+	return fmt.Sprintf("user:%s", username)
+}
+
+func IPKey(ip string) string {
+	return fmt.Sprintf("ip:%s", ip)
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"auth_service/internal/apperror"
+	"auth_service/pkg/logging"
+	"encoding/json"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+)
+
+const tokenURL = "/api/auth/token"
+
+type Handler struct {
+	Logger        logging.Logger
+	ClientService Service
+}
+
+func (h *Handler) Register(router *httprouter.Router) {
+	router.HandlerFunc(http.MethodPost, tokenURL, apperror.Middleware(h.Token))
+}
+
+// Service token
+// @Summary Client credentials grant. Issues token with service audience and service role for service-to-service calls.
+// @Description Client id and secret are passed with HTTP Basic auth or as client_id and client_secret form fields
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "client_credentials"
+// @Tags Auth
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} apperror.AppError
+// @Failure 401 {object} apperror.AppError
+// @Router /api/auth/token [post]
+func (h *Handler) Token(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Println("POST SERVICE TOKEN")
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := r.ParseForm(); err != nil {
+		return apperror.BadRequestError("invalid form data")
+	}
+	if grantType := r.PostForm.Get("grant_type"); grantType != grantTypeClientCredentials {
+		return apperror.BadRequestError(fmt.Sprintf("unsupported grant type: %s", grantType))
+	}
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID = r.PostForm.Get("client_id")
+		clientSecret = r.PostForm.Get("client_secret")
+	}
+
+	response, err := h.ClientService.Token(r.Context(), clientID, clientSecret)
+	if err != nil {
+		return err
+	}
+	bytes, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("failed to marshal token response due to: %v", err)
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(bytes)
+	return nil
+}
@@ -0,0 +1,10 @@
+package client
+
+const grantTypeClientCredentials = "client_credentials"
+
+// TokenResponse is OAuth 2.0 access token response, service tokens are not refreshed
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
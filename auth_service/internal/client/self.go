@@ -0,0 +1,45 @@
+package client
+
+import (
+	"auth_service/internal/config"
+	jwt_setup "auth_service/pkg/jwt-setup"
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// SelfClientID is subject of service tokens the auth service issues to itself
+	SelfClientID = "auth_service"
+	// expiryMargin is how long before expiration the token is renewed
+	expiryMargin = 30 * time.Second
+)
+
+// SelfSource issues service tokens of the auth service for its calls to other services.
+// It signs them with its own keys, so no client credentials are needed. Tokens are cached until they expire
+type SelfSource struct {
+	keys      *jwt_setup.KeySet
+	cfg       *config.Config
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func NewSelfSource(keys *jwt_setup.KeySet, cfg *config.Config) *SelfSource {
+	return &SelfSource{keys: keys, cfg: cfg}
+}
+
+func (s *SelfSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && time.Now().Before(s.expiresAt.Add(-expiryMargin)) {
+		return s.token, nil
+	}
+	token, err := jwt_setup.CreateServiceToken(s.cfg, s.keys, SelfClientID)
+	if err != nil {
+		return "", err
+	}
+	s.token = token
+	s.expiresAt = time.Now().Add(s.cfg.Tokens.ServiceTTL)
+	return s.token, nil
+}
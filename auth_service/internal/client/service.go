@@ -0,0 +1,51 @@
+package client
+
+import (
+	"auth_service/internal/apperror"
+	"auth_service/internal/config"
+	jwt_setup "auth_service/pkg/jwt-setup"
+	"auth_service/pkg/logging"
+	"context"
+	"crypto/subtle"
+	"fmt"
+)
+
+var _ Service = &service{}
+
+type service struct {
+	clients map[string]string
+	keys    *jwt_setup.KeySet
+	cfg     *config.Config
+	logger  *logging.Logger
+}
+
+func NewService(keys *jwt_setup.KeySet, cfg *config.Config, logger *logging.Logger) Service {
+	return &service{
+		clients: cfg.Clients,
+		keys:    keys,
+		cfg:     cfg,
+		logger:  logger,
+	}
+}
+
+type Service interface {
+	Token(ctx context.Context, clientID, clientSecret string) (TokenResponse, error)
+}
+
+// Token issues service token if client id and client secret match SERVICE_CLIENTS
+func (s service) Token(ctx context.Context, clientID, clientSecret string) (TokenResponse, error) {
+	secret, found := s.clients[clientID]
+	if !found || subtle.ConstantTimeCompare([]byte(secret), []byte(clientSecret)) != 1 {
+		s.logger.Warnf("failed client credentials attempt of client %q", clientID)
+		return TokenResponse{}, apperror.ErrInvalidClient
+	}
+	token, err := jwt_setup.CreateServiceToken(s.cfg, s.keys, clientID)
+	if err != nil {
+		return TokenResponse{}, fmt.Errorf("unable to create service token due to: %v", err)
+	}
+	return TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.cfg.Tokens.ServiceTTL.Seconds()),
+	}, nil
+}
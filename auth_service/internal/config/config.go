@@ -4,6 +4,7 @@ import (
 	"github.com/ilyakaznacheev/cleanenv"
 	"log"
 	"sync"
+	"time"
 )
 
 type Config struct {
@@ -16,14 +17,63 @@ type Config struct {
 		Port       string `env:"PORT" env-default:"10001"`
 	}
 	AppConfig struct {
-		LogLevel  string `env:"LOG_LEVEL" env-default:"trace"`
-		AdminUser struct {
+		LogLevel string `env:"LOG_LEVEL" env-default:"trace"`
+		// LegacySignIn makes sign in create unknown users like old app versions expect
+		LegacySignIn bool `env:"LEGACY_SIGN_IN" env-default:"false"`
+		AdminUser    struct {
 			Email    string `env:"ADMIN_EMAIL" env-default:"admin"`
 			Password string `env:"ADMIN_PWD" env-default:"admin"`
 		}
 	}
+	MongoDB struct {
+		Host     string `env:"HOST" env-default:"localhost"`
+		Port     string `env:"PORT" env-default:"27017"`
+		Username string `env:"ADMIN_USERNAME"`
+		Password string `env:"ADMIN_PASSWORD"`
+		Database string `env:"DATABASE" env-default:"auth-service"`
+		AuthDB   string `env:"AUTH_DB"`
+	}
+	Tokens struct {
+		AccessTTL  time.Duration `env:"ACCESS_TOKEN_TTL" env-default:"15m"`
+		RefreshTTL time.Duration `env:"REFRESH_TOKEN_TTL" env-default:"720h"`
+		ServiceTTL time.Duration `env:"SERVICE_TOKEN_TTL" env-default:"15m"`
+	}
+	// Lockout locks username or client IP for BaseDuration after MaxAttempts failed sign in attempts,
+	// every next lockout is twice as long up to MaxDuration
+	Lockout struct {
+		MaxAttempts   int           `env:"LOCKOUT_MAX_ATTEMPTS" env-default:"5"`
+		IPMaxAttempts int           `env:"LOCKOUT_IP_MAX_ATTEMPTS" env-default:"20"`
+		BaseDuration  time.Duration `env:"LOCKOUT_BASE_DURATION" env-default:"30s"`
+		MaxDuration   time.Duration `env:"LOCKOUT_MAX_DURATION" env-default:"1h"`
+		ResetAfter    time.Duration `env:"LOCKOUT_RESET_AFTER" env-default:"24h"`
+	}
+	// InternalKey verifies X-Client-IP signed by the gateway, it's INTERNAL_KEY of the gateway and has no default
+	InternalKey string `env:"INTERNAL_KEY" env-required:"true"`
+	// Clients are services allowed to get service tokens with client credentials, client_id:client_secret pairs.
+	// They have no default, the secrets are given only in the environment
+	Clients map[string]string `env:"SERVICE_CLIENTS" env-required:"true"`
+	// UserService is called to create and authorize users, idempotent requests are retried
+	// Retries times with Backoff doubled after every attempt
+	UserService struct {
+		URL     string        `env:"USER_SERVICE_URL" env-default:"http://localhost:10002"`
+		Timeout time.Duration `env:"USER_SERVICE_TIMEOUT" env-default:"5s"`
+		Retries int           `env:"USER_SERVICE_RETRIES" env-default:"2"`
+		Backoff time.Duration `env:"USER_SERVICE_BACKOFF" env-default:"100ms"`
+	}
+	// OIDC providers are configured by name: name:issuer, name:client_id and name:client_secret pairs.
+	// Callback of a provider is RedirectBaseURL/name/callback
+	OIDC struct {
+		Issuers         map[string]string `env:"OIDC_ISSUERS"`
+		ClientIDs       map[string]string `env:"OIDC_CLIENT_IDS"`
+		ClientSecrets   map[string]string `env:"OIDC_CLIENT_SECRETS"`
+		RedirectBaseURL string            `env:"OIDC_REDIRECT_BASE_URL" env-default:"http://localhost:10000/api/auth/oidc"`
+		StateTTL        time.Duration     `env:"OIDC_STATE_TTL" env-default:"10m"`
+	}
 	Keys struct {
-		JWTSignKey string `env:"JWT_SIGN_KEY" env-default:"alsfjak12h4i1h2uas7f7241231o1u2io5u12asopua0w9812"`
+		// Dir contains PEM encoded RSA keys, the newest one signs tokens
+		Dir string `env:"JWT_KEYS_DIR" env-default:"keys"`
+		// RotationInterval is how often a new signing key is generated, 0 disables rotation
+		RotationInterval time.Duration `env:"JWT_KEY_ROTATION_INTERVAL" env-default:"0"`
 	}
 }
 
@@ -42,6 +92,17 @@ func GetConfig() *Config {
 			log.Println(help)
 			log.Fatal(err)
 		}
+		if instance.InternalKey == "" {
+			log.Fatal("INTERNAL_KEY is empty")
+		}
+		if len(instance.Clients) == 0 {
+			log.Fatal("SERVICE_CLIENTS is empty")
+		}
+		for id, secret := range instance.Clients {
+			if id == "" || secret == "" {
+				log.Fatalf("SERVICE_CLIENTS has empty client id or secret of client %q", id)
+			}
+		}
 	})
 	return instance
 }
@@ -0,0 +1,41 @@
+package jwks
+
+import (
+	"auth_service/internal/apperror"
+	"auth_service/pkg/jwt-setup" // swag resolves types of the package only if the import isn't aliased
+	"auth_service/pkg/logging"
+	"encoding/json"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+)
+
+const jwksURL = "/.well-known/jwks.json"
+
+type Handler struct {
+	Logger logging.Logger
+	Keys   *jwt_setup.KeySet
+}
+
+func (h *Handler) Register(router *httprouter.Router) {
+	router.HandlerFunc(http.MethodGet, jwksURL, apperror.Middleware(h.GetJWKS))
+}
+
+// Get JWKS
+// @Summary Public keys to verify access tokens. Tokens have kid header of the key they are signed with
+// @Produce json
+// @Tags Auth
+// @Success 200 {object} jwt_setup.JWKSet
+// @Router /.well-known/jwks.json [get]
+func (h *Handler) GetJWKS(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+
+	bytes, err := json.Marshal(h.Keys.JWKS())
+	if err != nil {
+		return fmt.Errorf("failed to marshal jwks due to: %v", err)
+	}
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	w.Write(bytes)
+	return nil
+}
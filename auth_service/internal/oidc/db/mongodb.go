@@ -0,0 +1,60 @@
+package db
+
+import (
+	"auth_service/internal/apperror"
+	"auth_service/internal/oidc"
+	"auth_service/pkg/logging"
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type db struct {
+	collection *mongo.Collection
+	logger     *logging.Logger
+}
+
+func (d *db) Create(ctx context.Context, state oidc.LoginState) error {
+	_, err := d.collection.InsertOne(ctx, state)
+	if err != nil {
+		return fmt.Errorf("failed to create login state due to: %v", err)
+	}
+	return nil
+}
+
+func (d *db) Take(ctx context.Context, hash string) (state oidc.LoginState, err error) {
+	filter := bson.M{"hash": hash}
+	result := d.collection.FindOneAndDelete(ctx, filter)
+	if result.Err() != nil {
+		if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+			return state, apperror.ErrNotFound
+		}
+		return state, fmt.Errorf("failed to take login state due to: %v", result.Err())
+	}
+	if err = result.Decode(&state); err != nil {
+		return state, fmt.Errorf("failed to decode login state from DB due to: %v", err)
+	}
+	return state, nil
+}
+
+// NewStorage creates TTL index, so states of abandoned logins are removed by MongoDB
+func NewStorage(database *mongo.Database, collection string, logger *logging.Logger) oidc.Storage {
+	d := &db{
+		collection: database.Collection(collection),
+		logger:     logger,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := d.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expire_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		logger.Errorf("failed to create ttl index on login states due to: %v", err)
+	}
+	return d
+}
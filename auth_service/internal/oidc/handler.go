@@ -0,0 +1,81 @@
+package oidc
+
+import (
+	"auth_service/internal/apperror"
+	"auth_service/internal/token"
+	"auth_service/pkg/logging"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strings"
+)
+
+const (
+	loginURL    = "/api/auth/oidc/:provider/login"
+	callbackURL = "/api/auth/oidc/:provider/callback"
+)
+
+type Handler struct {
+	Logger      logging.Logger
+	OIDCService Service
+}
+
+func (h *Handler) Register(router *httprouter.Router) {
+	router.HandlerFunc(http.MethodGet, loginURL, apperror.Middleware(h.Login))
+	router.HandlerFunc(http.MethodGet, callbackURL, apperror.Middleware(h.Callback))
+}
+
+// Login
+// @Summary Start sign in with external identity provider (authorization code flow with PKCE). Redirects to the provider
+// @Description With access token of a signed in user the identity is linked to the user instead of signing in another one
+// @Param provider path string true "Provider name"
+// @Param Authorization header string false "Bearer access token to link the identity"
+// @Tags Auth
+// @Success 302
+// @Failure 403 {object} apperror.AppError
+// @Failure 404 {object} apperror.AppError
+// @Failure 502 {object} apperror.AppError
+// @Router /api/auth/oidc/{provider}/login [get]
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Println("GET OIDC LOGIN")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	var accessToken string
+	if authHeaderArr := strings.Split(r.Header.Get("Authorization"), " "); len(authHeaderArr) == 2 {
+		accessToken = authHeaderArr[1]
+	}
+	authURL, err := h.OIDCService.Login(r.Context(), params.ByName("provider"), accessToken)
+	if err != nil {
+		return err
+	}
+	http.Redirect(w, r, authURL, http.StatusFound)
+	return nil
+}
+
+// Callback
+// @Summary Provider redirects here with the code, responds with tokens of the user of the identity
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State of the login"
+// @Tags Auth
+// @Success 200 {object} token.Pair
+// @Failure 400 {object} apperror.AppError
+// @Failure 404 {object} apperror.AppError
+// @Failure 409 {object} apperror.AppError
+// @Failure 502 {object} apperror.AppError
+// @Router /api/auth/oidc/{provider}/callback [get]
+func (h *Handler) Callback(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Println("GET OIDC CALLBACK")
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	if providerErr := query.Get("error"); providerErr != "" {
+		return apperror.BadRequestError("provider rejected the sign in: " + providerErr)
+	}
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	pair, err := h.OIDCService.Callback(r.Context(), params.ByName("provider"), query.Get("code"), query.Get("state"))
+	if err != nil {
+		return err
+	}
+	return token.WritePair(w, http.StatusOK, pair)
+}
@@ -0,0 +1,57 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// LoginState is stored between redirect to the provider and callback. The client gets only the state,
+// its hash is stored. PKCE verifier and nonce never leave the auth service
+type LoginState struct {
+	ID           string `json:"id" bson:"_id,omitempty"`
+	Hash         string `json:"-" bson:"hash"`
+	Provider     string `json:"provider" bson:"provider"`
+	Nonce        string `json:"-" bson:"nonce"`
+	CodeVerifier string `json:"-" bson:"code_verifier"`
+	// LinkUserID is set when signed in user links the identity to the account
+	LinkUserID string    `json:"link_user_id,omitempty" bson:"link_user_id,omitempty"`
+	ExpireAt   time.Time `json:"expire_at" bson:"expire_at"`
+}
+
+// Identity is a verified account of the provider
+type Identity struct {
+	Provider          string
+	Subject           string
+	Email             string
+	PreferredUsername string
+}
+
+// IdentityDTO is sent to user service, username is a hint for a new user
+type IdentityDTO struct {
+	Provider string `json:"provider"`
+	Subject  string `json:"subject"`
+	Username string `json:"username"`
+}
+
+func newRandomString() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate random string due to: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// codeChallenge is S256 PKCE challenge of the verifier
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func hashState(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
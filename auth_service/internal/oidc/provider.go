@@ -0,0 +1,198 @@
+package oidc
+
+import (
+	jwt_setup "auth_service/pkg/jwt-setup"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	discoveryPath   = "/.well-known/openid-configuration"
+	providerTimeout = 10 * time.Second
+)
+
+// Provider is an external identity provider. Login redirects the user to AuthCodeURL,
+// the provider redirects back with the code which is exchanged for the verified identity
+type Provider interface {
+	Name() string
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (Identity, error)
+}
+
+var _ Provider = &discoveryProvider{}
+
+// discoveryProvider is OpenID Connect provider configured with discovery document of the issuer
+type discoveryProvider struct {
+	name         string
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	client       *http.Client
+
+	mu       sync.Mutex
+	metadata *metadata
+	keys     *jwt_setup.JWKS
+}
+
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce             string `json:"nonce"`
+	Email             string `json:"email"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+// NewProvider creates provider of the issuer. Discovery document is fetched on the first login,
+// so the auth service starts when the provider is unavailable
+func NewProvider(name, issuer, clientID, clientSecret, redirectURL string) Provider {
+	return &discoveryProvider{
+		name:         name,
+		issuer:       strings.TrimSuffix(issuer, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		client:       &http.Client{Timeout: providerTimeout},
+	}
+}
+
+func (p *discoveryProvider) Name() string {
+	return p.name
+}
+
+func (p *discoveryProvider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.clientID},
+		"redirect_uri":          {p.redirectURL},
+		"scope":                 {"openid profile email"},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(md.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return md.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// Exchange redeems the code with PKCE verifier and verifies id token: signature, issuer, audience, expiration and nonce
+func (p *discoveryProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (Identity, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return Identity{}, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.redirectURL},
+		"code_verifier": {codeVerifier},
+		"client_id":     {p.clientID},
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, md.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Identity{}, fmt.Errorf("failed to build token request due to: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.clientSecret))
+	response, err := p.client.Do(request)
+	if err != nil {
+		return Identity{}, fmt.Errorf("failed to do token request due to: %v", err)
+	}
+	defer response.Body.Close()
+
+	var tr tokenResponse
+	if err := json.NewDecoder(response.Body).Decode(&tr); err != nil {
+		return Identity{}, fmt.Errorf("failed to decode token response due to: %v", err)
+	}
+	if response.StatusCode != http.StatusOK || tr.Error != "" {
+		return Identity{}, fmt.Errorf("provider rejected the code, status: %d, error: %s %s", response.StatusCode, tr.Error, tr.ErrorDescription)
+	}
+	if tr.IDToken == "" {
+		return Identity{}, fmt.Errorf("provider responded without id token")
+	}
+
+	claims := &idTokenClaims{}
+	_, err = jwt.ParseWithClaims(tr.IDToken, claims, p.keys.Keyfunc)
+	if err != nil {
+		return Identity{}, fmt.Errorf("failed to verify id token due to: %v", err)
+	}
+	if claims.Issuer != md.Issuer {
+		return Identity{}, fmt.Errorf("id token has wrong issuer: %s", claims.Issuer)
+	}
+	if !claims.VerifyAudience(p.clientID, true) {
+		return Identity{}, fmt.Errorf("id token is issued for another client")
+	}
+	if claims.Nonce != nonce {
+		return Identity{}, fmt.Errorf("id token has wrong nonce")
+	}
+	if claims.Subject == "" {
+		return Identity{}, fmt.Errorf("id token has no subject")
+	}
+	return Identity{
+		Provider:          p.name,
+		Subject:           claims.Subject,
+		Email:             claims.Email,
+		PreferredUsername: claims.PreferredUsername,
+	}, nil
+}
+
+// discover fetches discovery document once, failed attempts are retried on the next login
+func (p *discoveryProvider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, p.issuer+discoveryPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build discovery request due to: %v", err)
+	}
+	response, err := p.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch discovery document of %s due to: %v", p.name, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch discovery document of %s, status: %d", p.name, response.StatusCode)
+	}
+	var md metadata
+	if err := json.NewDecoder(response.Body).Decode(&md); err != nil {
+		return nil, fmt.Errorf("failed to decode discovery document of %s due to: %v", p.name, err)
+	}
+	if strings.TrimSuffix(md.Issuer, "/") != p.issuer {
+		return nil, fmt.Errorf("discovery document of %s has wrong issuer: %s", p.name, md.Issuer)
+	}
+	if md.AuthorizationEndpoint == "" || md.TokenEndpoint == "" || md.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery document of %s misses endpoints", p.name)
+	}
+	p.metadata = &md
+	p.keys = jwt_setup.NewJWKS(md.JWKSURI)
+	return p.metadata, nil
+}
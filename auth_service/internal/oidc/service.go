@@ -0,0 +1,176 @@
+package oidc
+
+import (
+	"auth_service/internal/apperror"
+	"auth_service/internal/token"
+	jwt_setup "auth_service/pkg/jwt-setup"
+	"auth_service/pkg/logging"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var _ Service = &service{}
+
+const (
+	usernameMaxLength = 32
+	usernameMinLength = 3
+)
+
+var usernameDisallowed = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+type service struct {
+	providers map[string]Provider
+	storage   Storage
+	users     Users
+	tokens    token.Service
+	stateTTL  time.Duration
+	logger    *logging.Logger
+}
+
+func NewService(providers []Provider, storage Storage, users Users, tokens token.Service, stateTTL time.Duration, logger *logging.Logger) Service {
+	s := &service{
+		providers: make(map[string]Provider, len(providers)),
+		storage:   storage,
+		users:     users,
+		tokens:    tokens,
+		stateTTL:  stateTTL,
+		logger:    logger,
+	}
+	for _, p := range providers {
+		s.providers[p.Name()] = p
+	}
+	return s
+}
+
+// Users finds or creates user of the identity in user service
+type Users interface {
+	SignInWithIdentity(ctx context.Context, dto IdentityDTO) (uuid string, err error)
+	LinkIdentity(ctx context.Context, uuid string, dto IdentityDTO) error
+}
+
+type Service interface {
+	// Login returns URL of the provider to redirect the user to. If access token is given
+	// the identity is linked to its user after the callback
+	Login(ctx context.Context, providerName, accessToken string) (string, error)
+	Callback(ctx context.Context, providerName, code, state string) (token.Pair, error)
+}
+
+func (s service) Login(ctx context.Context, providerName, accessToken string) (string, error) {
+	provider, found := s.providers[providerName]
+	if !found {
+		return "", apperror.ErrNotFound
+	}
+
+	var linkUserID string
+	if accessToken != "" {
+		claims, err := s.tokens.Verify(ctx, accessToken)
+		if err != nil {
+			return "", err
+		}
+		// guests get a full account with claim, service tokens have no user
+		if claims.HasRole(token.RoleGuest, jwt_setup.RoleService) {
+			return "", apperror.ErrForbidden
+		}
+		linkUserID = claims.Id
+	}
+
+	state, err := newRandomString()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := newRandomString()
+	if err != nil {
+		return "", err
+	}
+	verifier, err := newRandomString()
+	if err != nil {
+		return "", err
+	}
+	err = s.storage.Create(ctx, LoginState{
+		Hash:         hashState(state),
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		LinkUserID:   linkUserID,
+		ExpireAt:     time.Now().Add(s.stateTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, codeChallenge(verifier))
+	if err != nil {
+		s.logger.Errorf("failed to start login with %s due to: %v", providerName, err)
+		return "", apperror.ErrBadGateway
+	}
+	return authURL, nil
+}
+
+// Callback exchanges the code for identity of the provider and issues tokens of its user.
+// Unknown identity gets a new user, unless login was started to link it to signed in user
+func (s service) Callback(ctx context.Context, providerName, code, state string) (pair token.Pair, err error) {
+	provider, found := s.providers[providerName]
+	if !found {
+		return pair, apperror.ErrNotFound
+	}
+	if code == "" || state == "" {
+		return pair, apperror.BadRequestError("code and state are required")
+	}
+	loginState, err := s.storage.Take(ctx, hashState(state))
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return pair, apperror.BadRequestError("unknown or expired state, start the login again")
+		}
+		return pair, err
+	}
+	if loginState.Provider != providerName || time.Now().After(loginState.ExpireAt) {
+		return pair, apperror.BadRequestError("unknown or expired state, start the login again")
+	}
+
+	identity, err := provider.Exchange(ctx, code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		s.logger.Warnf("failed to sign in with %s due to: %v", providerName, err)
+		return pair, apperror.ErrBadGateway
+	}
+	dto := IdentityDTO{
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Username: usernameHint(identity),
+	}
+
+	uuid := loginState.LinkUserID
+	if uuid != "" {
+		err = s.users.LinkIdentity(ctx, uuid, dto)
+		if err != nil {
+			return pair, err
+		}
+		s.logger.Infof("identity of %s is linked to user %s", providerName, uuid)
+	} else {
+		uuid, err = s.users.SignInWithIdentity(ctx, dto)
+		if err != nil {
+			return pair, err
+		}
+	}
+	return s.tokens.Issue(ctx, uuid)
+}
+
+// usernameHint makes username for a new user from preferred username or email of the identity
+func usernameHint(identity Identity) string {
+	hint := identity.PreferredUsername
+	if hint == "" {
+		hint = strings.Split(identity.Email, "@")[0]
+	}
+	hint = usernameDisallowed.ReplaceAllString(hint, "")
+	if len(hint) > usernameMaxLength {
+		hint = hint[:usernameMaxLength]
+	}
+	// guest prefix is reserved for guests
+	if len(hint) < usernameMinLength || strings.HasPrefix(strings.ToLower(hint), "guest-") {
+		return fmt.Sprintf("%s-player", identity.Provider)
+	}
+	return hint
+}
@@ -0,0 +1,9 @@
+package oidc
+
+import "context"
+
+type Storage interface {
+	Create(ctx context.Context, state LoginState) error
+	// Take finds state by hash and deletes it, so the state can be used once
+	Take(ctx context.Context, hash string) (LoginState, error)
+}
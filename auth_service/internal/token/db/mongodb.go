@@ -0,0 +1,88 @@
+package db
+
+import (
+	"auth_service/internal/apperror"
+	"auth_service/internal/token"
+	"auth_service/pkg/logging"
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type db struct {
+	collection *mongo.Collection
+	logger     *logging.Logger
+}
+
+func (d *db) Create(ctx context.Context, t token.RefreshToken) (string, error) {
+	result, err := d.collection.InsertOne(ctx, t)
+	if err != nil {
+		return "", fmt.Errorf("failed to create refresh token due to: %v", err)
+	}
+	oid, ok := result.InsertedID.(primitive.ObjectID)
+	if ok {
+		return oid.Hex(), nil
+	}
+	return "", fmt.Errorf("failed to convert objectId to hex. probable oid: %s", oid)
+}
+
+func (d *db) FindByHash(ctx context.Context, hash string) (t token.RefreshToken, err error) {
+	filter := bson.M{"hash": hash}
+	result := d.collection.FindOne(ctx, filter)
+	if result.Err() != nil {
+		if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+			return t, apperror.ErrNotFound
+		}
+		return t, fmt.Errorf("failed to find refresh token due to: %v", result.Err())
+	}
+	if err = result.Decode(&t); err != nil {
+		return t, fmt.Errorf("failed to decode refresh token from DB due to: %v", err)
+	}
+	return t, nil
+}
+
+func (d *db) MarkUsed(ctx context.Context, id string) (bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, fmt.Errorf("failed to convert refresh token ID to ObjectID. ID=%v", id)
+	}
+	filter := bson.M{"_id": objectID, "used": false}
+	update := bson.M{"$set": bson.M{"used": true}}
+	result, err := d.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to execute update refresh token query due to: %v", err)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (d *db) RevokeFamily(ctx context.Context, familyID string) error {
+	filter := bson.M{"family_id": familyID}
+	update := bson.M{"$set": bson.M{"revoked": true}}
+	result, err := d.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to execute revoke refresh tokens query due to: %v", err)
+	}
+	d.logger.Tracef("Revoked %d refresh tokens of family %s", result.ModifiedCount, familyID)
+	return nil
+}
+
+func (d *db) RevokeUser(ctx context.Context, userID string) error {
+	filter := bson.M{"user_id": userID}
+	update := bson.M{"$set": bson.M{"revoked": true}}
+	result, err := d.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to execute revoke refresh tokens query due to: %v", err)
+	}
+	d.logger.Tracef("Revoked %d refresh tokens of user %s", result.ModifiedCount, userID)
+	return nil
+}
+
+func NewStorage(database *mongo.Database, collection string, logger *logging.Logger) token.Storage {
+	return &db{
+		collection: database.Collection(collection),
+		logger:     logger,
+	}
+}
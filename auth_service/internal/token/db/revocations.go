@@ -0,0 +1,56 @@
+package db
+
+import (
+	"auth_service/internal/token"
+	"auth_service/pkg/logging"
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type revocations struct {
+	collection *mongo.Collection
+	logger     *logging.Logger
+}
+
+func (d *revocations) Create(ctx context.Context, revocation token.Revocation) error {
+	_, err := d.collection.InsertOne(ctx, revocation)
+	if err != nil {
+		return fmt.Errorf("failed to create revocation due to: %v", err)
+	}
+	return nil
+}
+
+// FindAll returns revocations which are not expired yet, MongoDB removes expired ones with a delay
+func (d *revocations) FindAll(ctx context.Context) (list []token.Revocation, err error) {
+	filter := bson.M{"expire_at": bson.M{"$gt": time.Now()}}
+	cursor, err := d.collection.Find(ctx, filter)
+	if err != nil {
+		return list, fmt.Errorf("failed to find revocations due to: %v", err)
+	}
+	if err := cursor.All(ctx, &list); err != nil {
+		return list, fmt.Errorf("failed to read revocations from cursor due to: %v", err)
+	}
+	return list, nil
+}
+
+// NewRevocationStorage creates TTL index, revocations are removed when revoked tokens expire
+func NewRevocationStorage(database *mongo.Database, collection string, logger *logging.Logger) token.RevocationStorage {
+	d := &revocations{
+		collection: database.Collection(collection),
+		logger:     logger,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := d.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expire_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		logger.Errorf("failed to create ttl index on revocations due to: %v", err)
+	}
+	return d
+}
@@ -0,0 +1,157 @@
+package token
+
+import (
+	"auth_service/internal/apperror"
+	"auth_service/pkg/logging"
+	"encoding/json"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strings"
+)
+
+const (
+	refreshURL     = "/api/auth/refresh"
+	signOutURL     = "/api/auth/sign-out"
+	revokeURL      = "/api/auth/revoke"
+	revocationsURL = "/api/auth/revocations"
+)
+
+type Handler struct {
+	Logger       logging.Logger
+	TokenService Service
+}
+
+func (h *Handler) Register(router *httprouter.Router) {
+	router.HandlerFunc(http.MethodPost, refreshURL, apperror.Middleware(h.Refresh))
+	router.HandlerFunc(http.MethodPost, signOutURL, apperror.Middleware(h.SignOut))
+	router.HandlerFunc(http.MethodPost, revokeURL, apperror.Middleware(h.RevokeAccess))
+	router.HandlerFunc(http.MethodGet, revocationsURL, apperror.Middleware(h.GetRevocations))
+}
+
+// Refresh
+// @Summary Exchange refresh token for a new access token and refresh token
+// @Accept json
+// @Produce json
+// @Param data body RefreshTokenDTO true "refresh token"
+// @Tags Auth
+// @Success 200 {object} Pair
+// @Failure 400
+// @Failure 401 {object} apperror.AppError
+// @Router /api/auth/refresh [post]
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Println("POST REFRESH")
+	w.Header().Set("Content-Type", "application/json")
+
+	var dto RefreshTokenDTO
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+	pair, err := h.TokenService.Refresh(r.Context(), dto.RefreshToken)
+	if err != nil {
+		return err
+	}
+	return WritePair(w, http.StatusOK, pair)
+}
+
+// Sign out
+// @Summary Revoke refresh token and all tokens rotated from the same sign in
+// @Accept json
+// @Produce json
+// @Param data body RefreshTokenDTO true "refresh token"
+// @Tags Auth
+// @Success 204
+// @Failure 400
+// @Failure 401 {object} apperror.AppError
+// @Router /api/auth/sign-out [post]
+func (h *Handler) SignOut(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Println("POST SIGN OUT")
+	w.Header().Set("Content-Type", "application/json")
+
+	var dto RefreshTokenDTO
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+	err := h.TokenService.Revoke(r.Context(), dto.RefreshToken)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// Revoke access tokens
+// @Summary Revoke access token by jti or all tokens of the user issued before the time (now by default).
+// @Description Refresh tokens of the user are revoked too. Available for operators, admins and services
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer access token of operator or admin or service token"
+// @Param data body RevokeDTO true "jti or user_id with optional before (unix time)"
+// @Tags Auth
+// @Success 204
+// @Failure 400 {object} apperror.AppError
+// @Failure 401 {object} apperror.AppError
+// @Failure 403 {object} apperror.AppError
+// @Router /api/auth/revoke [post]
+func (h *Handler) RevokeAccess(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Println("POST REVOKE")
+	w.Header().Set("Content-Type", "application/json")
+
+	authHeaderArr := strings.Split(r.Header.Get("Authorization"), " ")
+	if len(authHeaderArr) != 2 {
+		return apperror.ErrWrongToken
+	}
+	var dto RevokeDTO
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+	err := h.TokenService.RevokeAccess(r.Context(), authHeaderArr[1], dto)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// Get revocations
+// @Summary Revoked access tokens which are not expired yet. Services poll it, the gateway doesn't expose it
+// @Produce json
+// @Tags Auth
+// @Success 200 {object} RevocationList
+// @Router /api/auth/revocations [get]
+func (h *Handler) GetRevocations(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+
+	list, err := h.TokenService.Revocations(r.Context())
+	if err != nil {
+		return err
+	}
+	bytes, err := json.Marshal(list)
+	if err != nil {
+		return fmt.Errorf("failed to marshal revocations due to: %v", err)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(bytes)
+	return nil
+}
+
+// WritePair writes token pair in Authorization and Refresh-Token headers and in response body
+func WritePair(w http.ResponseWriter, statusCode int, pair Pair) error {
+	return WriteResponse(w, statusCode, pair, pair)
+}
+
+// WriteResponse writes token pair in Authorization and Refresh-Token headers and the body which contains the pair
+func WriteResponse(w http.ResponseWriter, statusCode int, pair Pair, body interface{}) error {
+	bytes, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal token pair due to: %v", err)
+	}
+	w.Header().Set("Authorization", fmt.Sprintf("Bearer %s", pair.AccessToken))
+	w.Header().Set("Refresh-Token", pair.RefreshToken)
+	w.WriteHeader(statusCode)
+	w.Write(bytes)
+	return nil
+}
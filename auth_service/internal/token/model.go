@@ -0,0 +1,52 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// RefreshToken is stored server-side, the client gets only the opaque token, its hash is stored.
+// Every refresh creates a new token of the same family and marks the old one as used.
+// If a used token is presented again, the whole family is revoked
+type RefreshToken struct {
+	ID        string `json:"id" bson:"_id,omitempty"`
+	Hash      string `json:"-" bson:"hash"`
+	UserID    string `json:"user_id" bson:"user_id"`
+	FamilyID  string `json:"family_id" bson:"family_id"`
+	Used      bool   `json:"used" bson:"used"`
+	Revoked   bool   `json:"revoked" bson:"revoked"`
+	CreatedAt int64  `json:"created_at" bson:"created_at"`
+	ExpiresAt int64  `json:"expires_at" bson:"expires_at"`
+}
+
+func (t RefreshToken) Expired() bool {
+	return time.Now().Unix() >= t.ExpiresAt
+}
+
+// Pair is returned to the client on sign in and refresh
+type Pair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+type RefreshTokenDTO struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func newOpaqueToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate refresh token due to: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
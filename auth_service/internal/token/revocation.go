@@ -0,0 +1,47 @@
+package token
+
+import (
+	jwt_setup "auth_service/pkg/jwt-setup"
+	"context"
+	"time"
+)
+
+// Revocation revokes one access token by jti or all tokens of the user issued before the time.
+// It's kept until revoked tokens expire
+type Revocation struct {
+	ID       string    `json:"id" bson:"_id,omitempty"`
+	JTI      string    `json:"jti,omitempty" bson:"jti,omitempty"`
+	UserID   string    `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	Before   int64     `json:"before,omitempty" bson:"before,omitempty"`
+	ExpireAt time.Time `json:"expire_at" bson:"expire_at"`
+}
+
+// RevokeDTO has either jti of the token or id of the user. Before defaults to now
+type RevokeDTO struct {
+	JTI    string `json:"jti"`
+	UserID string `json:"user_id"`
+	Before int64  `json:"before"`
+}
+
+// RevocationList is polled by services. Users maps user id to time, tokens issued at or before it are revoked
+type RevocationList struct {
+	JTIs        []string         `json:"jtis"`
+	Users       map[string]int64 `json:"users"`
+	GeneratedAt int64            `json:"generated_at"`
+}
+
+// Revoked reports whether the token is revoked by jti or by time of the user
+func (l RevocationList) Revoked(claims *jwt_setup.Claims) bool {
+	for _, jti := range l.JTIs {
+		if jti == claims.ID {
+			return true
+		}
+	}
+	before, found := l.Users[claims.Subject]
+	return found && claims.IssuedAt != nil && claims.IssuedAt.Unix() <= before
+}
+
+type RevocationStorage interface {
+	Create(ctx context.Context, revocation Revocation) error
+	FindAll(ctx context.Context) ([]Revocation, error)
+}
@@ -0,0 +1,276 @@
+package token
+
+import (
+	"auth_service/internal/apperror"
+	"auth_service/internal/config"
+	jwt_setup "auth_service/pkg/jwt-setup"
+	"auth_service/pkg/logging"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var _ Service = &service{}
+
+const (
+	RolePlayer   = "player"
+	RoleGuest    = "guest"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+type service struct {
+	storage     Storage
+	revocations RevocationStorage
+	users       Users
+	keys        *jwt_setup.KeySet
+	cfg         *config.Config
+	logger      *logging.Logger
+}
+
+func NewService(storage Storage, revocations RevocationStorage, users Users, keys *jwt_setup.KeySet, cfg *config.Config, logger *logging.Logger) Service {
+	return &service{
+		storage:     storage,
+		revocations: revocations,
+		users:       users,
+		keys:        keys,
+		cfg:         cfg,
+		logger:      logger,
+	}
+}
+
+// Users provides roles and bans of the user. Both are read on every issue and refresh, so granted roles
+// get into tokens without signing in again and a banned user can't get new tokens
+type Users interface {
+	FindRoles(ctx context.Context, userID string) ([]string, error)
+	CheckBan(ctx context.Context, userID string) error
+}
+
+type Service interface {
+	Issue(ctx context.Context, userID string) (Pair, error)
+	Refresh(ctx context.Context, refreshToken string) (Pair, error)
+	Revoke(ctx context.Context, refreshToken string) error
+	RevokeUser(ctx context.Context, userID string) error
+	Verify(ctx context.Context, accessToken string) (*jwt_setup.Claims, error)
+	RevokeAccess(ctx context.Context, accessToken string, dto RevokeDTO) error
+	Revocations(ctx context.Context) (RevocationList, error)
+}
+
+// Issue creates access token and refresh token of a new family
+func (s service) Issue(ctx context.Context, userID string) (Pair, error) {
+	familyID, err := newOpaqueToken()
+	if err != nil {
+		return Pair{}, err
+	}
+	return s.issue(ctx, userID, familyID)
+}
+
+// Refresh exchanges refresh token for a new pair. Refresh token can be used only once,
+// if a used token is presented, it's considered stolen and the whole family is revoked
+func (s service) Refresh(ctx context.Context, refreshToken string) (Pair, error) {
+	t, err := s.find(ctx, refreshToken)
+	if err != nil {
+		return Pair{}, err
+	}
+	if t.Used {
+		s.revokeReused(ctx, t)
+		return Pair{}, apperror.ErrWrongToken
+	}
+
+	marked, err := s.storage.MarkUsed(ctx, t.ID)
+	if err != nil {
+		return Pair{}, err
+	}
+	// token was used by a concurrent request
+	if !marked {
+		s.revokeReused(ctx, t)
+		return Pair{}, apperror.ErrWrongToken
+	}
+	return s.issue(ctx, t.UserID, t.FamilyID)
+}
+
+// Revoke revokes the whole family of the refresh token
+func (s service) Revoke(ctx context.Context, refreshToken string) error {
+	t, err := s.find(ctx, refreshToken)
+	if err != nil {
+		return err
+	}
+	err = s.storage.RevokeFamily(ctx, t.FamilyID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token due to: %v", err)
+	}
+	return nil
+}
+
+// RevokeUser revokes all refresh tokens of the user
+func (s service) RevokeUser(ctx context.Context, userID string) error {
+	err := s.storage.RevokeUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens of user %s due to: %v", userID, err)
+	}
+	return nil
+}
+
+// Verify checks access token issued by this service and not revoked
+func (s service) Verify(ctx context.Context, accessToken string) (*jwt_setup.Claims, error) {
+	claims, err := jwt_setup.ParseToken(s.keys, accessToken)
+	if err != nil {
+		return nil, apperror.ErrWrongToken
+	}
+	list, err := s.Revocations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if list.Revoked(claims) {
+		return nil, apperror.ErrWrongToken
+	}
+	return claims, nil
+}
+
+// RevokeAccess revokes access token by jti or all tokens of the user, refresh tokens of the user are revoked too.
+// Available for operators, admins and services, user service revokes tokens of banned users
+func (s service) RevokeAccess(ctx context.Context, accessToken string, dto RevokeDTO) error {
+	claims, err := s.Verify(ctx, accessToken)
+	if err != nil {
+		return err
+	}
+	if !claims.HasRole(RoleOperator, RoleAdmin, jwt_setup.RoleService) {
+		return apperror.ErrForbidden
+	}
+	if (dto.JTI == "") == (dto.UserID == "") {
+		return apperror.BadRequestError("either jti or user_id is required")
+	}
+
+	now := time.Now()
+	// revoked tokens can't outlive the longest token ttl
+	ttl := s.cfg.Tokens.AccessTTL
+	if s.cfg.Tokens.ServiceTTL > ttl {
+		ttl = s.cfg.Tokens.ServiceTTL
+	}
+	revocation := Revocation{JTI: dto.JTI, UserID: dto.UserID}
+	if dto.UserID != "" {
+		revocation.Before = dto.Before
+		if revocation.Before == 0 || revocation.Before > now.Unix() {
+			revocation.Before = now.Unix()
+		}
+	}
+	revocation.ExpireAt = now.Add(ttl)
+	err = s.revocations.Create(ctx, revocation)
+	if err != nil {
+		return err
+	}
+	s.logger.ExtraFields(map[string]interface{}{
+		"audit":   "token_revocation",
+		"by":      claims.Id,
+		"jti":     dto.JTI,
+		"user_id": dto.UserID,
+	}).Warn("access tokens revoked")
+
+	if dto.UserID != "" {
+		return s.RevokeUser(ctx, dto.UserID)
+	}
+	return nil
+}
+
+// Revocations returns revocations of tokens which are not expired yet
+func (s service) Revocations(ctx context.Context) (RevocationList, error) {
+	revocations, err := s.revocations.FindAll(ctx)
+	if err != nil {
+		return RevocationList{}, fmt.Errorf("failed to find revocations due to: %v", err)
+	}
+	list := RevocationList{
+		JTIs:        []string{},
+		Users:       map[string]int64{},
+		GeneratedAt: time.Now().Unix(),
+	}
+	for _, r := range revocations {
+		if r.JTI != "" {
+			list.JTIs = append(list.JTIs, r.JTI)
+		}
+		if r.UserID != "" && r.Before > list.Users[r.UserID] {
+			list.Users[r.UserID] = r.Before
+		}
+	}
+	return list, nil
+}
+
+// find returns refresh token if it's known, not revoked and not expired
+func (s service) find(ctx context.Context, refreshToken string) (RefreshToken, error) {
+	if refreshToken == "" {
+		return RefreshToken{}, apperror.ErrWrongToken
+	}
+	t, err := s.storage.FindByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return t, apperror.ErrWrongToken
+		}
+		return t, fmt.Errorf("failed to find refresh token due to: %v", err)
+	}
+	if t.Revoked || t.Expired() {
+		return t, apperror.ErrWrongToken
+	}
+	return t, nil
+}
+
+func (s service) revokeReused(ctx context.Context, t RefreshToken) {
+	s.logger.Warnf("refresh token reuse detected, revoke token family %s of user %s", t.FamilyID, t.UserID)
+	if err := s.storage.RevokeFamily(ctx, t.FamilyID); err != nil {
+		s.logger.Errorf("failed to revoke token family %s due to: %v", t.FamilyID, err)
+	}
+}
+
+func (s service) issue(ctx context.Context, userID, familyID string) (Pair, error) {
+	err := s.users.CheckBan(ctx, userID)
+	if err != nil {
+		if errors.Is(err, apperror.ErrBanned) {
+			// the family is revoked, so the user signs in again after the ban ends
+			if err := s.storage.RevokeFamily(ctx, familyID); err != nil {
+				s.logger.Errorf("failed to revoke token family %s due to: %v", familyID, err)
+			}
+			return Pair{}, err
+		}
+		return Pair{}, fmt.Errorf("unable to check ban of user %s due to: %v", userID, err)
+	}
+	roles, err := s.users.FindRoles(ctx, userID)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			// user was deleted, tokens of the family must not be refreshed anymore
+			if err := s.storage.RevokeFamily(ctx, familyID); err != nil {
+				s.logger.Errorf("failed to revoke token family %s due to: %v", familyID, err)
+			}
+			return Pair{}, apperror.ErrWrongToken
+		}
+		return Pair{}, fmt.Errorf("unable to find roles of user %s due to: %v", userID, err)
+	}
+	// users created before roles were introduced are players
+	if len(roles) == 0 {
+		roles = []string{RolePlayer}
+	}
+	accessToken, err := jwt_setup.CreateToken(s.cfg, s.keys, userID, roles)
+	if err != nil {
+		return Pair{}, fmt.Errorf("unable to create jwt token due to: %v", err)
+	}
+	refreshToken, err := newOpaqueToken()
+	if err != nil {
+		return Pair{}, err
+	}
+
+	now := time.Now()
+	_, err = s.storage.Create(ctx, RefreshToken{
+		Hash:      hashToken(refreshToken),
+		UserID:    userID,
+		FamilyID:  familyID,
+		CreatedAt: now.Unix(),
+		ExpiresAt: now.Add(s.cfg.Tokens.RefreshTTL).Unix(),
+	})
+	if err != nil {
+		return Pair{}, fmt.Errorf("unable to store refresh token due to: %v", err)
+	}
+
+	return Pair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.cfg.Tokens.AccessTTL.Seconds()),
+	}, nil
+}
@@ -0,0 +1,12 @@
+package token
+
+import "context"
+
+type Storage interface {
+	Create(ctx context.Context, token RefreshToken) (string, error)
+	FindByHash(ctx context.Context, hash string) (RefreshToken, error)
+	// MarkUsed marks unused token as used. It returns false if the token was already used
+	MarkUsed(ctx context.Context, id string) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeUser(ctx context.Context, userID string) error
+}
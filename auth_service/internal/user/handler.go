@@ -2,42 +2,157 @@ package user
 
 import (
 	"auth_service/internal/apperror"
+	"auth_service/internal/attempt"
+	"auth_service/internal/token"
+	"auth_service/pkg/gateway"
 	"auth_service/pkg/logging"
+	"errors"
 	"fmt"
 	"github.com/julienschmidt/httprouter"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
-const signInURL = "/api/auth/sign-in"
+const (
+	signUpURL = "/api/auth/sign-up"
+	signInURL = "/api/auth/sign-in"
+	guestURL  = "/api/auth/guest"
+	claimURL  = "/api/auth/claim"
+)
 
 type Handler struct {
 	Logger      logging.Logger
 	AuthService Service
+	// InternalKey verifies client IP signed by the gateway
+	InternalKey string
 }
 
 func (h *Handler) Register(router *httprouter.Router) {
+	router.HandlerFunc(http.MethodPost, signUpURL, apperror.Middleware(h.SignUp))
 	router.HandlerFunc(http.MethodPost, signInURL, apperror.Middleware(h.SignIn))
+	router.HandlerFunc(http.MethodPost, guestURL, apperror.Middleware(h.Guest))
+	router.HandlerFunc(http.MethodPost, claimURL, apperror.Middleware(h.Claim))
+}
+
+// Sign up
+// @Summary Sign up endpoint. Username must be 3-32 characters of latin letters, digits, '_', '.', '-'.
+// @Description Password must be at least 8 characters long and contain a letter and a digit
+// @Accept json
+// @Produce json
+// @Param data body UserDTO true "username and password"
+// @Tags Auth
+// @Success 201 {object} token.Pair
+// @Failure 400 {object} apperror.AppError
+// @Failure 409 {object} apperror.AppError
+// @Router /api/auth/sign-up [post]
+func (h *Handler) SignUp(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Println("POST SIGN UP")
+	w.Header().Set("Content-Type", "application/json")
+
+	defer r.Body.Close()
+	pair, err := h.AuthService.SignUp(r.Context(), r.Body)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Location", fmt.Sprintf("%s", signUpURL))
+	return token.WritePair(w, http.StatusCreated, pair)
 }
 
 // Sign in
-// @Summary Sign in/sign up endpoint
+// @Summary Sign in endpoint. Creates unknown user only if LEGACY_SIGN_IN is enabled
 // @Accept json
 // @Produce json
+// @Param data body UserDTO true "username and password"
 // @Tags Auth
-// @Success 201
+// @Success 201 {object} token.Pair
 // @Failure 400
+// @Failure 401 {object} apperror.AppError
+// @Failure 429 {object} apperror.AppError
 // @Router /api/auth/sign-in [post]
 func (h *Handler) SignIn(w http.ResponseWriter, r *http.Request) error {
 	// HEADER, BODY etc.
 	h.Logger.Println("POST SIGN IN")
 	w.Header().Set("Content-Type", "application/json")
 
-	token, err := h.AuthService.SignIn(r.Context(), r.Body)
+	defer r.Body.Close()
+	pair, err := h.AuthService.SignIn(r.Context(), h.clientIP(r), r.Body)
 	if err != nil {
+		var lockedErr attempt.LockedError
+		if errors.As(err, &lockedErr) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(lockedErr.RetryAfter.Seconds()))))
+			return apperror.ErrTooManyTries
+		}
 		return err
 	}
 	w.Header().Set("Location", fmt.Sprintf("%s", signInURL))
-	w.Header().Add("Authorization", fmt.Sprintf("Bearer %s", token))
-	w.WriteHeader(http.StatusCreated)
-	return nil
+	return token.WritePair(w, http.StatusCreated, pair)
+}
+
+// Guest
+// @Summary Sign in as a guest of the device. Guest account is created on the first call, guest token has only guest role
+// @Description device_secret is returned once, on the first call. The device keeps it, next calls require it
+// @Accept json
+// @Produce json
+// @Param data body GuestDTO true "device id and secret"
+// @Tags Auth
+// @Success 201 {object} GuestPair
+// @Failure 400 {object} apperror.AppError
+// @Failure 401 {object} apperror.AppError "wrong device secret"
+// @Router /api/auth/guest [post]
+func (h *Handler) Guest(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Println("POST GUEST")
+	w.Header().Set("Content-Type", "application/json")
+
+	defer r.Body.Close()
+	pair, err := h.AuthService.Guest(r.Context(), r.Body)
+	if err != nil {
+		return err
+	}
+	return token.WriteResponse(w, http.StatusCreated, pair.Pair, pair)
+}
+
+// Claim
+// @Summary Turn guest account into a full account with username and password. Tickets and records are kept
+// @Description Requires access token of the guest. Password policy is the same as on sign up
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer access token of the guest"
+// @Param data body UserDTO true "username and password"
+// @Tags Auth
+// @Success 200 {object} token.Pair
+// @Failure 400 {object} apperror.AppError
+// @Failure 401 {object} apperror.AppError
+// @Failure 403 {object} apperror.AppError
+// @Failure 409 {object} apperror.AppError
+// @Router /api/auth/claim [post]
+func (h *Handler) Claim(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Println("POST CLAIM")
+	w.Header().Set("Content-Type", "application/json")
+
+	authHeaderArr := strings.Split(r.Header.Get("Authorization"), " ")
+	if len(authHeaderArr) != 2 {
+		return apperror.ErrWrongToken
+	}
+	defer r.Body.Close()
+	pair, err := h.AuthService.Claim(r.Context(), authHeaderArr[1], r.Body)
+	if err != nil {
+		return err
+	}
+	return token.WritePair(w, http.StatusOK, pair)
+}
+
+// clientIP returns IP signed by the gateway, so clients behind the gateway are counted separately and
+// can't forge it. Requests which didn't come through the gateway are counted by their remote address
+func (h *Handler) clientIP(r *http.Request) string {
+	if ip, ok := gateway.ClientIP(r, h.InternalKey); ok {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
 }
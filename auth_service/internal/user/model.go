@@ -1,5 +1,7 @@
 package user
 
+import "auth_service/internal/token"
+
 type User struct {
 	ID            string   `json:"id" bson:"_id,omitempty"`
 	Username      string   `json:"username" bson:"username"`
@@ -13,9 +15,24 @@ type UserDTO struct {
 	Password string `json:"password"`
 }
 
+// GuestDTO identifies the device of a guest, a device has one guest account until it's claimed.
+// DeviceSecret is issued on the first sign in, next sign ins require it
+type GuestDTO struct {
+	DeviceID     string `json:"device_id"`
+	DeviceSecret string `json:"device_secret,omitempty"`
+}
+
+// GuestPair is the token pair of the guest. DeviceSecret is returned once, when it's issued,
+// the device keeps it for next sign ins
+type GuestPair struct {
+	token.Pair
+	DeviceSecret string `json:"device_secret,omitempty"`
+}
+
+// ResponseUserDTO has only fields auth service needs, tickets are grouped by game type in user service
 type ResponseUserDTO struct {
 	ID            string   `json:"id"`
 	Username      string   `json:"username"`
 	HasFreeTicket bool     `json:"has_free_ticket"`
-	Tickets       []string `json:"tickets"`
+	Roles         []string `json:"roles"`
 }
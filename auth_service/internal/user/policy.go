@@ -0,0 +1,62 @@
+package user
+
+import (
+	"auth_service/internal/apperror"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	usernameMinLength = 3
+	usernameMaxLength = 32
+	passwordMinLength = 8
+	passwordMaxLength = 72 // bcrypt ignores bytes after 72
+	deviceIDMaxLength = 128
+	// deviceSecretMaxLength is longer than issued secrets, it only limits the body
+	deviceSecretMaxLength = 128
+	// guestUsernamePrefix is reserved for generated usernames of guests
+	guestUsernamePrefix = "guest-"
+)
+
+var usernameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// Validate checks username and password policy of a new user
+func (dto UserDTO) Validate() error {
+	if len(dto.Username) < usernameMinLength || len(dto.Username) > usernameMaxLength {
+		return apperror.BadRequestError(fmt.Sprintf("username must be from %d to %d characters long", usernameMinLength, usernameMaxLength))
+	}
+	if !usernameRegexp.MatchString(dto.Username) {
+		return apperror.BadRequestError("username may contain only latin letters, digits, '_', '.' and '-'")
+	}
+	if strings.HasPrefix(strings.ToLower(dto.Username), guestUsernamePrefix) {
+		return apperror.BadRequestError(fmt.Sprintf("username must not start with '%s'", guestUsernamePrefix))
+	}
+	if len(dto.Password) < passwordMinLength || len(dto.Password) > passwordMaxLength {
+		return apperror.BadRequestError(fmt.Sprintf("password must be from %d to %d characters long", passwordMinLength, passwordMaxLength))
+	}
+	var hasLetter, hasDigit bool
+	for _, r := range dto.Password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return apperror.BadRequestError("password must contain at least one letter and one digit")
+	}
+	return nil
+}
+
+func (dto GuestDTO) Validate() error {
+	if dto.DeviceID == "" || len(dto.DeviceID) > deviceIDMaxLength {
+		return apperror.BadRequestError(fmt.Sprintf("device id must be from 1 to %d characters long", deviceIDMaxLength))
+	}
+	if len(dto.DeviceSecret) > deviceSecretMaxLength {
+		return apperror.BadRequestError(fmt.Sprintf("device secret must be at most %d characters long", deviceSecretMaxLength))
+	}
+	return nil
+}
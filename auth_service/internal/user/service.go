@@ -1,64 +1,197 @@
 package user
 
 import (
-	"auth_service/internal/config"
-	jwt_setup "auth_service/pkg/jwt-setup"
+	"auth_service/internal/apperror"
+	"auth_service/internal/attempt"
+	"auth_service/internal/token"
 	"auth_service/pkg/logging"
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
+	"time"
 )
 
 type service struct {
-	logger  *logging.Logger
-	storage Storage
+	logger       *logging.Logger
+	storage      Storage
+	tokens       token.Service
+	legacySignIn bool
+	// usernames and ips track failed sign in attempts
+	usernames attempt.Tracker
+	ips       attempt.Tracker
 }
 
-func NewService(storage Storage, logger *logging.Logger) Service {
+func NewService(storage Storage, tokens token.Service, legacySignIn bool, usernames, ips attempt.Tracker, logger *logging.Logger) Service {
 	return &service{
-		logger:  logger,
-		storage: storage,
+		logger:       logger,
+		storage:      storage,
+		tokens:       tokens,
+		legacySignIn: legacySignIn,
+		usernames:    usernames,
+		ips:          ips,
 	}
 }
 
 type Service interface {
-	SignIn(ctx context.Context, body io.ReadCloser) (string, error)
+	SignUp(ctx context.Context, body io.ReadCloser) (token.Pair, error)
+	SignIn(ctx context.Context, ip string, body io.ReadCloser) (token.Pair, error)
+	Guest(ctx context.Context, body io.ReadCloser) (GuestPair, error)
+	Claim(ctx context.Context, accessToken string, body io.ReadCloser) (token.Pair, error)
 }
 
-// SignIn method is called when some JSON request got to /auth/ as login: login, pwd:pwd
-// To find out what to do with that we:
-// At first check if the given login is in the db if yes, we authorize the user
-// If not then create new user
-func (s service) SignIn(ctx context.Context, body io.ReadCloser) (token string, err error) {
+// SignUp creates new user if username and password satisfy the policy and username is not taken
+func (s service) SignUp(ctx context.Context, body io.ReadCloser) (pair token.Pair, err error) {
 	var dto UserDTO
 	err = json.NewDecoder(body).Decode(&dto)
 	if err != nil {
-		return "", fmt.Errorf("unable to decode user data due to: %v", err)
+		return pair, apperror.BadRequestError("invalid JSON scheme. check swagger API")
 	}
-	uuid, err := s.storage.FindByUsername(ctx, dto.Username)
-	s.logger.Printf("uuid outside: %s", uuid)
+	err = dto.Validate()
+	if err != nil {
+		return pair, err
+	}
+	uuid, err := s.storage.Create(ctx, dto)
+	if err != nil {
+		return pair, err
+	}
+	return s.tokens.Issue(ctx, uuid)
+}
+
+// Guest signs in guest account of the device, the account is created on the first call and the device gets
+// its secret. Next calls require the secret. Guest token has only guest role
+func (s service) Guest(ctx context.Context, body io.ReadCloser) (pair GuestPair, err error) {
+	var dto GuestDTO
+	err = json.NewDecoder(body).Decode(&dto)
+	if err != nil {
+		return pair, apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+	err = dto.Validate()
+	if err != nil {
+		return pair, err
+	}
+	uuid, deviceSecret, err := s.storage.CreateGuest(ctx, dto)
+	if err != nil {
+		return pair, err
+	}
+	pair.Pair, err = s.tokens.Issue(ctx, uuid)
+	if err != nil {
+		return pair, err
+	}
+	pair.DeviceSecret = deviceSecret
+	return pair, nil
+}
+
+// Claim sets username and password of the guest account authorized by the access token.
+// Tokens of the guest are revoked and a new pair with player role is issued
+func (s service) Claim(ctx context.Context, accessToken string, body io.ReadCloser) (pair token.Pair, err error) {
+	claims, err := s.tokens.Verify(ctx, accessToken)
+	if err != nil {
+		return pair, err
+	}
+	if !claims.HasRole(token.RoleGuest) {
+		return pair, apperror.ErrForbidden
+	}
+
+	var dto UserDTO
+	err = json.NewDecoder(body).Decode(&dto)
+	if err != nil {
+		return pair, apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+	err = dto.Validate()
+	if err != nil {
+		return pair, err
+	}
+	err = s.storage.Claim(ctx, claims.Id, dto)
+	if err != nil {
+		return pair, err
+	}
+	s.logger.Infof("guest %s claimed the account as %s", claims.Id, dto.Username)
+
+	err = s.tokens.RevokeUser(ctx, claims.Id)
+	if err != nil {
+		return pair, err
+	}
+	return s.tokens.Issue(ctx, claims.Id)
+}
+
+// SignIn authorizes existing user by username and password.
+// If legacy sign in is enabled unknown user is created like it was before sign up was introduced
+func (s service) SignIn(ctx context.Context, ip string, body io.ReadCloser) (pair token.Pair, err error) {
+	var dto UserDTO
+	err = json.NewDecoder(body).Decode(&dto)
+	if err != nil {
+		return pair, apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+	if s.legacySignIn {
+		return s.signInOrSignUp(ctx, ip, dto)
+	}
+	uuid, err := s.authorize(ctx, ip, dto)
+	if err != nil {
+		return pair, err
+	}
+	return s.tokens.Issue(ctx, uuid)
+}
+
+// authorize checks password unless username or client IP is locked. Failed attempts are counted for both
+func (s service) authorize(ctx context.Context, ip string, dto UserDTO) (string, error) {
+	usernameKey, ipKey := attempt.UsernameKey(dto.Username), attempt.IPKey(ip)
+	if left, locked := s.usernames.Locked(usernameKey); locked {
+		return "", attempt.LockedError{RetryAfter: left}
+	}
+	if left, locked := s.ips.Locked(ipKey); locked {
+		return "", attempt.LockedError{RetryAfter: left}
+	}
+
+	uuid, err := s.storage.FindByUsernameAndPassword(ctx, dto)
 	if err != nil {
+		if errors.Is(err, apperror.ErrUnauthorized) {
+			if lockout, locked := s.usernames.Fail(usernameKey); locked {
+				s.auditLockout(usernameKey, dto.Username, ip, lockout)
+			}
+			if lockout, locked := s.ips.Fail(ipKey); locked {
+				s.auditLockout(ipKey, dto.Username, ip, lockout)
+			}
+		}
 		return "", err
 	}
+	// failures of the ip are kept, one valid account must not unlock guessing of others
+	s.usernames.Reset(usernameKey)
+	return uuid, nil
+}
+
+func (s service) auditLockout(key, username, ip string, lockout time.Duration) {
+	s.logger.ExtraFields(map[string]interface{}{
+		"audit":    "sign_in_lockout",
+		"key":      key,
+		"username": username,
+		"ip":       ip,
+		"lockout":  lockout.String(),
+	}).Warn("sign in locked after too many failed attempts")
+}
+
+// signInOrSignUp is called when some JSON request got to /auth/ as login: login, pwd:pwd
+// To find out what to do with that we:
+// At first check if the given login is in the db if yes, we authorize the user
+// If not then create new user
+func (s service) signInOrSignUp(ctx context.Context, ip string, dto UserDTO) (pair token.Pair, err error) {
+	uuid, err := s.storage.FindByUsername(ctx, dto.Username)
+	if err != nil {
+		return pair, err
+	}
 	// if FindByUsername can't find user then it creates new user
 	if uuid == "" {
 		s.logger.Printf("NEW USER")
-		err := s.storage.Create(ctx, dto)
+		uuid, err = s.storage.Create(ctx, dto)
 		if err != nil {
-			return "", fmt.Errorf("unable to create new user due to: %v", err)
+			return pair, err
 		}
 	} else {
 		s.logger.Printf("FINDING USER IN DB BY USERNAME AND PASSWORD")
-		uuid, err = s.storage.FindByUsernameAndPassword(ctx, dto)
+		uuid, err = s.authorize(ctx, ip, dto)
 		if err != nil {
-			return "", fmt.Errorf("unable to authorize due to: %v", err)
+			return pair, err
 		}
 	}
-	token, err = jwt_setup.CreateToken(config.GetConfig(), uuid)
-	if err != nil {
-		return "", fmt.Errorf("unable to create jwt token due to: %v", err)
-	}
-
-	return token, nil
+	return s.tokens.Issue(ctx, uuid)
 }
@@ -5,7 +5,10 @@ import (
 )
 
 type Storage interface {
-	Create(ctx context.Context, dto UserDTO) error
+	Create(ctx context.Context, dto UserDTO) (uuid string, err error)
 	FindByUsername(ctx context.Context, username string) (string, error)
 	FindByUsernameAndPassword(ctx context.Context, dto UserDTO) (uuid string, err error)
+	// CreateGuest returns the guest of the device and the device secret if it's issued
+	CreateGuest(ctx context.Context, dto GuestDTO) (uuid, deviceSecret string, err error)
+	Claim(ctx context.Context, uuid string, dto UserDTO) error
 }
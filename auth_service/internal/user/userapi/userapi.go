@@ -1,93 +1,131 @@
 package userapi
 
 import (
+	"auth_service/internal/apperror"
+	"auth_service/internal/oidc"
 	"auth_service/internal/user"
+	"auth_service/pkg/client/userservice"
 	"auth_service/pkg/logging"
 	"context"
-	"encoding/json"
-	"fmt"
-	"net/http"
-	"strings"
-)
-
-const (
-	usersURL     = "http://localhost:10002/api/users"
-	usersAuthURL = "http://localhost:10002/api/users/auth"
-	usernameURL  = "http://localhost:10002/api/users/username"
+	"errors"
 )
 
+// UserAPI is user storage of the auth service backed by user service
 type UserAPI struct {
 	logger *logging.Logger
-	client http.Client
+	client *userservice.Client
 }
 
-func NewStorage(logger *logging.Logger) *UserAPI {
-	return &UserAPI{logger: logger}
+func NewStorage(client *userservice.Client, logger *logging.Logger) *UserAPI {
+	return &UserAPI{
+		logger: logger,
+		client: client,
+	}
 }
 
-func (ua *UserAPI) Create(ctx context.Context, dto user.UserDTO) error {
-	ua.logger.Println(dto)
-	userbytes, err := json.Marshal(dto)
-	if err != nil {
-		return fmt.Errorf("failed to marshal dto due to: %v", err)
-	}
-	reader := strings.NewReader(string(userbytes))
-	request, err := http.NewRequest(http.MethodPost, usersURL, reader)
-	if err != nil {
-		return fmt.Errorf("failed to build a request due to: %v", err)
-	}
-	_, err = ua.client.Do(request)
+// Create creates user and returns its id. Returns ErrConflict if username is taken
+func (ua *UserAPI) Create(ctx context.Context, dto user.UserDTO) (uuid string, err error) {
+	uuid, err = ua.client.Create(ctx, userservice.Credentials{Username: dto.Username, Password: dto.Password})
 	if err != nil {
-		return fmt.Errorf("failed to do a request due to: %v", err)
+		return "", appError(err)
 	}
-	return nil
+	ua.logger.Printf("created user with id: %s", uuid)
+	return uuid, nil
 }
 
+// FindByUsername returns id of the user or empty string if there is no such user
 func (ua *UserAPI) FindByUsername(ctx context.Context, username string) (uuid string, err error) {
-	url := fmt.Sprintf("%s/%s", usernameURL, username)
-	ua.logger.Printf("URL: %s", url)
-	request, err := http.NewRequest(http.MethodPost, url, nil)
+	u, err := ua.client.FindByUsername(ctx, username)
 	if err != nil {
-		return "", err
+		if errors.Is(err, userservice.ErrNotFound) {
+			return "", nil
+		}
+		return "", appError(err)
 	}
-	response, err := ua.client.Do(request)
+	return u.ID, nil
+}
+
+// FindByUsernameAndPassword returns id of the user. Returns ErrUnauthorized if there is no such user or password doesn't match
+func (ua *UserAPI) FindByUsernameAndPassword(ctx context.Context, dto user.UserDTO) (uuid string, err error) {
+	u, err := ua.client.Authenticate(ctx, userservice.Credentials{Username: dto.Username, Password: dto.Password})
 	if err != nil {
-		return "", fmt.Errorf("failed to do a request due to: %v", err)
+		return "", appError(err)
 	}
-	var dto user.ResponseUserDTO
-	err = json.NewDecoder(response.Body).Decode(&dto)
+	return u.ID, nil
+}
+
+// FindRoles returns roles of the user. Returns ErrNotFound if there is no such user
+func (ua *UserAPI) FindRoles(ctx context.Context, uuid string) (roles []string, err error) {
+	u, err := ua.client.FindByID(ctx, uuid)
 	if err != nil {
-		return "", fmt.Errorf("failed to decode the response data due to: %v", err)
+		return nil, appError(err)
 	}
-	ua.logger.Printf("found user with id: %s", dto.ID)
-	return dto.ID, nil
+	return u.Roles, nil
 }
 
-func (ua *UserAPI) FindByUsernameAndPassword(ctx context.Context, dto user.UserDTO) (uuid string, err error) {
-	userbytes, err := json.Marshal(dto)
+// CheckBan returns BannedError with the reason and expiry of the ban if the user is banned
+func (ua *UserAPI) CheckBan(ctx context.Context, uuid string) error {
+	status, err := ua.client.Sanctions(ctx, uuid)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal dto due to: %v", err)
+		return appError(err)
 	}
-	reader := strings.NewReader(string(userbytes))
-	request, err := http.NewRequest(http.MethodPost, usersAuthURL, reader)
-	ua.logger.Printf("REQUEST PAYLOAD: %v", reader)
-	if err != nil {
-		return "", fmt.Errorf("failed to build a request due to: %v", err)
+	if status.Banned {
+		return apperror.BannedError(status.Message)
 	}
+	return nil
+}
 
-	response, err := ua.client.Do(request)
+// CreateGuest returns id of the guest account of the device, the account is created if the device has none.
+// Returns ErrUnauthorized if the device secret is wrong
+func (ua *UserAPI) CreateGuest(ctx context.Context, dto user.GuestDTO) (uuid, deviceSecret string, err error) {
+	uuid, deviceSecret, err = ua.client.CreateGuest(ctx, dto.DeviceID, dto.DeviceSecret)
 	if err != nil {
-		return "", fmt.Errorf("failed to do a request due to: %v", err)
+		return "", "", appError(err)
 	}
-	var rdto user.ResponseUserDTO
-	err = json.NewDecoder(response.Body).Decode(&rdto)
+	ua.logger.Printf("guest of the device has id: %s", uuid)
+	return uuid, deviceSecret, nil
+}
+
+// Claim sets username and password of the guest. Returns ErrConflict if username is taken
+// and ErrNotFound if there is no such guest
+func (ua *UserAPI) Claim(ctx context.Context, uuid string, dto user.UserDTO) error {
+	err := ua.client.Claim(ctx, uuid, userservice.Credentials{Username: dto.Username, Password: dto.Password})
+	return appError(err)
+}
+
+// SignInWithIdentity returns id of the user of the identity, the user is created for unknown identity
+func (ua *UserAPI) SignInWithIdentity(ctx context.Context, dto oidc.IdentityDTO) (uuid string, err error) {
+	uuid, err = ua.client.SignInWithIdentity(ctx, userservice.Identity{
+		Provider: dto.Provider,
+		Subject:  dto.Subject,
+		Username: dto.Username,
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to decode the response data due to: %v", err)
+		return "", appError(err)
 	}
+	ua.logger.Printf("identity of %s belongs to user with id: %s", dto.Provider, uuid)
+	return uuid, nil
+}
 
-	if rdto.Username == "" && rdto.ID == "" {
-		return "", fmt.Errorf("password doesn't match")
-	}
+// LinkIdentity links the identity to the user. Returns ErrConflict if the identity belongs to another user
+func (ua *UserAPI) LinkIdentity(ctx context.Context, uuid string, dto oidc.IdentityDTO) error {
+	err := ua.client.LinkIdentity(ctx, uuid, userservice.Identity{Provider: dto.Provider, Subject: dto.Subject})
+	return appError(err)
+}
 
-	return rdto.ID, nil
+// appError maps errors of user service to errors of the auth service, unexpected ones become system errors
+func appError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, userservice.ErrBadRequest):
+		return apperror.BadRequestError(err.Error())
+	case errors.Is(err, userservice.ErrUnauthorized):
+		return apperror.ErrUnauthorized
+	case errors.Is(err, userservice.ErrNotFound):
+		return apperror.ErrNotFound
+	case errors.Is(err, userservice.ErrConflict):
+		return apperror.ErrConflict
+	}
+	return err
 }
@@ -0,0 +1,148 @@
+package userservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxErrorBodySize limits error body read from user service
+const maxErrorBodySize = 4096
+
+type Config struct {
+	// BaseURL is scheme, host and port of user service
+	BaseURL string
+	// Timeout limits every attempt of a request
+	Timeout time.Duration
+	// Retries is how many times idempotent request is repeated after network error or 429, 502, 503, 504
+	Retries int
+	// Backoff is delay before the first retry, every next delay is twice as long
+	Backoff time.Duration
+	// Token returns service token for Authorization header, internal API of user service requires service role
+	Token func(ctx context.Context) (string, error)
+}
+
+// Client is typed client of user service. Requests are bound to the context,
+// statuses are mapped to ErrBadRequest, ErrUnauthorized, ErrNotFound, ErrConflict or *StatusError
+type Client struct {
+	baseURL string
+	http    *http.Client
+	retries int
+	backoff time.Duration
+	token   func(ctx context.Context) (string, error)
+}
+
+func NewClient(cfg Config) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(cfg.BaseURL, "/"),
+		http:    &http.Client{Timeout: cfg.Timeout},
+		retries: cfg.Retries,
+		backoff: cfg.Backoff,
+		token:   cfg.Token,
+	}
+}
+
+// do sends the request and returns response with any status, the caller closes the body.
+// Only idempotent requests are retried, a repeated create could report conflict with itself
+func (c *Client) do(ctx context.Context, method, path string, body interface{}, idempotent bool) (*http.Response, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request body due to: %v", err)
+		}
+	}
+
+	var authorization string
+	if c.token != nil {
+		token, err := c.token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get service token due to: %v", err)
+		}
+		authorization = "Bearer " + token
+	}
+
+	attempts := 1
+	if idempotent {
+		attempts += c.retries
+	}
+	delay := c.backoff
+	for attempt := 1; ; attempt++ {
+		request, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("failed to build a request due to: %v", err)
+		}
+		if body != nil {
+			request.Header.Set("Content-Type", "application/json")
+		}
+		if authorization != "" {
+			request.Header.Set("Authorization", authorization)
+		}
+
+		response, err := c.http.Do(request)
+		if err == nil {
+			statusErr := &StatusError{StatusCode: response.StatusCode}
+			if !statusErr.temporary() || attempt == attempts {
+				return response, nil
+			}
+			io.Copy(io.Discard, io.LimitReader(response.Body, maxErrorBodySize))
+			response.Body.Close()
+			err = statusErr
+		}
+		if attempt == attempts || ctx.Err() != nil {
+			return nil, fmt.Errorf("failed to do a request to user service due to: %w", err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("failed to do a request to user service due to: %w", ctx.Err())
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+// check returns nil if response has one of expected statuses, otherwise it maps the status to an error
+func check(response *http.Response, expected ...int) error {
+	for _, status := range expected {
+		if response.StatusCode == status {
+			return nil
+		}
+	}
+
+	var body appError
+	json.NewDecoder(io.LimitReader(response.Body, maxErrorBodySize)).Decode(&body)
+	switch response.StatusCode {
+	case http.StatusBadRequest:
+		return fmt.Errorf("%w: %s", ErrBadRequest, body.Message)
+	case http.StatusUnauthorized:
+		// a rejected service token is a system error, not wrong credentials of the user
+		if body.Code == codeUnauthorized {
+			return ErrUnauthorized
+		}
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusConflict:
+		return ErrConflict
+	}
+	message := body.Message
+	if body.DeveloperMessage != "" {
+		message = fmt.Sprintf("%s: %s", message, body.DeveloperMessage)
+	}
+	return &StatusError{StatusCode: response.StatusCode, Message: message}
+}
+
+func decode(response *http.Response, v interface{}) error {
+	if err := json.NewDecoder(response.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response of user service due to: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package userservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, retries int, backoff time.Duration) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewClient(Config{
+		BaseURL: server.URL + "/",
+		Timeout: 5 * time.Second,
+		Retries: retries,
+		Backoff: backoff,
+		Token: func(ctx context.Context) (string, error) {
+			return "service-token", nil
+		},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestStatusErrors(t *testing.T) {
+	tests := []struct {
+		status     int
+		body       appError
+		want       error
+		statusCode int
+	}{
+		{status: http.StatusBadRequest, body: appError{Message: "username is required"}, want: ErrBadRequest},
+		{status: http.StatusUnauthorized, body: appError{Message: "wrong username or password", Code: codeUnauthorized}, want: ErrUnauthorized},
+		{status: http.StatusUnauthorized, body: appError{Message: "wrong token", Code: "NS-000004"}, statusCode: http.StatusUnauthorized},
+		{status: http.StatusForbidden, body: appError{Message: "forbidden", Code: "NS-000007"}, statusCode: http.StatusForbidden},
+		{status: http.StatusNotFound, want: ErrNotFound},
+		{status: http.StatusConflict, want: ErrConflict},
+		{status: http.StatusTeapot, body: appError{Message: "system error", DeveloperMessage: "db is down"}, statusCode: http.StatusTeapot},
+		{status: http.StatusInternalServerError, statusCode: http.StatusInternalServerError},
+		{status: http.StatusServiceUnavailable, statusCode: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, tt.status, tt.body)
+			}, 0, time.Millisecond)
+
+			_, err := client.FindByID(context.Background(), "42")
+			if tt.want != nil {
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("FindByID() error = %v, want %v", err, tt.want)
+				}
+				return
+			}
+			var statusErr *StatusError
+			if !errors.As(err, &statusErr) {
+				t.Fatalf("FindByID() error = %v, want *StatusError", err)
+			}
+			if statusErr.StatusCode != tt.statusCode {
+				t.Errorf("StatusCode = %d, want %d", statusErr.StatusCode, tt.statusCode)
+			}
+			if tt.body.DeveloperMessage != "" && statusErr.Message != "system error: db is down" {
+				t.Errorf("Message = %q, want message with developer message", statusErr.Message)
+			}
+		})
+	}
+}
+
+func TestRetriesIdempotentRequest(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writeJSON(w, http.StatusOK, User{ID: "42", Username: "player"})
+	}, 2, time.Millisecond)
+
+	u, err := client.FindByID(context.Background(), "42")
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if u.ID != "42" {
+		t.Errorf("ID = %q, want 42", u.ID)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestRetryLimit(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}, 2, time.Millisecond)
+
+	_, err := client.FindByUsername(context.Background(), "player")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("FindByUsername() error = %v, want 502 status error", err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestNoRetryOnCreate(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, 2, time.Millisecond)
+
+	_, err := client.Create(context.Background(), Credentials{Username: "player", Password: "password1"})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Create() error = %v, want 503 status error", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestNoRetryOnPermanentStatus(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}, 2, time.Millisecond)
+
+	if _, err := client.FindByID(context.Background(), "42"); err == nil {
+		t.Fatal("FindByID() error = nil, want error")
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestContextCanceledDuringBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, 5, time.Hour)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.FindByID(ctx, "42")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("FindByID() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("FindByID() didn't return after the context was canceled")
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestContextDeadlineDuringRequest(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}, 2, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := client.Sanctions(ctx, "42")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Sanctions() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestDecodeResponses(t *testing.T) {
+	user := User{ID: "42", Username: "player", HasFreeTicket: true, Roles: []string{"player"}}
+	status := SanctionStatus{Banned: true, Message: "user is banned: cheating"}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer service-token" {
+			t.Errorf("Authorization = %q, want service token", got)
+		}
+		switch r.URL.Path {
+		case userIDPath + "42", usernamePath + "player", authPath:
+			writeJSON(w, http.StatusOK, user)
+		case usersPath:
+			w.Header().Set("Location", "/api/users/43")
+			w.WriteHeader(http.StatusCreated)
+		case guestsPath:
+			w.Header().Set("Location", "/api/users/44")
+			writeJSON(w, http.StatusCreated, guestSecret{DeviceSecret: "secret"})
+		case identitiesPath:
+			w.Header().Set("Location", "/api/users/45")
+			w.WriteHeader(http.StatusCreated)
+		case claimPath + "44", identitiesPath + "42":
+			w.WriteHeader(http.StatusNoContent)
+		case sanctionsPath + "42":
+			writeJSON(w, http.StatusOK, status)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}, 0, time.Millisecond)
+	ctx := context.Background()
+
+	for name, find := range map[string]func() (User, error){
+		"FindByID":       func() (User, error) { return client.FindByID(ctx, "42") },
+		"FindByUsername": func() (User, error) { return client.FindByUsername(ctx, "player") },
+		"Authenticate": func() (User, error) {
+			return client.Authenticate(ctx, Credentials{Username: "player", Password: "password1"})
+		},
+	} {
+		got, err := find()
+		if err != nil {
+			t.Fatalf("%s() error = %v", name, err)
+		}
+		if !reflect.DeepEqual(got, user) {
+			t.Errorf("%s() = %+v, want %+v", name, got, user)
+		}
+	}
+
+	for name, create := range map[string]struct {
+		call func() (string, error)
+		want string
+	}{
+		"Create": {func() (string, error) { return client.Create(ctx, Credentials{Username: "new", Password: "password1"}) }, "43"},
+		"SignInWithIdentity": {func() (string, error) {
+			return client.SignInWithIdentity(ctx, Identity{Provider: "google", Subject: "1"})
+		}, "45"},
+	} {
+		id, err := create.call()
+		if err != nil {
+			t.Fatalf("%s() error = %v", name, err)
+		}
+		if id != create.want {
+			t.Errorf("%s() = %q, want %q", name, id, create.want)
+		}
+	}
+
+	id, secret, err := client.CreateGuest(ctx, "device", "")
+	if err != nil {
+		t.Fatalf("CreateGuest() error = %v", err)
+	}
+	if id != "44" || secret != "secret" {
+		t.Errorf("CreateGuest() = %q, %q, want 44, secret", id, secret)
+	}
+
+	if err := client.Claim(ctx, "44", Credentials{Username: "new", Password: "password1"}); err != nil {
+		t.Errorf("Claim() error = %v", err)
+	}
+	if err := client.LinkIdentity(ctx, "42", Identity{Provider: "google", Subject: "1"}); err != nil {
+		t.Errorf("LinkIdentity() error = %v", err)
+	}
+	gotStatus, err := client.Sanctions(ctx, "42")
+	if err != nil {
+		t.Fatalf("Sanctions() error = %v", err)
+	}
+	if gotStatus != status {
+		t.Errorf("Sanctions() = %+v, want %+v", gotStatus, status)
+	}
+}
+
+func TestAuthenticateUnknownUser(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotFound, appError{Message: "not found"})
+	}, 0, time.Millisecond)
+
+	_, err := client.Authenticate(context.Background(), Credentials{Username: "nobody", Password: "password1"})
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("Authenticate() error = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestAuthenticateRejectedServiceToken(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusUnauthorized, appError{Message: "wrong token", Code: "NS-000004"})
+	}, 0, time.Millisecond)
+
+	_, err := client.Authenticate(context.Background(), Credentials{Username: "player", Password: "password1"})
+	if errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("Authenticate() error = %v, want system error for rejected service token", err)
+	}
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Authenticate() error = %v, want 401 status error", err)
+	}
+}
+
+func TestCreateWithoutLocation(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}, 0, time.Millisecond)
+
+	if _, err := client.Create(context.Background(), Credentials{Username: "new", Password: "password1"}); err == nil {
+		t.Fatal("Create() error = nil, want error for missing location")
+	}
+}
@@ -0,0 +1,44 @@
+package userservice
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	ErrBadRequest   = errors.New("user service rejected the request")
+	ErrUnauthorized = errors.New("wrong username or password")
+	ErrNotFound     = errors.New("user not found")
+	ErrConflict     = errors.New("user already exists")
+)
+
+// codeUnauthorized is the code of user service's error for wrong username or password, other 401 responses
+// are about the service token
+const codeUnauthorized = "NS-000009"
+
+// StatusError is returned for unexpected status, user service responds with 418 to system errors
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("user service responded with status %d: %s", e.StatusCode, e.Message)
+}
+
+// temporary reports whether the request may succeed if repeated
+func (e *StatusError) temporary() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// appError is an error body of user service
+type appError struct {
+	Message          string `json:"message"`
+	DeveloperMessage string `json:"developer_message"`
+	Code             string `json:"code"`
+}
@@ -0,0 +1,38 @@
+package userservice
+
+type User struct {
+	ID            string   `json:"id"`
+	Username      string   `json:"username"`
+	HasFreeTicket bool     `json:"has_free_ticket"`
+	Roles         []string `json:"roles"`
+	IsGuest       bool     `json:"is_guest"`
+}
+
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type Identity struct {
+	Provider string `json:"provider"`
+	Subject  string `json:"subject"`
+	// Username is a hint for a new user
+	Username string `json:"username,omitempty"`
+}
+
+type SanctionStatus struct {
+	Banned       bool `json:"banned"`
+	ShadowBanned bool `json:"shadow_banned"`
+	// Message explains the ban to the user
+	Message string `json:"message"`
+}
+
+type guest struct {
+	DeviceID     string `json:"device_id"`
+	DeviceSecret string `json:"device_secret"`
+}
+
+// guestSecret is the secret issued to the device, it's empty if the device has it already
+type guestSecret struct {
+	DeviceSecret string `json:"device_secret"`
+}
@@ -0,0 +1,158 @@
+package userservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+)
+
+const (
+	usersPath      = "/api/users/internal/"
+	authPath       = "/api/users/internal/auth/"
+	usernamePath   = "/api/users/internal/username/"
+	userIDPath     = "/api/users/internal/id/"
+	guestsPath     = "/api/users/internal/guests/"
+	claimPath      = "/api/users/internal/claim/"
+	identitiesPath = "/api/users/internal/identities/"
+	sanctionsPath  = "/api/users/internal/sanctions/"
+)
+
+// Create creates user and returns its id. Returns ErrConflict if username is taken
+func (c *Client) Create(ctx context.Context, credentials Credentials) (string, error) {
+	response, err := c.do(ctx, http.MethodPost, usersPath, credentials, false)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if err := check(response, http.StatusCreated); err != nil {
+		return "", err
+	}
+	return locationID(response)
+}
+
+// FindByID returns ErrNotFound if there is no such user
+func (c *Client) FindByID(ctx context.Context, id string) (User, error) {
+	return c.find(ctx, userIDPath+url.PathEscape(id))
+}
+
+// FindByUsername returns ErrNotFound if there is no such user
+func (c *Client) FindByUsername(ctx context.Context, username string) (User, error) {
+	return c.find(ctx, usernamePath+url.PathEscape(username))
+}
+
+func (c *Client) find(ctx context.Context, path string) (u User, err error) {
+	response, err := c.do(ctx, http.MethodPost, path, nil, true)
+	if err != nil {
+		return u, err
+	}
+	defer response.Body.Close()
+	if err := check(response, http.StatusOK); err != nil {
+		return u, err
+	}
+	err = decode(response, &u)
+	return u, err
+}
+
+// Authenticate returns user with the username and password. Returns ErrUnauthorized for unknown user or wrong password
+func (c *Client) Authenticate(ctx context.Context, credentials Credentials) (u User, err error) {
+	response, err := c.do(ctx, http.MethodPost, authPath, credentials, true)
+	if err != nil {
+		return u, err
+	}
+	defer response.Body.Close()
+	if err := check(response, http.StatusOK); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return u, ErrUnauthorized
+		}
+		return u, err
+	}
+	if err = decode(response, &u); err != nil {
+		return u, err
+	}
+	if u.ID == "" {
+		return u, ErrUnauthorized
+	}
+	return u, nil
+}
+
+// CreateGuest returns id of the guest of the device, the guest is created if the device has none. The existing
+// guest requires the device secret, ErrUnauthorized is returned for a wrong one. The secret issued to the device
+// is returned once
+func (c *Client) CreateGuest(ctx context.Context, deviceID, deviceSecret string) (id, secret string, err error) {
+	response, err := c.do(ctx, http.MethodPost, guestsPath, guest{DeviceID: deviceID, DeviceSecret: deviceSecret}, true)
+	if err != nil {
+		return "", "", err
+	}
+	defer response.Body.Close()
+	if err := check(response, http.StatusCreated, http.StatusOK); err != nil {
+		return "", "", err
+	}
+	if id, err = locationID(response); err != nil {
+		return "", "", err
+	}
+	var issued guestSecret
+	if err = decode(response, &issued); err != nil {
+		return "", "", err
+	}
+	return id, issued.DeviceSecret, nil
+}
+
+// Claim sets username and password of the guest. Returns ErrConflict if username is taken,
+// ErrNotFound if there is no such user and ErrBadRequest if the account is already claimed
+func (c *Client) Claim(ctx context.Context, id string, credentials Credentials) error {
+	response, err := c.do(ctx, http.MethodPut, claimPath+url.PathEscape(id), credentials, false)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	return check(response, http.StatusNoContent)
+}
+
+// SignInWithIdentity returns id of the user of the identity, the user is created for unknown identity
+func (c *Client) SignInWithIdentity(ctx context.Context, identity Identity) (string, error) {
+	response, err := c.do(ctx, http.MethodPost, identitiesPath, identity, true)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if err := check(response, http.StatusCreated, http.StatusOK); err != nil {
+		return "", err
+	}
+	return locationID(response)
+}
+
+// LinkIdentity links the identity to the user. Returns ErrConflict if the identity belongs to another user
+func (c *Client) LinkIdentity(ctx context.Context, id string, identity Identity) error {
+	response, err := c.do(ctx, http.MethodPut, identitiesPath+url.PathEscape(id), identity, true)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	return check(response, http.StatusNoContent)
+}
+
+// locationID returns id of the user from Location header
+func locationID(response *http.Response) (string, error) {
+	location := response.Header.Get("Location")
+	if location == "" {
+		return "", fmt.Errorf("user service responded without location of the user")
+	}
+	return path.Base(location), nil
+}
+
+// Sanctions returns active sanctions of the user, a user without sanctions isn't banned
+func (c *Client) Sanctions(ctx context.Context, id string) (status SanctionStatus, err error) {
+	response, err := c.do(ctx, http.MethodGet, sanctionsPath+url.PathEscape(id), nil, true)
+	if err != nil {
+		return status, err
+	}
+	defer response.Body.Close()
+	if err := check(response, http.StatusOK); err != nil {
+		return status, err
+	}
+	err = decode(response, &status)
+	return status, err
+}
@@ -0,0 +1,46 @@
+package gateway
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	UserIDHeader    = "X-User-ID"
+	ClientIPHeader  = "X-Client-IP"
+	SignatureHeader = "X-Gateway-Signature"
+	TimestampHeader = "X-Gateway-Timestamp"
+
+	// maxAge is how long identity headers are accepted after the gateway signed them
+	maxAge = time.Minute
+)
+
+// Sign returns hex encoded HMAC-SHA256 of "userID.clientIP.timestamp", the same as the gateway signs
+func Sign(key, userID, clientIP string, timestamp int64) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(fmt.Sprintf("%s.%s.%d", userID, clientIP, timestamp)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ClientIP returns IP of the client signed by the gateway. It is false if the request didn't come through
+// the gateway: the headers are missing, expired or the signature doesn't match
+func ClientIP(r *http.Request, key string) (string, bool) {
+	ip := r.Header.Get(ClientIPHeader)
+	timestamp, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+	if ip == "" || err != nil {
+		return "", false
+	}
+	if age := time.Since(time.Unix(timestamp, 0)); age > maxAge || age < -maxAge {
+		return "", false
+	}
+	signature := Sign(key, r.Header.Get(UserIDHeader), ip, timestamp)
+	if !hmac.Equal([]byte(signature), []byte(r.Header.Get(SignatureHeader))) {
+		return "", false
+	}
+	return ip, true
+}
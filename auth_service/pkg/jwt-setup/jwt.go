@@ -2,15 +2,108 @@ package jwt_setup
 
 import (
 	"auth_service/internal/config"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
 	"github.com/golang-jwt/jwt/v4"
 	"time"
 )
 
-func CreateToken(cfg *config.Config, userId string) (string, error) {
+const (
+	ServiceAudience = "service"
+	RoleService     = "service"
+)
+
+// Claims duplicates user id in id claim, services read it from there
+type Claims struct {
+	jwt.RegisteredClaims
+	Id    string   `json:"id"`
+	Roles []string `json:"roles"`
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		ID:        userId,
-		ExpiresAt: &jwt.NumericDate{Time: time.Now().Add(8760 * time.Hour)},
+// CreateToken creates access token valid for Tokens.AccessTTL signed with the active key of the key set.
+// Every token gets unique jti
+func CreateToken(cfg *config.Config, keys *KeySet, userId string, roles []string) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	kid, signKey := keys.Active()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   userId,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(cfg.Tokens.AccessTTL)),
+		},
+		Id:    userId,
+		Roles: roles,
 	})
-	return token.SignedString([]byte(cfg.Keys.JWTSignKey))
+	token.Header["kid"] = kid
+	return token.SignedString(signKey)
+}
+
+// CreateServiceToken creates token for service-to-service calls valid for Tokens.ServiceTTL.
+// The token has service audience and service role, client id is used as user id
+func CreateServiceToken(cfg *config.Config, keys *KeySet, clientID string) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	kid, signKey := keys.Active()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   clientID,
+			Audience:  jwt.ClaimStrings{ServiceAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(cfg.Tokens.ServiceTTL)),
+		},
+		Id:    clientID,
+		Roles: []string{RoleService},
+	})
+	token.Header["kid"] = kid
+	return token.SignedString(signKey)
+}
+
+// HasRole reports whether the token has one of the roles
+func (c *Claims) HasRole(roles ...string) bool {
+	for _, role := range roles {
+		for _, r := range c.Roles {
+			if r == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ParseToken verifies access token signed by one of the keys of the key set and returns its claims
+func ParseToken(keys *KeySet, tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		publicKey, found := keys.PublicKey(kid)
+		if !found {
+			return nil, fmt.Errorf("unknown key id: %s", kid)
+		}
+		return publicKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func newTokenID() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate token id due to: %v", err)
+	}
+	return hex.EncodeToString(bytes), nil
 }
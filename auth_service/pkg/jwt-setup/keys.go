@@ -0,0 +1,219 @@
+package jwt_setup
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	keyBits = 2048
+	// keyActivationDelay is how long a rotated key is published before it signs tokens,
+	// so services have time to refetch JWKS
+	keyActivationDelay = time.Minute
+)
+
+type signingKey struct {
+	kid        string
+	privateKey *rsa.PrivateKey
+	activeFrom time.Time
+}
+
+// KeySet holds RSA keys of the auth service. The newest activated key signs tokens,
+// the key is retired when the next one is activated. Retired keys are still published
+// in JWKS until tokens signed by them expire
+type KeySet struct {
+	mu   sync.RWMutex
+	dir  string
+	keys []*signingKey
+}
+
+// LoadKeys reads PEM encoded RSA private keys from the dir, file name without extension is used as kid.
+// Files are sorted by name and the last one becomes active, so names generated by Rotate sort by creation time.
+// Key is considered active from its file modification time.
+// If the dir has no keys a new key is generated and saved there
+func LoadKeys(dir string) (*KeySet, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create keys dir %s due to: %v", dir, err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys dir %s due to: %v", dir, err)
+	}
+	sort.Strings(files)
+
+	ks := &KeySet{dir: dir}
+	for _, file := range files {
+		privateKey, err := readPrivateKey(file)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat rsa key %s due to: %v", file, err)
+		}
+		ks.keys = append(ks.keys, &signingKey{
+			kid:        strings.TrimSuffix(filepath.Base(file), ".pem"),
+			privateKey: privateKey,
+			activeFrom: info.ModTime(),
+		})
+	}
+	if len(ks.keys) == 0 {
+		return ks, ks.addKey(time.Now())
+	}
+	return ks, nil
+}
+
+// Rotate generates new key and saves it to the keys dir.
+// The key is published immediately and starts to sign tokens after keyActivationDelay
+func (ks *KeySet) Rotate() error {
+	return ks.addKey(time.Now().Add(keyActivationDelay))
+}
+
+func (ks *KeySet) addKey(activeFrom time.Time) error {
+	privateKey, err := rsa.GenerateKey(rand.Reader, keyBits)
+	if err != nil {
+		return fmt.Errorf("failed to generate rsa key due to: %v", err)
+	}
+	kid := time.Now().UTC().Format("20060102T150405.000000000")
+	bytes := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+	})
+	if err := os.WriteFile(ks.keyFile(kid), bytes, 0600); err != nil {
+		return fmt.Errorf("failed to save rsa key due to: %v", err)
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys = append(ks.keys, &signingKey{kid: kid, privateKey: privateKey, activeFrom: activeFrom})
+	return nil
+}
+
+// Prune removes keys retired more than retention ago, they can't verify unexpired tokens anymore
+func (ks *KeySet) Prune(retention time.Duration) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	keys := make([]*signingKey, 0, len(ks.keys))
+	for i, key := range ks.keys {
+		// key is retired when the next one is activated
+		if i+1 < len(ks.keys) && time.Since(ks.keys[i+1].activeFrom) > retention {
+			if err := os.Remove(ks.keyFile(key.kid)); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove rsa key %s due to: %v", key.kid, err)
+			}
+			continue
+		}
+		keys = append(keys, key)
+	}
+	ks.keys = keys
+	return nil
+}
+
+// RunRotation rotates keys every interval and prunes retired ones
+func (ks *KeySet) RunRotation(interval, retention time.Duration, onError func(err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := ks.Rotate(); err != nil {
+			onError(err)
+		}
+		if err := ks.Prune(retention); err != nil {
+			onError(err)
+		}
+	}
+}
+
+// Active returns kid and private key used to sign new tokens
+func (ks *KeySet) Active() (string, *rsa.PrivateKey) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	now := time.Now()
+	for i := len(ks.keys) - 1; i > 0; i-- {
+		if !ks.keys[i].activeFrom.After(now) {
+			return ks.keys[i].kid, ks.keys[i].privateKey
+		}
+	}
+	return ks.keys[0].kid, ks.keys[0].privateKey
+}
+
+// PublicKey returns public key by kid, retired keys are found until pruned
+func (ks *KeySet) PublicKey(kid string) (*rsa.PublicKey, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	for _, key := range ks.keys {
+		if key.kid == kid {
+			return &key.privateKey.PublicKey, true
+		}
+	}
+	return nil, false
+}
+
+// JWK is a public RSA key in JSON Web Key format
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns public parts of rotated, active and retired keys
+func (ks *KeySet) JWKS() JWKSet {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	set := JWKSet{Keys: make([]JWK, 0, len(ks.keys))}
+	for _, key := range ks.keys {
+		publicKey := key.privateKey.PublicKey
+		set.Keys = append(set.Keys, JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: "RS256",
+			Kid: key.kid,
+			N:   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+		})
+	}
+	return set
+}
+
+func (ks *KeySet) keyFile(kid string) string {
+	return filepath.Join(ks.dir, kid+".pem")
+}
+
+func readPrivateKey(file string) (*rsa.PrivateKey, error) {
+	bytes, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rsa key %s due to: %v", file, err)
+	}
+	block, _ := pem.Decode(bytes)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode pem file %s", file)
+	}
+	if privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return privateKey, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rsa key %s due to: %v", file, err)
+	}
+	privateKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("key %s is not rsa private key", file)
+	}
+	return privateKey, nil
+}
@@ -30,10 +30,10 @@ const docTemplate = `{
                 "summary": "Create lobby endpoint",
                 "responses": {
                     "201": {
-                        "description": ""
+                        "description": "Created"
                     },
                     "400": {
-                        "description": ""
+                        "description": "Bad Request"
                     }
                 }
             },
@@ -50,10 +50,10 @@ const docTemplate = `{
                 "summary": "Partially update lobby by lobby id",
                 "responses": {
                     "204": {
-                        "description": ""
+                        "description": "No Content"
                     },
                     "400": {
-                        "description": ""
+                        "description": "Bad Request"
                     }
                 }
             }
@@ -69,13 +69,39 @@ const docTemplate = `{
                 "tags": [
                     "Lobbies"
                 ],
-                "summary": "Get all lobbies",
+                "summary": "Get page of lobbies",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page size, 20 by default, 100 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "id, start_time, end_time, now_players, ticket_price or prize_sum, - prefix for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "lobbies of the game type",
+                        "name": "game_type",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
-                        "description": ""
+                        "description": "OK"
                     },
                     "400": {
-                        "description": ""
+                        "description": "Bad Request"
                     }
                 }
             }
@@ -94,10 +120,54 @@ const docTemplate = `{
                 "summary": "Delete all lobbies endpoint",
                 "responses": {
                     "204": {
-                        "description": ""
+                        "description": "No Content"
                     },
                     "400": {
-                        "description": ""
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/auth.AppError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/lobbies/events/{id}": {
+            "get": {
+                "description": "The stream starts with the lobby event (the current lobby) and ends before the server's write timeout,\nthe client reconnects with Last-Event-ID header and gets the missed events instead of the lobby",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Lobbies"
+                ],
+                "summary": "Server-sent events of the lobby: player_joined, player_ready, lobby_full, game_server_created, lobby_rescheduled, lobby_cancelled",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Lobby ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "id of the last event the client got",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lobby.Event"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found"
                     }
                 }
             }
@@ -116,10 +186,10 @@ const docTemplate = `{
                 "summary": "Get lobby by lobby id",
                 "responses": {
                     "200": {
-                        "description": ""
+                        "description": "OK"
                     },
                     "400": {
-                        "description": ""
+                        "description": "Bad Request"
                     }
                 }
             },
@@ -136,16 +206,23 @@ const docTemplate = `{
                 "summary": "Delete lobby by lobby id",
                 "responses": {
                     "204": {
-                        "description": ""
+                        "description": "No Content"
                     },
                     "400": {
-                        "description": ""
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/auth.AppError"
+                        }
                     }
                 }
             }
         },
         "/api/lobbies/join": {
             "post": {
+                "description": "The join is idempotent by request_id: a retry with the same request_id continues the join or returns its result",
                 "consumes": [
                     "application/json"
                 ],
@@ -155,13 +232,31 @@ const docTemplate = `{
                 "tags": [
                     "Lobbies"
                 ],
-                "summary": "adds user to lobby by userID, lobbyID and ticketID",
+                "summary": "adds the user of the token to lobby by lobbyID and ticketID",
                 "responses": {
                     "200": {
-                        "description": ""
+                        "description": "OK"
                     },
                     "400": {
-                        "description": ""
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "the token has no required role or the user is banned",
+                        "schema": {
+                            "$ref": "#/definitions/auth.AppError"
+                        }
+                    },
+                    "404": {
+                        "description": "the lobby isn't found",
+                        "schema": {
+                            "$ref": "#/definitions/auth.AppError"
+                        }
+                    },
+                    "409": {
+                        "description": "the join with the request_id is being processed",
+                        "schema": {
+                            "$ref": "#/definitions/auth.AppError"
+                        }
                     }
                 }
             }
@@ -180,10 +275,10 @@ const docTemplate = `{
                 "summary": "return lobbyID by game_type, prize_sum and max_players",
                 "responses": {
                     "200": {
-                        "description": ""
+                        "description": "OK"
                     },
                     "400": {
-                        "description": ""
+                        "description": "Bad Request"
                     }
                 }
             }
@@ -202,10 +297,35 @@ const docTemplate = `{
                 "summary": "gets lobby by id, updates lobby time and returns new time to be checked",
                 "responses": {
                     "200": {
-                        "description": ""
+                        "description": "OK"
                     },
                     "400": {
-                        "description": ""
+                        "description": "Bad Request"
+                    }
+                }
+            }
+        },
+        "/health/ready": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Metrics"
+                ],
+                "summary": "Readiness metric. Checks database and downstream services",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.ReadyResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.ReadyResponse"
+                        }
                     }
                 }
             }
@@ -218,11 +338,81 @@ const docTemplate = `{
                 "summary": "Heartbeat metric",
                 "responses": {
                     "204": {
-                        "description": ""
+                        "description": "No Content"
                     },
                     "400": {
-                        "description": ""
+                        "description": "Bad Request"
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "auth.AppError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "developer_message": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "lobby.Event": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "game_server_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lobby_id": {
+                    "type": "string"
+                },
+                "start_time": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "metrics.CheckResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "metrics.ReadyResponse": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/metrics.CheckResult"
                     }
+                },
+                "status": {
+                    "type": "string"
                 }
             }
         }
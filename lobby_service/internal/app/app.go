@@ -36,18 +36,29 @@ func NewApp(cfg *config.Config, logger *logging.Logger) (App, error) {
 	router.Handler(http.MethodGet, "/swagger", http.RedirectHandler("/swagger/index.html", http.StatusMovedPermanently))
 	router.Handler(http.MethodGet, "/swagger/*any", httpSwagger.WrapHandler)
 
-	logger.Println("heartbeat metric initializing")
-	metricHandler := metrics.Handler{}
-	metricHandler.Register(router)
-
 	mongodbClient, err := mongodb.NewClient(context.Background(), cfg.MongoDB.Host, cfg.MongoDB.Port,
 		cfg.MongoDB.Username, cfg.MongoDB.Password, cfg.MongoDB.Database, cfg.MongoDB.AuthDB)
 	if err != nil {
 		panic(err)
 	}
 
+	logger.Println("heartbeat metric initializing")
+	metricHandler := metrics.Handler{
+		Checks: map[string]metrics.Check{
+			"mongodb":         mongodb.Ping(mongodbClient),
+			"user_service":    metrics.HTTPCheck(cfg.Dependencies.UserServiceURL),
+			"ticket_service":  metrics.HTTPCheck(cfg.Dependencies.TicketServiceURL),
+			"manager_service": metrics.HTTPCheck(cfg.Dependencies.ManagerServiceURL),
+			"snake":           metrics.HTTPCheck(cfg.Dependencies.SnakeURL),
+			"quiz":            metrics.HTTPCheck(cfg.Dependencies.QuizURL),
+		},
+	}
+	metricHandler.Register(router)
+
 	storage := db.NewStorage(mongodbClient, "lobbies", logger)
-	service, err := lobby.NewService(storage, *logger)
+	joins := db.NewJoinStorage(mongodbClient, "joins", logger)
+	events := lobby.NewEventBus()
+	service, err := lobby.NewService(storage, joins, events, *logger)
 	if err != nil {
 		panic(err)
 	}
@@ -55,6 +66,7 @@ func NewApp(cfg *config.Config, logger *logging.Logger) (App, error) {
 	usersHandler := lobby.Handler{
 		Logger:       logging.GetLogger(cfg.AppConfig.LogLevel),
 		LobbyService: service,
+		Events:       events,
 	}
 	usersHandler.Register(router)
 
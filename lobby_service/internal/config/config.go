@@ -4,6 +4,7 @@ import (
 	"github.com/ilyakaznacheev/cleanenv"
 	"log"
 	"sync"
+	"time"
 )
 
 type Config struct {
@@ -32,11 +33,27 @@ type Config struct {
 		Database string `env:"DATABASE" env-default:"lobby-service"`
 		AuthDB   string `env:"AUTH_DB"`
 	}
+	ServiceAuth struct {
+		TokenURL     string `env:"SERVICE_TOKEN_URL" env-default:"http://localhost:10001/api/auth/token"`
+		ClientID     string `env:"SERVICE_CLIENT_ID" env-default:"lobby_service"`
+		ClientSecret string `env:"SERVICE_CLIENT_SECRET" env-required:"true"`
+	}
 	Keys struct {
-		AccessKey  string `env:"ACCESS_KEY" env-default:"18d8debd1eec2eb338c4a9a8815633cede19cf3d17b0f20c60cf3839a89699cb"`
-		JWTSignKey string `env:"JWT_SIGN_KEY" env-default:"alsfjak12h4i1h2uas7f7241231o1u2io5u12asopua0w9812"`
+		AccessKey string `env:"ACCESS_KEY" env-default:"18d8debd1eec2eb338c4a9a8815633cede19cf3d17b0f20c60cf3839a89699cb"`
+		JWKSURL   string `env:"JWKS_URL" env-default:"http://localhost:10001/.well-known/jwks.json"`
+		// RevocationsURL is polled every RevocationsInterval, revoked tokens are rejected
+		RevocationsURL      string        `env:"REVOCATIONS_URL" env-default:"http://localhost:10001/api/auth/revocations"`
+		RevocationsInterval time.Duration `env:"REVOCATIONS_REFRESH_INTERVAL" env-default:"10s"`
 	}
 	TicketsAvailable bool `env:"TICKETS_AVAILABLE" env-default:"true"`
+	// Dependencies are base URLs of services the readiness check calls
+	Dependencies struct {
+		UserServiceURL    string `env:"USER_SERVICE_URL" env-default:"http://localhost:10002"`
+		TicketServiceURL  string `env:"TICKET_SERVICE_URL" env-default:"http://localhost:10004"`
+		ManagerServiceURL string `env:"MANAGER_SERVICE_URL" env-default:"http://localhost:10007"`
+		SnakeURL          string `env:"SNAKE_SERVICE_URL" env-default:"http://localhost:10008"`
+		QuizURL           string `env:"QUIZ_SERVICE_URL" env-default:"http://localhost:10009"`
+	}
 }
 
 var instance *Config
@@ -54,6 +71,9 @@ func GetConfig() *Config {
 			log.Println(help)
 			log.Fatal(err)
 		}
+		if instance.ServiceAuth.ClientSecret == "" {
+			log.Fatal("SERVICE_CLIENT_SECRET is empty")
+		}
 	})
 	return instance
 }
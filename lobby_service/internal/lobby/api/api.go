@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"io"
+	"lobby_service/pkg/servicetoken"
 	"net/http"
 )
 
@@ -13,5 +14,9 @@ func MakeRequestWithContext(ctx context.Context, requestType string, u string, b
 	if err != nil {
 		return nil, err
 	}
+	err = servicetoken.Authorize(ctx, request)
+	if err != nil {
+		return nil, err
+	}
 	return client.Do(request)
 }
@@ -1,9 +1,11 @@
 package lobby
 
 const (
-	GetUsersByIDURL  = "http://localhost:10002/api/users/id/"
-	UpdateUserURL    = "http://localhost:10002/api/users/update"
+	ConsumeTicketURL = "http://localhost:10002/api/users/internal/tickets/consume"
+	GrantTicketURL   = "http://localhost:10002/api/users/internal/tickets/grant"
+	SanctionsURL     = "http://localhost:10002/api/users/internal/sanctions/"
 	UseTicketURL     = "http://localhost:10004/api/tickets/use/"
+	ReleaseTicketURL = "http://localhost:10004/api/tickets/release/"
 	createSnakeGSURL = "http://localhost:10008/api/snake/"
 	createQuizGSURL  = "http://localhost:10009/api/quiz/"
 	notifyMangerURL  = "http://localhost:10007/api/manager/"
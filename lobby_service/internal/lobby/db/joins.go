@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"lobby_service/internal/auth"
+	"lobby_service/internal/lobby"
+	"lobby_service/pkg/logging"
+	"time"
+)
+
+// joinsTTL is how long the result of the join is kept for retries
+const joinsTTL = 7 * 24 * time.Hour
+
+type joins struct {
+	collection *mongo.Collection
+	logger     *logging.Logger
+}
+
+func (d *joins) Find(ctx context.Context, requestID string) (join lobby.Join, err error) {
+	err = d.collection.FindOne(ctx, bson.M{"_id": requestID}).Decode(&join)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return join, auth.ErrNotFound
+		}
+		return join, fmt.Errorf("failed to find join %s due to: %v", requestID, err)
+	}
+	return join, nil
+}
+
+// Claim inserts the join locked for the lease by its owner. If the join exists, its expired lock is taken over
+// by the owner, so only one request runs the join at a time
+func (d *joins) Claim(ctx context.Context, join lobby.Join, lease time.Duration) (lobby.Join, error) {
+	now := time.Now()
+	join.LockedUntil = now.Add(lease)
+	_, err := d.collection.InsertOne(ctx, join)
+	if err == nil {
+		return join, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return join, fmt.Errorf("failed to create join due to: %v", err)
+	}
+
+	filter := bson.M{
+		"_id":          join.RequestID,
+		"state":        bson.M{"$in": bson.A{lobby.JoinRunning, lobby.JoinCompensating}},
+		"locked_until": bson.M{"$lt": now},
+	}
+	update := bson.M{"$set": bson.M{"owner": join.Owner, "locked_until": join.LockedUntil}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var claimed lobby.Join
+	err = d.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&claimed)
+	if err == nil {
+		return claimed, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return join, fmt.Errorf("failed to claim join %s due to: %v", join.RequestID, err)
+	}
+
+	stored, err := d.Find(ctx, join.RequestID)
+	if err != nil {
+		return join, err
+	}
+	if stored.State == lobby.JoinCompleted || stored.State == lobby.JoinCompensated {
+		return stored, nil
+	}
+	return join, auth.ErrConflict
+}
+
+// Save replaces the join only while it has the same owner. Claim changes the owner when it takes over
+// the expired lock, so a request whose lease expired can't overwrite the join continued by another request.
+// The lease expired without a takeover is still held, the saved join extends it
+func (d *joins) Save(ctx context.Context, join lobby.Join) error {
+	filter := bson.M{"_id": join.RequestID, "owner": join.Owner}
+	result, err := d.collection.ReplaceOne(ctx, filter, join)
+	if err != nil {
+		return fmt.Errorf("failed to save join %s due to: %v", join.RequestID, err)
+	}
+	if result.MatchedCount == 0 {
+		return lobby.ErrJoinLost
+	}
+	return nil
+}
+
+// NewJoinStorage creates ttl index, so results of old joins are removed
+func NewJoinStorage(database *mongo.Database, collection string, logger *logging.Logger) lobby.JoinStorage {
+	d := &joins{
+		collection: database.Collection(collection),
+		logger:     logger,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := d.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "created_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(joinsTTL.Seconds())),
+	})
+	if err != nil {
+		logger.Errorf("failed to create ttl index on joins due to: %v", err)
+	}
+	return d
+}
@@ -8,9 +8,10 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"lobby_service/internal/auth"
 	"lobby_service/internal/lobby"
 	"lobby_service/pkg/logging"
-	"log"
+	"lobby_service/pkg/pagination"
 )
 
 type db struct {
@@ -19,8 +20,18 @@ type db struct {
 }
 
 func (d *db) Create(ctx context.Context, lobby lobby.Lobby) (string, error) {
-	result, err := d.collection.InsertOne(ctx, lobby)
+	var document interface{} = lobby
+	if lobby.ID != "" {
+		var err error
+		if document, err = withObjectID(lobby, lobby.ID); err != nil {
+			return "", err
+		}
+	}
+	result, err := d.collection.InsertOne(ctx, document)
 	if err != nil {
+		if lobby.ID != "" && mongo.IsDuplicateKeyError(err) {
+			return lobby.ID, nil
+		}
 		return "", fmt.Errorf("failed to create lobby due to: %v", err)
 	}
 	d.logger.Debug("convert InsertedID to objectID")
@@ -42,7 +53,7 @@ func (d *db) FindById(ctx context.Context, id string) (lobby lobby.Lobby, err er
 	result := d.collection.FindOne(ctx, filter)
 	if result.Err() != nil {
 		if errors.Is(result.Err(), mongo.ErrNoDocuments) {
-			// TODO ErrEntityNotFound
+			return lobby, auth.ErrNotFound
 		}
 		return lobby, fmt.Errorf("failed to find lobby by id: %s due to error: %v", id, result.Err())
 	}
@@ -52,16 +63,19 @@ func (d *db) FindById(ctx context.Context, id string) (lobby lobby.Lobby, err er
 	return lobby, nil
 }
 
-func (d *db) FindAll(ctx context.Context) (users []lobby.Lobby, err error) {
-	cursor, err := d.collection.Find(ctx, bson.M{})
-	if cursor.Err() != nil {
-		return users, fmt.Errorf("failed to find all lobbys due to: %v", cursor.Err())
+func (d *db) FindPage(ctx context.Context, filter lobby.Filter, params pagination.Params) (lobbies []lobby.Lobby, err error) {
+	query := bson.M{}
+	if filter.GameType != "" {
+		query["game_type"] = filter.GameType
 	}
-	if err := cursor.All(ctx, &users); err != nil {
-		return users, fmt.Errorf("failed to read all documents from cursor")
+	cursor, err := d.collection.Find(ctx, params.Filter(query), params.Options())
+	if err != nil {
+		return lobbies, fmt.Errorf("failed to find lobbys due to: %v", err)
 	}
-	log.Println(users)
-	return users, nil
+	if err := cursor.All(ctx, &lobbies); err != nil {
+		return lobbies, fmt.Errorf("failed to read all documents from cursor due to: %v", err)
+	}
+	return lobbies, nil
 }
 
 // Update by lobbyID
@@ -99,6 +113,100 @@ func (d *db) Update(ctx context.Context, lobby lobby.Lobby) error {
 	return nil
 }
 
+func (d *db) UpdateTime(ctx context.Context, id string, startTime, endTime int64) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("failed to convert lobby ID to ObjectID. ID=%v", id)
+	}
+
+	filter := bson.M{"_id": objectID}
+	update := bson.M{"$set": bson.M{"start_time": startTime, "end_time": endTime}}
+	result, err := d.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to execute update lobby time query due to: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return auth.ErrNotFound
+	}
+	return nil
+}
+
+// AddPlayer pushes the player only while a seat is left and the player isn't in the lobby, so concurrent
+// joins can't overfill the lobby or overwrite each other's players
+func (d *db) AddPlayer(ctx context.Context, lobbyID string, player lobby.Player) (l lobby.Lobby, err error) {
+	objectID, err := primitive.ObjectIDFromHex(lobbyID)
+	if err != nil {
+		return l, fmt.Errorf("failed to convert lobby ID to ObjectID. ID=%v", lobbyID)
+	}
+
+	filter := bson.M{
+		"_id":        objectID,
+		"players.id": bson.M{"$ne": player.ID},
+		"$expr":      bson.M{"$lt": bson.A{"$now_players", "$max_players"}},
+	}
+	update := bson.M{
+		"$push": bson.M{"players": player},
+		"$inc":  bson.M{"now_players": 1},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = d.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&l)
+	if err == nil {
+		return l, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return l, fmt.Errorf("failed to add player to lobby due to: %v", err)
+	}
+
+	// the seat isn't taken, the current lobby tells why
+	l, err = d.FindById(ctx, lobbyID)
+	if err != nil {
+		return l, err
+	}
+	for _, p := range l.Players {
+		if p.ID == player.ID {
+			return l, lobby.ErrPlayerInLobby
+		}
+	}
+	return l, lobby.ErrLobbyFull
+}
+
+func (d *db) SetReady(ctx context.Context, lobbyID, userID string) error {
+	objectID, err := primitive.ObjectIDFromHex(lobbyID)
+	if err != nil {
+		return fmt.Errorf("failed to convert lobby ID to ObjectID. ID=%v", lobbyID)
+	}
+
+	filter := bson.M{"_id": objectID, "players": bson.M{"$elemMatch": bson.M{"id": userID, "ready": false}}}
+	update := bson.M{"$set": bson.M{"players.$.ready": true}}
+	result, err := d.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to set player ready due to: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return lobby.ErrAlreadyReady
+	}
+	return nil
+}
+
+// RemovePlayer pulls the player and frees the seat in one update, so seats taken concurrently aren't lost
+func (d *db) RemovePlayer(ctx context.Context, lobbyID, userID string) error {
+	objectID, err := primitive.ObjectIDFromHex(lobbyID)
+	if err != nil {
+		return fmt.Errorf("failed to convert lobby ID to ObjectID. ID=%v", lobbyID)
+	}
+
+	filter := bson.M{"_id": objectID, "players.id": userID}
+	update := bson.M{
+		"$pull": bson.M{"players": bson.M{"id": userID}},
+		"$inc":  bson.M{"now_players": -1},
+	}
+	_, err = d.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to remove player from lobby due to: %v", err)
+	}
+	return nil
+}
+
 // Delete lobby by lobbyID
 func (d *db) Delete(ctx context.Context, id string) error {
 	objectID, err := primitive.ObjectIDFromHex(id)
@@ -113,8 +221,7 @@ func (d *db) Delete(ctx context.Context, id string) error {
 	}
 
 	if result.DeletedCount == 0 {
-		// TODO ErrEntityNotFound
-		return fmt.Errorf("not found")
+		return auth.ErrNotFound
 	}
 	d.logger.Tracef("Deleted %d documents", result.DeletedCount)
 	return nil
@@ -139,7 +246,7 @@ func (d *db) DeleteAll(ctx context.Context) error {
 func (d *db) FindByParams(ctx context.Context, gameType string, maxPlayers, prizeSum int) (lobbyID string, err error) {
 	filter := bson.M{"game_type": gameType, "max_players": maxPlayers, "prize_sum": prizeSum}
 	findOptions := options.Find()
-	findOptions.SetSort(bson.D{{"start_time", 1}})
+	findOptions.SetSort(bson.D{{Key: "start_time", Value: 1}})
 	cursor, err := d.collection.Find(ctx, filter, findOptions)
 	if err != nil {
 		return "", fmt.Errorf("failed to create cursor due to: %v", err)
@@ -156,6 +263,24 @@ func (d *db) FindByParams(ctx context.Context, gameType string, maxPlayers, priz
 	return lobbies[0].ID, nil
 }
 
+// withObjectID returns the document with _id converted from hex, so it's found by the id later
+func withObjectID(v interface{}, id string) (bson.M, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert hex to objectID, hex: %s", id)
+	}
+	bytes, err := bson.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal document due to: %v", err)
+	}
+	var document bson.M
+	if err = bson.Unmarshal(bytes, &document); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal document bytes due to: %v", err)
+	}
+	document["_id"] = objectID
+	return document, nil
+}
+
 func NewStorage(database *mongo.Database, collection string, logger *logging.Logger) lobby.Storage {
 
 	return &db{
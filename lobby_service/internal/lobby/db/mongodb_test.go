@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"lobby_service/internal/lobby"
+	"lobby_service/pkg/logging"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testDatabase connects to MONGODB_TEST_URI, the test is skipped if it isn't set and fails if mongo
+// isn't reachable. CI and `make test` run mongo from docker-compose.test.yml
+func testDatabase(t *testing.T) *mongo.Database {
+	t.Helper()
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI isn't set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("failed to connect to mongo: %v", err)
+	}
+	if err = client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
+		t.Fatalf("failed to ping mongo: %v", err)
+	}
+	database := client.Database(fmt.Sprintf("lobby-service-test-%d", time.Now().UnixNano()))
+	t.Cleanup(func() {
+		database.Drop(context.Background())
+		client.Disconnect(context.Background())
+	})
+	return database
+}
+
+func TestMongoAddPlayerConcurrently(t *testing.T) {
+	const (
+		maxPlayers = 10
+		users      = 150
+		// every user joins twice, so duplicates race with each other too
+		joinsPerUser = 2
+	)
+	logger := logging.GetLogger("error")
+	storage := NewStorage(testDatabase(t), "lobbies", &logger)
+	ctx := context.Background()
+	lobbyID, err := storage.Create(ctx, lobby.Lobby{GameType: "snake", MaxPlayers: maxPlayers, Players: []lobby.Player{}})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	var (
+		wg                   sync.WaitGroup
+		mu                   sync.Mutex
+		joined, full, inside int
+		unexpected           []error
+	)
+	start := make(chan struct{})
+	for i := 0; i < users*joinsPerUser; i++ {
+		wg.Add(1)
+		go func(userID string) {
+			defer wg.Done()
+			<-start
+			_, err := storage.AddPlayer(ctx, lobbyID, lobby.Player{ID: userID})
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				joined++
+			case errors.Is(err, lobby.ErrLobbyFull):
+				full++
+			case errors.Is(err, lobby.ErrPlayerInLobby):
+				inside++
+			default:
+				unexpected = append(unexpected, err)
+			}
+		}(fmt.Sprintf("user-%d", i%users))
+	}
+	close(start)
+	wg.Wait()
+
+	if len(unexpected) > 0 {
+		t.Fatalf("AddPlayer() unexpected errors: %v", unexpected)
+	}
+	if joined != maxPlayers {
+		t.Errorf("joined = %d, want %d", joined, maxPlayers)
+	}
+	if joined+full+inside != users*joinsPerUser {
+		t.Errorf("joined %d + full %d + already inside %d != %d joins", joined, full, inside, users*joinsPerUser)
+	}
+
+	got, err := storage.FindById(ctx, lobbyID)
+	if err != nil {
+		t.Fatalf("FindById() error = %v", err)
+	}
+	if got.NowPlayers != maxPlayers || len(got.Players) != maxPlayers {
+		t.Errorf("now_players = %d, players = %d, want %d", got.NowPlayers, len(got.Players), maxPlayers)
+	}
+	seen := map[string]bool{}
+	for _, player := range got.Players {
+		if seen[player.ID] {
+			t.Errorf("player %s is in the lobby twice", player.ID)
+		}
+		seen[player.ID] = true
+	}
+}
+
+func TestMongoUpdateTimeKeepsPlayers(t *testing.T) {
+	logger := logging.GetLogger("error")
+	storage := NewStorage(testDatabase(t), "lobbies", &logger)
+	ctx := context.Background()
+	lobbyID, err := storage.Create(ctx, lobby.Lobby{GameType: "snake", MaxPlayers: 2, Players: []lobby.Player{}, StartTime: 100, EndTime: 200})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err = storage.AddPlayer(ctx, lobbyID, lobby.Player{ID: "user"}); err != nil {
+		t.Fatalf("AddPlayer() error = %v", err)
+	}
+
+	if err = storage.UpdateTime(ctx, lobbyID, 300, 400); err != nil {
+		t.Fatalf("UpdateTime() error = %v", err)
+	}
+	got, err := storage.FindById(ctx, lobbyID)
+	if err != nil {
+		t.Fatalf("FindById() error = %v", err)
+	}
+	if got.StartTime != 300 || got.EndTime != 400 {
+		t.Errorf("start_time = %d, end_time = %d, want 300, 400", got.StartTime, got.EndTime)
+	}
+	if got.NowPlayers != 1 || len(got.Players) != 1 {
+		t.Errorf("now_players = %d, players = %d, want 1", got.NowPlayers, len(got.Players))
+	}
+}
@@ -0,0 +1,151 @@
+package lobby
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	EventPlayerJoined      = "player_joined"
+	EventPlayerReady       = "player_ready"
+	EventLobbyFull         = "lobby_full"
+	EventGameServerCreated = "game_server_created"
+	EventLobbyRescheduled  = "lobby_rescheduled"
+	EventLobbyCancelled    = "lobby_cancelled"
+
+	// eventsHistory is how many last events of the lobby are kept to be replayed after reconnect
+	eventsHistory = 100
+	// eventsRetention is how long events of the closed lobby are kept, so the clients get the last events
+	eventsRetention = time.Minute
+	// eventsBuffer is how many events a subscriber can fall behind, the slow subscriber is dropped
+	// and replays missed events after reconnect
+	eventsBuffer = 32
+	// eventsStreamDuration keeps the stream shorter than write timeouts of the gateway and the service,
+	// the client reconnects after eventsRetry
+	eventsStreamDuration = 10 * time.Second
+	eventsRetry          = time.Second
+)
+
+// Event is sent to clients watching the lobby. ID grows with every published event, clients send the last
+// one they got in Last-Event-ID to get missed events
+type Event struct {
+	ID           int64     `json:"id"`
+	Type         string    `json:"type"`
+	LobbyID      string    `json:"lobby_id"`
+	UserID       string    `json:"user_id,omitempty"`
+	GameServerID string    `json:"game_server_id,omitempty"`
+	StartTime    int64     `json:"start_time,omitempty"`
+	At           time.Time `json:"at"`
+}
+
+// closes reports whether the lobby gets no events after the event
+func (e Event) closes() bool {
+	return e.Type == EventGameServerCreated || e.Type == EventLobbyCancelled
+}
+
+type lobbyEvents struct {
+	history     []Event
+	subscribers map[chan Event]struct{}
+	closedAt    time.Time
+}
+
+// EventBus delivers events of lobbies to subscribers in the process. Publish never blocks
+type EventBus struct {
+	mu      sync.Mutex
+	lastID  int64
+	lobbies map[string]*lobbyEvents
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{
+		lobbies: map[string]*lobbyEvents{},
+	}
+}
+
+func (b *EventBus) Publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cleanup()
+
+	b.lastID++
+	event.ID = b.lastID
+	event.At = time.Now()
+	events := b.lobby(event.LobbyID)
+	events.history = append(events.history, event)
+	if len(events.history) > eventsHistory {
+		events.history = events.history[len(events.history)-eventsHistory:]
+	}
+	for ch := range events.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(events.subscribers, ch)
+			close(ch)
+		}
+	}
+	if event.closes() {
+		events.closedAt = event.At
+		for ch := range events.subscribers {
+			delete(events.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe returns events of the lobby published after lastEventID and the channel of next events.
+// The channel is closed when the lobby closes or the subscriber falls behind, unsubscribe must be called
+// when the subscriber stops reading
+func (b *EventBus) Subscribe(lobbyID string, lastEventID int64) (missed []Event, events <-chan Event, unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cleanup()
+
+	lobby := b.lobby(lobbyID)
+	if lastEventID > 0 {
+		for _, event := range lobby.history {
+			if event.ID > lastEventID {
+				missed = append(missed, event)
+			}
+		}
+	}
+	ch := make(chan Event, eventsBuffer)
+	if !lobby.closedAt.IsZero() {
+		close(ch)
+		return missed, ch, func() {}
+	}
+	lobby.subscribers[ch] = struct{}{}
+	return missed, ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, found := lobby.subscribers[ch]; found {
+			delete(lobby.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (b *EventBus) lobby(lobbyID string) *lobbyEvents {
+	events, found := b.lobbies[lobbyID]
+	if !found {
+		events = &lobbyEvents{subscribers: map[chan Event]struct{}{}}
+		b.lobbies[lobbyID] = events
+	}
+	return events
+}
+
+// cleanup forgets closed lobbies after retention and lobbies nobody watches which had no events for it
+func (b *EventBus) cleanup() {
+	now := time.Now()
+	for id, events := range b.lobbies {
+		if !events.closedAt.IsZero() && now.Sub(events.closedAt) > eventsRetention {
+			delete(b.lobbies, id)
+			continue
+		}
+		if len(events.subscribers) > 0 {
+			continue
+		}
+		if len(events.history) == 0 || now.Sub(events.history[len(events.history)-1].At) > eventsRetention {
+			delete(b.lobbies, id)
+		}
+	}
+}
@@ -3,11 +3,15 @@ package lobby
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/julienschmidt/httprouter"
 	"lobby_service/internal/auth"
 	"lobby_service/pkg/logging"
+	"lobby_service/pkg/pagination"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 var (
@@ -19,24 +23,27 @@ var (
 	joinLobbyURL          = "/api/lobbies/join"
 	getLobbyIDByParamsURL = "/api/lobbies/params"
 	deleteAllURL          = "/api/lobbies/del/all"
+	lobbyEventsURL        = "/api/lobbies/events/:id"
 )
 
 type Handler struct {
 	Logger       logging.Logger
 	LobbyService Service
+	Events       *EventBus
 }
 
 func (h *Handler) Register(router *httprouter.Router) {
 	router.HandlerFunc(http.MethodPost, lobbiesUrl, auth.Middleware(h.CreateLobby))
 	router.HandlerFunc(http.MethodPost, lobbyUrl, auth.Middleware(h.GetLobbyById))
 	router.HandlerFunc(http.MethodPost, getAllLobbysUrl, auth.Middleware(h.GetLobbys))
-	router.HandlerFunc(http.MethodDelete, lobbyUrl, auth.Middleware(h.DeleteLobby))
+	router.HandlerFunc(http.MethodDelete, lobbyUrl, auth.RoleMiddleware(h.DeleteLobby, auth.RoleOperator, auth.RoleAdmin))
 	router.HandlerFunc(http.MethodPatch, lobbiesUrl, auth.Middleware(h.PartiallyUpdateLobby))
-	router.HandlerFunc(http.MethodPost, joinLobbyURL, auth.Middleware(h.JoinLobby))
+	router.HandlerFunc(http.MethodPost, joinLobbyURL, auth.RoleMiddleware(h.JoinLobby, auth.AccountRoles...))
 	router.HandlerFunc(http.MethodPost, getLobbyIDByParamsURL, auth.Middleware(h.GetLobbyIDByParams))
-	router.HandlerFunc(http.MethodPut, updateTime, auth.NoAuthMiddleware(h.UpdateLobbyTime))
+	router.HandlerFunc(http.MethodPut, updateTime, auth.RoleMiddleware(h.UpdateLobbyTime, auth.RoleService))
 	//router.HandlerFunc(http.MethodDelete, recreateUrl, auth.NoAuthMiddleware(h.RecreateLobby))
-	router.HandlerFunc(http.MethodDelete, deleteAllURL, auth.Middleware(h.DeleteAll))
+	router.HandlerFunc(http.MethodDelete, deleteAllURL, auth.RoleMiddleware(h.DeleteAll, auth.RoleAdmin))
+	router.HandlerFunc(http.MethodGet, lobbyEventsURL, auth.Middleware(h.LobbyEvents))
 
 }
 
@@ -54,7 +61,6 @@ func (h *Handler) CreateLobby(w http.ResponseWriter, r *http.Request) error {
 	var dto LobbyDTO
 	defer r.Body.Close()
 	err := json.NewDecoder(r.Body).Decode(&dto)
-	dto.JWTToken = r.Header.Get("Authorization")
 	if err != nil {
 		return auth.BadRequestError("invalid JSON scheme. check swagger API")
 	}
@@ -106,10 +112,14 @@ func (h *Handler) GetLobbyById(w http.ResponseWriter, r *http.Request) error {
 }
 
 // Gets lobbies
-// @Summary Get all lobbies
+// @Summary Get page of lobbies
 // @Accept json
 // @Produce json
 // @Tags Lobbies
+// @Param limit query int false "page size, 20 by default, 100 at most"
+// @Param after query string false "next_cursor of the previous page"
+// @Param sort query string false "id, start_time, end_time, now_players, ticket_price or prize_sum, - prefix for descending"
+// @Param game_type query string false "lobbies of the game type"
 // @Success 200
 // @Failure 400
 // @Router /api/lobbies/all [post]
@@ -117,7 +127,13 @@ func (h *Handler) GetLobbys(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Info("GET LOBBYS")
 	w.Header().Set("Content-Type", "application/json")
 
-	lobbies, err := h.LobbyService.GetAll(r.Context())
+	query := r.URL.Query()
+	params, err := pagination.Parse(query, SortFields...)
+	if err != nil {
+		return auth.BadRequestError(err.Error())
+	}
+
+	lobbies, err := h.LobbyService.GetPage(r.Context(), NewFilter(query), params)
 	if err != nil {
 		return err
 	}
@@ -210,6 +226,7 @@ func (h *Handler) PartiallyUpdateLobby(w http.ResponseWriter, r *http.Request) e
 // @Tags Lobbies
 // @Success 204
 // @Failure 400
+// @Failure 403 {object} auth.AppError
 // @Router /api/lobbies/id/:id [delete]
 func (h *Handler) DeleteLobby(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Info("DELETE LOBBY")
@@ -235,6 +252,7 @@ func (h *Handler) DeleteLobby(w http.ResponseWriter, r *http.Request) error {
 // @Tags Lobbies internal
 // @Success 204
 // @Failure 400
+// @Failure 403 {object} auth.AppError
 // @Router /api/lobbies/del/all [delete]
 func (h *Handler) DeleteAll(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Info("DELETE ALL")
@@ -250,12 +268,16 @@ func (h *Handler) DeleteAll(w http.ResponseWriter, r *http.Request) error {
 }
 
 // JoinLobby handles join lobby function
-// @Summary adds user to lobby by userID, lobbyID and ticketID
+// @Summary adds the user of the token to lobby by lobbyID and ticketID
+// @Description The join is idempotent by request_id: a retry with the same request_id continues the join or returns its result
 // @Accept json
 // @Produce json
 // @Tags Lobbies
 // @Success 200
 // @Failure 400
+// @Failure 403 {object} auth.AppError "the token has no required role or the user is banned"
+// @Failure 404 {object} auth.AppError "the lobby isn't found"
+// @Failure 409 {object} auth.AppError "the join with the request_id is being processed"
 // @Router /api/lobbies/join [post]
 func (h *Handler) JoinLobby(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Info("JOIN LOBBY")
@@ -265,9 +287,17 @@ func (h *Handler) JoinLobby(w http.ResponseWriter, r *http.Request) error {
 	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
 		return auth.BadRequestError("invalid JSON scheme. check swagger API")
 	}
-	dto.JWTToken = r.Header.Get("Authorization")
+	claims, ok := auth.Claims(r.Context())
+	if !ok {
+		return auth.ErrWrongToken
+	}
+	dto.UserID = claims.Id
 	err := h.LobbyService.AddUserToLobby(context.Background(), dto)
 	if err != nil {
+		var appErr *auth.AppError
+		if errors.As(err, &appErr) {
+			return err
+		}
 		return fmt.Errorf("failed to add user to lobby due to: %v", err)
 	}
 
@@ -275,6 +305,92 @@ func (h *Handler) JoinLobby(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+// LobbyEvents streams events of the lobby
+// @Summary Server-sent events of the lobby: player_joined, player_ready, lobby_full, game_server_created, lobby_rescheduled, lobby_cancelled
+// @Description The stream starts with the lobby event (the current lobby) and ends before the server's write timeout,
+// @Description the client reconnects with Last-Event-ID header and gets the missed events instead of the lobby
+// @Produce text/event-stream
+// @Param id path string true "Lobby ID"
+// @Param Last-Event-ID header int false "id of the last event the client got"
+// @Tags Lobbies
+// @Success 200 {object} Event
+// @Failure 404
+// @Router /api/lobbies/events/{id} [get]
+func (h *Handler) LobbyEvents(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("LOBBY EVENTS")
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return fmt.Errorf("streaming is not supported")
+	}
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	id := params.ByName("id")
+	lastEventID, _ := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64)
+
+	// the client subscribes before the lobby is read, so no event is lost between them
+	missed, events, unsubscribe := h.Events.Subscribe(id, lastEventID)
+	defer unsubscribe()
+	var lobby *Lobby
+	if lastEventID == 0 {
+		found, err := h.LobbyService.GetById(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		lobby = &found
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", eventsRetry.Milliseconds())
+	if lobby != nil {
+		if err := writeEvent(w, "", "lobby", lobby); err != nil {
+			return nil
+		}
+	}
+	for _, event := range missed {
+		if err := writeEvent(w, strconv.FormatInt(event.ID, 10), event.Type, event); err != nil {
+			return nil
+		}
+	}
+	flusher.Flush()
+
+	timer := time.NewTimer(eventsStreamDuration)
+	defer timer.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-timer.C:
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := writeEvent(w, strconv.FormatInt(event.ID, 10), event.Type, event); err != nil {
+				h.Logger.Debugf("failed to write event of lobby %s due to: %v", id, err)
+				return nil
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent writes the server-sent event, events without id don't change Last-Event-ID of the client
+func writeEvent(w http.ResponseWriter, id, event string, data interface{}) error {
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err = fmt.Fprintf(w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, bytes)
+	return err
+}
+
 // GetLobbyIDByParams returns lobbyID of lobby with the closest start_time to current time
 // @Summary return lobbyID by game_type, prize_sum and max_players
 // @Accept json
@@ -319,8 +435,7 @@ func (h *Handler) UpdateLobbyTime(w http.ResponseWriter, r *http.Request) error
 	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
 	id := params.ByName("id")
 	dto := UpdateTimeDTO{
-		ID:       id,
-		JWTToken: r.Header.Get("Authorization"),
+		ID: id,
 	}
 	newExpiration, err := h.LobbyService.UpdateLobbyTime(r.Context(), dto)
 	if err != nil {
@@ -0,0 +1,458 @@
+package lobby
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"lobby_service/internal/auth"
+	"lobby_service/internal/lobby/api"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	JoinRunning      = "running"
+	JoinCompensating = "compensating"
+	JoinCompleted    = "completed"
+	JoinCompensated  = "compensated"
+
+	StepStarted     = "started"
+	StepDone        = "done"
+	StepRefused     = "refused"
+	StepCompensated = "compensated"
+
+	stepConsumeTicket    = "consume_ticket"
+	stepUseTicket        = "use_ticket"
+	stepAddPlayer        = "add_player"
+	stepCreateNextLobby  = "create_next_lobby"
+	stepNotifyManager    = "notify_manager"
+	stepCreateGameServer = "create_game_server"
+	stepDeleteLobby      = "delete_lobby"
+
+	// joinLease is how long a request holds the join, a join of a crashed request is continued after it
+	joinLease = 30 * time.Second
+)
+
+// ErrJoinLost is returned by JoinStorage.Save if another request took the join over after the lease expired
+var ErrJoinLost = errors.New("join is held by another request")
+
+// Join is the saga of joining the lobby. Its state is stored after every step, so a request with the same
+// request id continues the join where it stopped or returns its result. If a step fails, the done steps
+// are compensated in reverse order: the player is removed, the ticket is released and refunded
+type Join struct {
+	RequestID string `json:"request_id" bson:"_id"`
+	UserID    string `json:"user_id" bson:"user_id"`
+	LobbyID   string `json:"lobby_id" bson:"lobby_id"`
+	TicketID  string `json:"ticket_id" bson:"ticket_id"`
+	GameType  string `json:"game_type" bson:"game_type"`
+	State     string `json:"state" bson:"state"`
+	// Steps are statuses of steps by name
+	Steps map[string]string `json:"steps" bson:"steps"`
+	// Full is set when the player took the last seat, the join starts the game then
+	Full bool `json:"full" bson:"full"`
+	// NextLobbyID and GameServerID are stored before they are created, so a retry creates the same ones
+	NextLobbyID  string `json:"next_lobby_id,omitempty" bson:"next_lobby_id,omitempty"`
+	GameServerID string `json:"game_server_id,omitempty" bson:"game_server_id,omitempty"`
+	// Error is the reason the join was compensated
+	Error string `json:"error,omitempty" bson:"error,omitempty"`
+	// Owner is the token of the request holding the join until LockedUntil
+	Owner       string    `json:"-" bson:"owner"`
+	LockedUntil time.Time `json:"-" bson:"locked_until"`
+	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+func NewJoin(dto JoinLobbyDTO, gameType string) Join {
+	now := time.Now()
+	return Join{
+		RequestID: dto.RequestID,
+		UserID:    dto.UserID,
+		LobbyID:   dto.LobbyID,
+		TicketID:  dto.TicketID,
+		GameType:  gameType,
+		State:     JoinRunning,
+		Steps:     map[string]string{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Matches reports whether the request is a retry of the join
+func (j Join) Matches(dto JoinLobbyDTO) bool {
+	return j.UserID == dto.UserID && j.LobbyID == dto.LobbyID && j.TicketID == dto.TicketID
+}
+
+type JoinStorage interface {
+	// Find returns ErrNotFound if there is no join with the request id
+	Find(ctx context.Context, requestID string) (Join, error)
+	// Claim creates the join or returns the stored join with the same request id. Running and compensating
+	// joins are locked for the lease by the owner of the join, returns ErrConflict if another request holds the lock
+	Claim(ctx context.Context, join Join, lease time.Duration) (Join, error)
+	// Save replaces the stored join if the owner still holds its lock, returns ErrJoinLost otherwise
+	Save(ctx context.Context, join Join) error
+}
+
+// joinStep is applied once, compensate reverts it. Compensations must succeed if the step wasn't applied,
+// a step with unknown outcome is compensated too
+type joinStep struct {
+	name       string
+	do         func(ctx context.Context, join *Join) error
+	compensate func(ctx context.Context, join *Join) error
+}
+
+// refusedError is returned if the other service answered and refused the step, the step wasn't applied
+// and isn't compensated
+type refusedError struct {
+	error
+}
+
+func refused(err error) error {
+	return refusedError{err}
+}
+
+// newToken returns random hex token, it is used as request id and owner of the join
+func newToken() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate token due to: %v", err)
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// newObjectID returns hex of a mongo object id, which starts with the time like the generated ones,
+// the rest is random, so ids of game servers and lobbies created by the join can't be guessed
+func newObjectID() (string, error) {
+	bytes := make([]byte, 12)
+	binary.BigEndian.PutUint32(bytes, uint32(time.Now().Unix()))
+	if _, err := rand.Read(bytes[4:]); err != nil {
+		return "", fmt.Errorf("failed to generate object id due to: %v", err)
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// reserveSteps take the ticket and the seat, they are compensated if any of them fails
+func (s service) reserveSteps() []joinStep {
+	return []joinStep{
+		{
+			name: stepConsumeTicket,
+			do: func(ctx context.Context, join *Join) error {
+				return ConsumeTicket(ctx, ConsumeTicketDTO{ID: join.UserID, GameType: join.GameType, TicketID: join.TicketID})
+			},
+			compensate: func(ctx context.Context, join *Join) error {
+				return RefundTicket(ctx, ConsumeTicketDTO{ID: join.UserID, GameType: join.GameType, TicketID: join.TicketID})
+			},
+		},
+		{
+			name: stepUseTicket,
+			do: func(ctx context.Context, join *Join) error {
+				return UseTicket(ctx, join.TicketID)
+			},
+			compensate: func(ctx context.Context, join *Join) error {
+				return ReleaseTicket(ctx, join.TicketID)
+			},
+		},
+		{
+			name: stepAddPlayer,
+			do:   s.addPlayer,
+			compensate: func(ctx context.Context, join *Join) error {
+				return s.storage.RemovePlayer(ctx, join.LobbyID, join.UserID)
+			},
+		},
+	}
+}
+
+// addPlayer takes a seat in the lobby, the player who takes the last seat starts the game
+func (s service) addPlayer(ctx context.Context, join *Join) error {
+	lobby, err := s.storage.AddPlayer(ctx, join.LobbyID, Player{
+		ID:    join.UserID,
+		Ready: false,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrNotFound):
+			return refused(fmt.Errorf("lobby not found"))
+		case errors.Is(err, ErrLobbyFull), errors.Is(err, ErrPlayerInLobby):
+			// the player who is in the lobby joined with another request, that join keeps the seat
+			return refused(err)
+		}
+		return err
+	}
+	join.Full = lobby.NowPlayers == lobby.MaxPlayers
+	s.events.Publish(Event{Type: EventPlayerJoined, LobbyID: join.LobbyID, UserID: join.UserID})
+	if join.Full {
+		s.events.Publish(Event{Type: EventLobbyFull, LobbyID: join.LobbyID})
+	}
+	return nil
+}
+
+// startSteps replace the full lobby with a game server and a new lobby of the same type. They aren't
+// compensated, the players have their seats, a retry with the same request id continues them. Ids of
+// the next lobby and the game server are stored before they are created, so a retry doesn't create them twice
+func (s service) startSteps() []joinStep {
+	var lobby *Lobby
+	find := func(ctx context.Context, join *Join) (Lobby, error) {
+		if lobby != nil {
+			return *lobby, nil
+		}
+		found, err := s.storage.FindById(ctx, join.LobbyID)
+		if err != nil {
+			if errors.Is(err, auth.ErrNotFound) {
+				return found, fmt.Errorf("lobby not found")
+			}
+			return found, err
+		}
+		lobby = &found
+		return found, nil
+	}
+	return []joinStep{
+		{
+			name: stepCreateNextLobby,
+			do: func(ctx context.Context, join *Join) error {
+				full, err := find(ctx, join)
+				if err != nil {
+					return err
+				}
+				if join.NextLobbyID == "" {
+					if join.NextLobbyID, err = newObjectID(); err != nil {
+						return err
+					}
+					if err = s.saveJoin(ctx, join); err != nil {
+						return err
+					}
+				}
+				_, err = s.storage.Create(ctx, nextLobby(full, join.NextLobbyID))
+				return err
+			},
+		},
+		{
+			name: stepNotifyManager,
+			do: func(ctx context.Context, join *Join) error {
+				full, err := find(ctx, join)
+				if err != nil {
+					return err
+				}
+				return NotifyManager(ctx, NewNotifyManagerDTO(nextLobby(full, join.NextLobbyID)))
+			},
+		},
+		{
+			name: stepCreateGameServer,
+			do: func(ctx context.Context, join *Join) error {
+				full, err := find(ctx, join)
+				if err != nil {
+					return err
+				}
+				if join.GameServerID == "" {
+					if join.GameServerID, err = newObjectID(); err != nil {
+						return err
+					}
+					if err = s.saveJoin(ctx, join); err != nil {
+						return err
+					}
+				}
+				switch full.GameType {
+				case "snake":
+					if err = s.CreateSnakeGS(full, join.GameServerID); err != nil {
+						return fmt.Errorf("failed to create snake game server due to: %v", err)
+					}
+				case "quiz":
+					if err = s.CreateQuizGS(full, join.GameServerID); err != nil {
+						return fmt.Errorf("failed to create quiz game server due to: %v", err)
+					}
+				}
+				s.events.Publish(Event{Type: EventGameServerCreated, LobbyID: join.LobbyID, GameServerID: join.GameServerID})
+				return nil
+			},
+		},
+		{
+			name: stepDeleteLobby,
+			do: func(ctx context.Context, join *Join) error {
+				// the full lobby isn't cancelled, it is deleted without lobby_cancelled event
+				err := s.storage.Delete(ctx, join.LobbyID)
+				if errors.Is(err, auth.ErrNotFound) {
+					return nil
+				}
+				return err
+			},
+		},
+	}
+}
+
+// nextLobby is the lobby of the same type which replaces the full lobby
+func nextLobby(full Lobby, id string) Lobby {
+	lobby := NewLobby(LobbyDTO{
+		GameType:    full.GameType,
+		MaxPlayers:  full.MaxPlayers,
+		TicketPrice: full.TicketPrice,
+		PrizeSum:    full.PrizeSum,
+		PrizeType:   full.PrizeType,
+		StartTime:   full.StartTime,
+		EndTime:     full.EndTime,
+	})
+	lobby.ID = id
+	return lobby
+}
+
+// runJoin continues the join from its stored state and stores the result, the lock is released at the end.
+// If another request took the join over, the join is left to it and ErrConflict is returned
+func (s service) runJoin(ctx context.Context, join Join) error {
+	if join.State == JoinRunning {
+		for _, step := range s.reserveSteps() {
+			if join.Steps[step.name] == StepStarted {
+				// the request holding the join stopped during the step, it may have been applied
+				join.State = JoinCompensating
+				join.Error = "join was interrupted"
+				break
+			}
+		}
+	}
+
+	if join.State == JoinRunning {
+		if err := s.runSteps(ctx, &join, s.reserveSteps()); err != nil {
+			if errors.Is(err, ErrJoinLost) {
+				return auth.ErrConflict
+			}
+			var refusal refusedError
+			if !errors.As(err, &refusal) {
+				s.logger.Errorf("join %s failed, compensating: %v", join.RequestID, err)
+			}
+			join.State = JoinCompensating
+			join.Error = err.Error()
+		}
+	}
+
+	if join.State == JoinCompensating {
+		if err := s.compensate(ctx, &join); err != nil {
+			if errors.Is(err, ErrJoinLost) {
+				return auth.ErrConflict
+			}
+			s.logger.Errorf("failed to compensate join %s due to: %v", join.RequestID, err)
+			s.unlockJoin(ctx, join)
+			return fmt.Errorf("failed to cancel join, retry with the same request_id: %v", err)
+		}
+		join.State = JoinCompensated
+		s.unlockJoin(ctx, join)
+		return errors.New(join.Error)
+	}
+
+	if join.Full {
+		if err := s.runSteps(ctx, &join, s.startSteps()); err != nil {
+			if errors.Is(err, ErrJoinLost) {
+				return auth.ErrConflict
+			}
+			s.unlockJoin(ctx, join)
+			return fmt.Errorf("player joined, but the game wasn't started, retry with the same request_id: %v", err)
+		}
+	}
+	join.State = JoinCompleted
+	s.unlockJoin(ctx, join)
+	return nil
+}
+
+// runSteps applies steps which aren't done, the state is stored before and after every step
+func (s service) runSteps(ctx context.Context, join *Join, steps []joinStep) error {
+	for _, step := range steps {
+		if join.Steps[step.name] == StepDone {
+			continue
+		}
+		if err := s.setStep(ctx, join, step.name, StepStarted); err != nil {
+			return err
+		}
+		if err := step.do(ctx, join); err != nil {
+			var refusal refusedError
+			if errors.As(err, &refusal) {
+				if err := s.setStep(ctx, join, step.name, StepRefused); err != nil {
+					return err
+				}
+			}
+			return err
+		}
+		if err := s.setStep(ctx, join, step.name, StepDone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compensate reverts started and done steps in reverse order
+func (s service) compensate(ctx context.Context, join *Join) error {
+	steps := s.reserveSteps()
+	for i := len(steps) - 1; i >= 0; i-- {
+		step := steps[i]
+		if status := join.Steps[step.name]; status != StepStarted && status != StepDone {
+			continue
+		}
+		if err := step.compensate(ctx, join); err != nil {
+			return fmt.Errorf("failed to compensate %s due to: %w", step.name, err)
+		}
+		if err := s.setStep(ctx, join, step.name, StepCompensated); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s service) setStep(ctx context.Context, join *Join, step, status string) error {
+	join.Steps[step] = status
+	return s.saveJoin(ctx, join)
+}
+
+// saveJoin stores the join and extends its lock for the lease
+func (s service) saveJoin(ctx context.Context, join *Join) error {
+	now := time.Now()
+	join.UpdatedAt = now
+	join.LockedUntil = now.Add(joinLease)
+	if err := s.joins.Save(ctx, *join); err != nil {
+		return fmt.Errorf("failed to save join due to: %w", err)
+	}
+	return nil
+}
+
+// unlockJoin stores the join without the lock, so a retry doesn't wait for the lease
+func (s service) unlockJoin(ctx context.Context, join Join) {
+	join.LockedUntil = time.Time{}
+	join.UpdatedAt = time.Now()
+	if err := s.joins.Save(ctx, join); err != nil && !errors.Is(err, ErrJoinLost) {
+		s.logger.Errorf("failed to save join %s due to: %v", join.RequestID, err)
+	}
+}
+
+// RefundTicket gives the consumed ticket back to the user. Granting a ticket the user owns is refused
+// with 409, so the refund is applied once
+func RefundTicket(ctx context.Context, dto ConsumeTicketDTO) error {
+	bytes, err := json.Marshal(&dto)
+	if err != nil {
+		return err
+	}
+
+	response, err := api.MakeRequestWithContext(ctx, http.MethodPost, GrantTicketURL, io.NopCloser(strings.NewReader(string(bytes))))
+	if err != nil {
+		return fmt.Errorf("failed to do request due to: %v", err)
+	}
+	defer response.Body.Close()
+	switch response.StatusCode {
+	case http.StatusOK, http.StatusConflict, http.StatusNotFound:
+		return nil
+	}
+	return fmt.Errorf("user service returned wrong status code: %d", response.StatusCode)
+}
+
+// ReleaseTicket makes the used ticket active again
+func ReleaseTicket(ctx context.Context, ticketID string) error {
+	response, err := api.MakeRequestWithContext(ctx, http.MethodPost, ReleaseTicketURL+url.PathEscape(ticketID), nil)
+	if err != nil {
+		return fmt.Errorf("failed to do request due to: %v", err)
+	}
+	defer response.Body.Close()
+	switch response.StatusCode {
+	case http.StatusNoContent, http.StatusNotFound:
+		return nil
+	}
+	return fmt.Errorf("ticket service returned wrong status code: %d", response.StatusCode)
+}
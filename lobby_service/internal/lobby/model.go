@@ -1,5 +1,22 @@
 package lobby
 
+import (
+	"net/url"
+	"time"
+)
+
+// SortFields can be used in sort parameter of the lobbies list
+var SortFields = []string{"start_time", "end_time", "now_players", "ticket_price", "prize_sum"}
+
+// Filter of the lobbies list, empty fields match any lobby
+type Filter struct {
+	GameType string
+}
+
+func NewFilter(query url.Values) Filter {
+	return Filter{GameType: query.Get("game_type")}
+}
+
 type Lobby struct {
 	ID          string   `json:"id" bson:"_id,omitempty"`
 	GameType    string   `json:"game_type" bson:"game_type"`
@@ -11,7 +28,38 @@ type Lobby struct {
 	Players     []Player `json:"players" bson:"players"`
 	StartTime   int64    `json:"start_time" bson:"start_time"`
 	EndTime     int64    `json:"end_time" bson:"end_time"`
-	JWTToken    string   `json:"-" bson:"-"`
+}
+
+// NewLobby creates the lobby without players, it starts at the next even hour if the start time isn't given
+// and lasts two hours
+func NewLobby(dto LobbyDTO) Lobby {
+	lobby := Lobby{
+		GameType:    dto.GameType,
+		MaxPlayers:  dto.MaxPlayers,
+		TicketPrice: dto.TicketPrice,
+		PrizeSum:    dto.PrizeSum,
+		Players:     []Player{},
+		StartTime:   dto.StartTime,
+		EndTime:     dto.EndTime,
+	}
+
+	var start int
+	now := time.Now()
+	hour := now.Hour()
+	if hour%2 == 0 {
+		start = hour + 2
+	} else {
+		start = hour + 1
+	}
+
+	// If start time and is not in a payload then it sets start time as next even hour
+	if dto.StartTime == 0 {
+		startTime := time.Date(now.Year(), now.Month(), now.Day(), start, 0, 0, 0, now.Location()).Unix()
+		lobby.StartTime = startTime
+	}
+
+	lobby.EndTime = lobby.StartTime + TwoHours
+	return lobby
 }
 
 func GetPlayersIDS(lobby Lobby) []string {
@@ -27,17 +75,17 @@ type Player struct {
 	Ready bool   `json:"ready"`
 }
 
-type UpdateUserDTO struct {
-	ID            string        `json:"id" bson:"_id,omitempty"`
-	Username      string        `json:"username" bson:"username"`
-	HasFreeTicket bool          `json:"has_free_ticket" bson:"has_free_ticket"`
-	Tickets       []GameTickets `json:"tickets" bson:"tickets"`
+// ConsumeTicketDTO takes one ticket of the game type from the user, the user must own the ticket
+type ConsumeTicketDTO struct {
+	ID       string `json:"id"`
+	GameType string `json:"game_type"`
+	TicketID string `json:"ticket_id"`
 }
 
-type GameTickets struct {
-	GameType string   `json:"game_type"`
-	Amount   int      `json:"amount"`
-	IDsOfGT  []string `json:"tickets_of_gt"`
+// SanctionStatus is the part of active sanctions of the user which the lobby checks
+type SanctionStatus struct {
+	Banned  bool   `json:"banned"`
+	Message string `json:"message"`
 }
 
 //type CreateLobbyDTO struct {
@@ -58,21 +106,29 @@ type LobbyDTO struct {
 	PrizeType   int    `json:"prize_type"`
 	StartTime   int64  `json:"start_time"`
 	EndTime     int64  `json:"end_time"`
-	JWTToken    string `json:"-"`
 }
 
 type JoinLobbyDTO struct {
-	UserID   string `json:"user_id"`
+	// UserID is taken from the verified token, user_id of the body is ignored
+	UserID   string `json:"-"`
 	LobbyID  string `json:"lobby_id"`
 	TicketID string `json:"ticket_id"`
-	JWTToken string `json:"-"`
+	// RequestID makes the join idempotent, a retry with the same id continues the join or returns its result
+	RequestID string `json:"request_id"`
 }
 
 type NotifyManagerDTO struct {
 	GameType   string `json:"game_type"`
 	LobbyID    string `json:"lobby_id"`
 	Expiration int64  `json:"expiration"`
-	JWTToken   string `json:"jwt_token"`
+}
+
+func NewNotifyManagerDTO(lobby Lobby) NotifyManagerDTO {
+	return NotifyManagerDTO{
+		GameType:   lobby.GameType,
+		LobbyID:    lobby.ID,
+		Expiration: lobby.StartTime,
+	}
 }
 
 type Params struct {
@@ -82,11 +138,12 @@ type Params struct {
 }
 
 type UpdateTimeDTO struct {
-	ID       string `json:"id"`
-	JWTToken string `json:"-"`
+	ID string `json:"id"`
 }
 
 type CreateGSDTO struct {
+	// ID is generated by the lobby service, so a retried creation doesn't create a second game server
+	ID        string   `json:"id"`
 	Players   []string `json:"players"`
 	StartTime int64    `json:"start_time"`
 	EndTime   int64    `json:"end_time"`
@@ -10,8 +10,11 @@ import (
 	"lobby_service/internal/auth"
 	"lobby_service/internal/lobby/api"
 	"lobby_service/pkg/logging"
+	"lobby_service/pkg/pagination"
+	"lobby_service/pkg/servicetoken"
 	"log"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
@@ -20,19 +23,23 @@ var _ Service = &service{}
 
 type service struct {
 	storage Storage
+	joins   JoinStorage
+	events  *EventBus
 	logger  logging.Logger
 }
 
-func NewService(storage Storage, logger logging.Logger) (Service, error) {
+func NewService(storage Storage, joins JoinStorage, events *EventBus, logger logging.Logger) (Service, error) {
 	return &service{
 		storage: storage,
+		joins:   joins,
+		events:  events,
 		logger:  logger,
 	}, nil
 }
 
 type Service interface {
 	Create(ctx context.Context, dto LobbyDTO) (string, error)
-	GetAll(ctx context.Context) ([]Lobby, error)
+	GetPage(ctx context.Context, filter Filter, params pagination.Params) (pagination.Page[Lobby], error)
 	GetById(ctx context.Context, id string) (Lobby, error)
 	Update(ctx context.Context, dto Lobby) error
 	Delete(ctx context.Context, id string) error
@@ -52,7 +59,13 @@ func NotifyManager(ctx context.Context, dto NotifyManagerDTO) error {
 	}
 	body := io.NopCloser(strings.NewReader(fmt.Sprintf(string(bytes))))
 	request, err := http.NewRequestWithContext(ctx, http.MethodPost, u, body)
-	request.Header.Add("Authorization", dto.JWTToken)
+	if err != nil {
+		return fmt.Errorf("failed to create new request due to: %v", err)
+	}
+	err = servicetoken.Authorize(ctx, request)
+	if err != nil {
+		return fmt.Errorf("failed to authorize request due to: %v", err)
+	}
 	var client http.Client
 	response, err := client.Do(request)
 	if err != nil {
@@ -77,32 +90,7 @@ func NotifyManager(ctx context.Context, dto NotifyManagerDTO) error {
 
 func (s service) Create(ctx context.Context, dto LobbyDTO) (lobbyID string, err error) {
 	s.logger.Debug("CREATE LOBBY SERVICE")
-	lobby := Lobby{
-		GameType:    dto.GameType,
-		MaxPlayers:  dto.MaxPlayers,
-		TicketPrice: dto.TicketPrice,
-		PrizeSum:    dto.PrizeSum,
-		Players:     []Player{},
-		StartTime:   dto.StartTime,
-		EndTime:     dto.EndTime,
-	}
-
-	var start int
-	now := time.Now()
-	hour := now.Hour()
-	if hour%2 == 0 {
-		start = hour + 2
-	} else {
-		start = hour + 1
-	}
-
-	// If start time and is not in a payload then it sets start time as next even hour
-	if dto.StartTime == 0 {
-		startTime := time.Date(now.Year(), now.Month(), now.Day(), start, 0, 0, 0, now.Location()).Unix()
-		lobby.StartTime = startTime
-	}
-
-	lobby.EndTime = lobby.StartTime + TwoHours
+	lobby := NewLobby(dto)
 
 	log.Printf("CREATING LOBBY WITH START TIME: %v", lobby.StartTime)
 
@@ -114,13 +102,8 @@ func (s service) Create(ctx context.Context, dto LobbyDTO) (lobbyID string, err
 		return lobbyID, fmt.Errorf("failed to create lobby. error: %w", err)
 	}
 
-	notifyDTO := NotifyManagerDTO{
-		GameType:   lobby.GameType,
-		LobbyID:    lobbyID,
-		Expiration: lobby.StartTime,
-		JWTToken:   dto.JWTToken,
-	}
-	err = NotifyManager(ctx, notifyDTO)
+	lobby.ID = lobbyID
+	err = NotifyManager(ctx, NewNotifyManagerDTO(lobby))
 	if err != nil {
 		return "", fmt.Errorf("failed to notify manager due to: %v", err)
 	}
@@ -140,12 +123,12 @@ func (s service) GetById(ctx context.Context, id string) (lobby Lobby, err error
 	return lobby, nil
 }
 
-func (s service) GetAll(ctx context.Context) ([]Lobby, error) {
-	lobbys, err := s.storage.FindAll(ctx)
+func (s service) GetPage(ctx context.Context, filter Filter, params pagination.Params) (pagination.Page[Lobby], error) {
+	lobbys, err := s.storage.FindPage(ctx, filter, params)
 	if err != nil {
-		return lobbys, fmt.Errorf("failed to find lobbys. error: %v", err)
+		return pagination.Page[Lobby]{}, fmt.Errorf("failed to find lobbys. error: %v", err)
 	}
-	return lobbys, nil
+	return pagination.NewPage(lobbys, params)
 }
 
 func (s service) Update(ctx context.Context, lobby Lobby) error {
@@ -168,7 +151,8 @@ func (s service) Delete(ctx context.Context, id string) error {
 		}
 		return fmt.Errorf("failed to delete lobby. error: %w", err)
 	}
-	return err
+	s.events.Publish(Event{Type: EventLobbyCancelled, LobbyID: id})
+	return nil
 }
 
 func (s service) DeleteAll(ctx context.Context) error {
@@ -188,79 +172,67 @@ func getPlayerIndex(id string, players []Player) (int, bool) {
 	return -1, false
 }
 
-func GetUserByID(ctx context.Context, userID string) (dto UpdateUserDTO, err error) {
-	_u := fmt.Sprintf("%s%s", GetUsersByIDURL, userID)
-
-	response, err := api.MakeRequestWithContext(ctx, http.MethodPost, _u, nil)
-	if err != nil {
-		return dto, err
-	}
-	defer response.Body.Close()
-
-	if err != nil {
-		return dto, fmt.Errorf("failed to do request due to: %v", err)
-	}
-
-	err = json.NewDecoder(response.Body).Decode(&dto)
-	if err != nil {
-		return dto, fmt.Errorf("failed to parse response body due to: %v", err)
-	}
-	return dto, nil
-}
-
-func getGameTickets(gameType string, gameTicketsArr []GameTickets) (index int, found bool) {
-	for i := 0; i < len(gameTicketsArr); i++ {
-		if gameTicketsArr[i].GameType == gameType {
-			return i, true
-		}
-	}
-	return -1, false
-}
-
 func UseTicket(ctx context.Context, ticketID string) error {
 	url := fmt.Sprintf("%s/%s", UseTicketURL, ticketID)
 	response, err := api.MakeRequestWithContext(ctx, http.MethodPost, url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to do request due to: %v", err)
 	}
+	defer response.Body.Close()
 	if response.StatusCode != 200 {
 		bytes, err := ioutil.ReadAll(response.Body)
 		if err != nil {
 			return err
 		}
-		return fmt.Errorf("failed to use lobby due to: %s", string(bytes))
+		err = fmt.Errorf("failed to use ticket due to: %s", string(bytes))
+		if response.StatusCode >= http.StatusInternalServerError {
+			return err
+		}
+		return refused(err)
 	}
 	return nil
 }
 
-func UpdateUserTicket(ctx context.Context, gameType string, dto UpdateUserDTO) error {
-	var (
-		found bool
-		i     int
-	)
-	if i, found = getGameTickets(gameType, dto.Tickets); !found {
-		return fmt.Errorf("user has no tickets of game type: %s", gameType)
-	}
-
-	if dto.Tickets[i].Amount == 0 {
-		return fmt.Errorf("user has no active tickets of game type: %s", gameType)
-	}
-
-	dto.Tickets[i].Amount -= 1
-
-	_url := UpdateUserURL
+// ConsumeTicket takes the ticket from the user in one atomic update of user service
+func ConsumeTicket(ctx context.Context, dto ConsumeTicketDTO) error {
 	bytes, err := json.Marshal(&dto)
 	if err != nil {
 		return err
 	}
 
-	response, err := api.MakeRequestWithContext(ctx, http.MethodPost, _url, io.NopCloser(strings.NewReader(string(bytes))))
+	response, err := api.MakeRequestWithContext(ctx, http.MethodPost, ConsumeTicketURL, io.NopCloser(strings.NewReader(string(bytes))))
 	if err != nil {
 		return fmt.Errorf("failed to do request due to: %v", err)
 	}
-	if response.StatusCode != 204 {
+	defer response.Body.Close()
+	switch response.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return refused(fmt.Errorf("user not found"))
+	case http.StatusBadRequest, http.StatusConflict:
+		return refused(fmt.Errorf("user has no active ticket %s of game type: %s", dto.TicketID, dto.GameType))
+	}
+	return fmt.Errorf("user service returned wrong status code: %d", response.StatusCode)
+}
+
+// CheckBan returns BannedError if the user is banned, banned users can't join lobbies
+func CheckBan(ctx context.Context, userID string) error {
+	response, err := api.MakeRequestWithContext(ctx, http.MethodGet, SanctionsURL+url.PathEscape(userID), nil)
+	if err != nil {
+		return fmt.Errorf("failed to do request due to: %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
 		return fmt.Errorf("user service returned wrong status code: %d", response.StatusCode)
 	}
+	var status SanctionStatus
+	if err := json.NewDecoder(response.Body).Decode(&status); err != nil {
+		return fmt.Errorf("failed to decode sanctions of user due to: %v", err)
+	}
+	if status.Banned {
+		return auth.BannedError(status.Message)
+	}
 	return nil
 }
 
@@ -277,11 +249,13 @@ func (s service) RecreateLobby(ctx context.Context, dto LobbyDTO, lobbyID string
 	return nil
 }
 
-func (s service) CreateSnakeGS(lobby Lobby) error {
+// CreateSnakeGS creates the game server with the id, creating it again with the same id isn't an error
+func (s service) CreateSnakeGS(lobby Lobby, id string) error {
 	log.Println("CREATE SNAKE GAME SERVER")
 	log.Printf("%v", lobby)
 	ids := GetPlayersIDS(lobby)
 	var dto CreateGSDTO
+	dto.ID = id
 	dto.Players = ids
 	dto.StartTime = lobby.StartTime
 	dto.EndTime = lobby.EndTime
@@ -295,7 +269,10 @@ func (s service) CreateSnakeGS(lobby Lobby) error {
 		return fmt.Errorf("failed to create new request due to: %v", err)
 	}
 
-	request.Header.Add("Authorization", lobby.JWTToken)
+	err = servicetoken.Authorize(request.Context(), request)
+	if err != nil {
+		return fmt.Errorf("failed to authorize request due to: %v", err)
+	}
 
 	var client http.Client
 	response, err := client.Do(request)
@@ -305,17 +282,20 @@ func (s service) CreateSnakeGS(lobby Lobby) error {
 	if response == nil {
 		return fmt.Errorf("response is nil")
 	}
+	defer response.Body.Close()
 	if response.StatusCode != http.StatusCreated {
 		return fmt.Errorf("failed status code: %d", response.StatusCode)
 	}
 	return nil
 }
 
-func (s service) CreateQuizGS(lobby Lobby) error {
+// CreateQuizGS creates the game server with the id, creating it again with the same id isn't an error
+func (s service) CreateQuizGS(lobby Lobby, id string) error {
 	log.Println("CREATE QUIZ GAME SERVER")
 	log.Printf("%v", lobby)
 	ids := GetPlayersIDS(lobby)
 	var dto CreateGSDTO
+	dto.ID = id
 	dto.Players = ids
 	dto.StartTime = lobby.StartTime
 	dto.EndTime = lobby.EndTime
@@ -329,7 +309,10 @@ func (s service) CreateQuizGS(lobby Lobby) error {
 		return fmt.Errorf("failed to create new request due to: %v", err)
 	}
 
-	request.Header.Add("Authorization", lobby.JWTToken)
+	err = servicetoken.Authorize(request.Context(), request)
+	if err != nil {
+		return fmt.Errorf("failed to authorize request due to: %v", err)
+	}
 
 	var client http.Client
 	response, err := client.Do(request)
@@ -339,124 +322,86 @@ func (s service) CreateQuizGS(lobby Lobby) error {
 	if response == nil {
 		return fmt.Errorf("response is nil")
 	}
+	defer response.Body.Close()
 	if response.StatusCode != http.StatusCreated {
 		return fmt.Errorf("failed status code: %d", response.StatusCode)
 	}
 	return nil
 }
 
-// AddUserToLobby needs check if user has such lobby.
-// Or check must be on client
+// AddUserToLobby sets the player ready if the player is in the lobby, otherwise the player joins the lobby.
+// The join is a saga (see Join), a request with the same request id continues it or returns its result
 func (s service) AddUserToLobby(ctx context.Context, dto JoinLobbyDTO) error {
 	s.logger.Println("GOT INTO addUserToLobby")
-	lobby, err := s.storage.FindById(ctx, dto.LobbyID)
+	err := CheckBan(ctx, dto.UserID)
 	if err != nil {
 		return err
 	}
-
-	var (
-		i     int
-		found bool
-	)
-	// If user is in players list then set his status ready
-	if i, found = getPlayerIndex(dto.UserID, lobby.Players); found {
-		if lobby.Players[i].Ready == true {
-			return fmt.Errorf("user is already ready")
+	if dto.RequestID != "" {
+		// the stored join of the request is continued even if the lobby is deleted or the player is in it
+		join, err := s.joins.Find(ctx, dto.RequestID)
+		if err == nil {
+			return s.continueJoin(ctx, join, dto)
 		}
-		lobby.Players[i].Ready = true
-	} else {
-		// If user not in players list
-		// If lobby is full raises error
-		if lobby.NowPlayers == lobby.MaxPlayers {
-			return fmt.Errorf("lobby is full")
-		}
-		s.logger.Printf("trying to get user by id: %s", dto.UserID)
-
-		user, err := GetUserByID(ctx, dto.UserID)
-		if err != nil {
+		if !errors.Is(err, auth.ErrNotFound) {
 			return err
 		}
-
-		s.logger.Println("got user by id: %v", user)
-
-		userDTO := UpdateUserDTO{
-			ID:            user.ID,
-			Username:      user.Username,
-			HasFreeTicket: user.HasFreeTicket,
-			Tickets:       user.Tickets,
-		}
-		err = UpdateUserTicket(ctx, lobby.GameType, userDTO)
-		if err != nil {
-			return fmt.Errorf("failed to update user lobby due to: %v", err)
-		}
-
-		err = UseTicket(ctx, dto.TicketID)
-		if err != nil {
-			return fmt.Errorf("failed to use lobby due to: %v", err)
-		}
-
-		lobby.Players = append(lobby.Players, Player{
-			ID:    dto.UserID,
-			Ready: false,
-		})
-		lobby.NowPlayers += 1
 	}
 
-	err = s.storage.Update(ctx, lobby)
+	lobby, err := s.storage.FindById(ctx, dto.LobbyID)
 	if err != nil {
 		return err
 	}
 
-	// If user is added successfully and lobby got full adds new lobby of the same type
-	if lobby.NowPlayers == lobby.MaxPlayers && !found {
-		lobbyDTO := LobbyDTO{
-			GameType:    lobby.GameType,
-			MaxPlayers:  lobby.MaxPlayers,
-			NowPlayers:  0,
-			TicketPrice: lobby.TicketPrice,
-			PrizeSum:    lobby.PrizeSum,
-			PrizeType:   lobby.PrizeType,
-			StartTime:   lobby.StartTime,
-			EndTime:     lobby.EndTime,
-			JWTToken:    dto.JWTToken,
-		}
-
-		// create new lobby with same params
-		_, err = s.Create(ctx, lobbyDTO)
-		if err != nil {
+	// If user is in players list then set his status ready
+	if _, found := getPlayerIndex(dto.UserID, lobby.Players); found {
+		if err := s.storage.SetReady(ctx, dto.LobbyID, dto.UserID); err != nil {
 			return err
 		}
-		lobby.JWTToken = dto.JWTToken
-
-		switch lobby.GameType {
-		case "snake":
-			{
-				err := s.CreateSnakeGS(lobby)
-				if err != nil {
-					return fmt.Errorf("failed to create snake game server due to: %v", err)
-				}
-			}
-		case "quiz":
-			{
-				err := s.CreateQuizGS(lobby)
-				if err != nil {
-					return fmt.Errorf("failed to create quiz game server due to: %v", err)
-				}
-			}
-		}
-
-		// ????
-		// wait for start time to make sure all players are ready
-		// start game service after 2 minutes from the start
+		s.events.Publish(Event{Type: EventPlayerReady, LobbyID: dto.LobbyID, UserID: dto.UserID})
+		return nil
+	}
 
-		// delete lobby
-		err = s.Delete(ctx, lobby.ID)
-		if err != nil {
+	// If lobby is full raises error before the ticket is taken, the seat is reserved atomically by the join
+	if lobby.NowPlayers >= lobby.MaxPlayers {
+		return ErrLobbyFull
+	}
+	if dto.TicketID == "" {
+		return auth.BadRequestError("ticket_id is required to join the lobby")
+	}
+	if dto.RequestID == "" {
+		if dto.RequestID, err = newToken(); err != nil {
 			return err
 		}
 	}
+	return s.continueJoin(ctx, NewJoin(dto, lobby.GameType), dto)
+}
 
-	return nil
+// continueJoin claims the join and runs it, finished joins return their result
+func (s service) continueJoin(ctx context.Context, join Join, dto JoinLobbyDTO) error {
+	if !join.Matches(dto) {
+		return auth.BadRequestError("request_id is used by another join")
+	}
+	owner, err := newToken()
+	if err != nil {
+		return err
+	}
+	join.Owner = owner
+	join, err = s.joins.Claim(ctx, join, joinLease)
+	if err != nil {
+		return err
+	}
+	// another request could create the join with the same request id concurrently
+	if !join.Matches(dto) {
+		return auth.BadRequestError("request_id is used by another join")
+	}
+	switch join.State {
+	case JoinCompleted:
+		return nil
+	case JoinCompensated:
+		return errors.New(join.Error)
+	}
+	return s.runJoin(ctx, join)
 }
 
 func (s service) GetLobbyIDByParams(ctx context.Context, params Params) (lobbyID string, err error) {
@@ -483,24 +428,24 @@ func (s service) UpdateLobbyTime(ctx context.Context, utdto UpdateTimeDTO) (int6
 		PrizeType:   lobby.PrizeType,
 		StartTime:   lobby.StartTime + 24*OneHour,
 		EndTime:     lobby.EndTime + 24*OneHour,
-		JWTToken:    utdto.JWTToken,
 	}
 	_, err = s.Create(ctx, dto)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create new lobby with same params due to: %v", err)
 	}
 
-	hour := time.Unix(lobby.StartTime, 0).Hour()
+	startTime := lobby.StartTime
+	hour := time.Unix(startTime, 0).Hour()
 	if hour == 2 {
-		lobby.StartTime += 14 * OneHour
+		startTime += 14 * OneHour
 	} else {
-		lobby.StartTime += OneHour
+		startTime += OneHour
 	}
-	lobby.EndTime = lobby.StartTime + 2*OneHour
 
-	err = s.storage.Update(ctx, lobby)
+	err = s.storage.UpdateTime(ctx, lobby.ID, startTime, startTime+2*OneHour)
 	if err != nil {
 		return 0, fmt.Errorf("failed to update lobby time due to: %v", err)
 	}
-	return lobby.StartTime, nil
+	s.events.Publish(Event{Type: EventLobbyRescheduled, LobbyID: lobby.ID, StartTime: startTime})
+	return startTime, nil
 }
@@ -1,13 +1,33 @@
 package lobby
 
-import "context"
+import (
+	"context"
+	"errors"
+	"lobby_service/pkg/pagination"
+)
+
+var (
+	ErrLobbyFull     = errors.New("lobby is full")
+	ErrPlayerInLobby = errors.New("user is already in the lobby")
+	ErrAlreadyReady  = errors.New("user is already ready")
+)
 
 type Storage interface {
+	// Create inserts the lobby, the lobby with ID is created with it once, creating it again returns the ID
 	Create(ctx context.Context, lobby Lobby) (string, error)
 	FindById(ctx context.Context, id string) (Lobby, error)
 	FindByParams(ctx context.Context, gameType string, maxPlayers, prizeSum int) (string, error)
-	FindAll(ctx context.Context) ([]Lobby, error)
+	FindPage(ctx context.Context, filter Filter, params pagination.Params) ([]Lobby, error)
 	Update(ctx context.Context, lobby Lobby) error
+	// UpdateTime sets only start and end time, so players who join meanwhile aren't overwritten
+	UpdateTime(ctx context.Context, id string, startTime, endTime int64) error
+	// AddPlayer takes a seat in one conditional update and returns the updated lobby. Returns ErrNotFound,
+	// ErrLobbyFull or ErrPlayerInLobby if the seat isn't taken
+	AddPlayer(ctx context.Context, lobbyID string, player Player) (Lobby, error)
+	// SetReady sets the player ready, returns ErrAlreadyReady if the lobby has no such player who isn't ready
+	SetReady(ctx context.Context, lobbyID, userID string) error
+	// RemovePlayer removes the player from the lobby, removing a player who isn't in the lobby isn't an error
+	RemovePlayer(ctx context.Context, lobbyID, userID string) error
 	Delete(ctx context.Context, id string) error
 	DeleteAll(ctx context.Context) error
 }
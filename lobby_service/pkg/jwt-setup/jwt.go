@@ -1,31 +1,66 @@
 package jwt_setup
 
 import (
-	"crypto/rsa"
 	"fmt"
 	"github.com/golang-jwt/jwt/v4"
 	"lobby_service/internal/config"
+	"sync"
 )
 
-var signKey *rsa.PrivateKey
+var (
+	jwks        *JWKS
+	revocations *Revocations
+	jwksOnce    sync.Once
+)
 
 type DTO interface {
 }
 
 type RegisteredClaims struct {
 	jwt.RegisteredClaims
-	Id string `json:"id"`
+	Id    string   `json:"id"`
+	Roles []string `json:"roles"`
 }
 
+// HasRole reports whether the token has at least one of the roles
+func (c *RegisteredClaims) HasRole(roles ...string) bool {
+	for _, role := range roles {
+		for _, r := range c.Roles {
+			if r == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ParseToken verifies token with public keys of the auth service and returns id of the user
 func ParseToken(tokenString string) (userId string, err error) {
-	token, err := jwt.ParseWithClaims(tokenString, &RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(config.GetConfig().Keys.JWTSignKey), nil
-	})
+	claims, err := ParseClaims(tokenString)
+	if err != nil {
+		return "", err
+	}
+	return claims.Id, nil
+}
 
-	if claims, ok := token.Claims.(*RegisteredClaims); ok && token.Valid {
-		return claims.Id, nil
-	} else {
-		return "", fmt.Errorf("wrong token: %v", claims.RegisteredClaims.Issuer)
+// ParseClaims verifies token with public keys of the auth service and returns its claims
+func ParseClaims(tokenString string) (*RegisteredClaims, error) {
+	jwksOnce.Do(func() {
+		cfg := config.GetConfig()
+		jwks = NewJWKS(cfg.Keys.JWKSURL)
+		revocations = NewRevocations(cfg.Keys.RevocationsURL, cfg.Keys.RevocationsInterval)
+	})
+	token, err := jwt.ParseWithClaims(tokenString, &RegisteredClaims{}, jwks.Keyfunc)
+	if err != nil {
+		return nil, fmt.Errorf("wrong token: %v", err)
+	}
 
+	claims, ok := token.Claims.(*RegisteredClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("wrong token")
+	}
+	if revocations.Revoked(claims.RegisteredClaims) {
+		return nil, fmt.Errorf("wrong token: revoked")
 	}
+	return claims, nil
 }
@@ -80,16 +80,23 @@ func NewApp(cfg *config.Config, logger *logging.Logger) (App, error) {
 	router.Handler(http.MethodGet, "/swagger", http.RedirectHandler("/swagger/index.html", http.StatusMovedPermanently))
 	router.Handler(http.MethodGet, "/swagger/*any", httpSwagger.WrapHandler)
 
-	logger.Println("heartbeat metric initializing")
-	metricHandler := metrics.Handler{}
-	metricHandler.Register(router)
-
 	mongodbClient, err := mongodb.NewClient(context.Background(), cfg.MongoDB.Host, cfg.MongoDB.Port,
 		cfg.MongoDB.Username, cfg.MongoDB.Password, cfg.MongoDB.Database, cfg.MongoDB.AuthDB)
 	if err != nil {
 		panic(err)
 	}
 
+	logger.Println("heartbeat metric initializing")
+	metricHandler := metrics.Handler{
+		Checks: map[string]metrics.Check{
+			"mongodb":          mongodb.Ping(mongodbClient),
+			"lobby_service":    metrics.HTTPCheck(cfg.Dependencies.LobbyServiceURL),
+			"qualifications":   metrics.HTTPCheck(cfg.Dependencies.QualificationsURL),
+			"training_service": metrics.HTTPCheck(cfg.Dependencies.TrainingServiceURL),
+		},
+	}
+	metricHandler.Register(router)
+
 	storage := db.NewStorage(mongodbClient, "managers", logger)
 	service, err := manager.NewService(storage, *logger)
 	if err != nil {
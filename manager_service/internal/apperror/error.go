@@ -7,6 +7,7 @@ import (
 var (
 	ErrNotFound   = NewAppError(nil, "not found", "NS-000003", "")
 	ErrWrongToken = NewAppError(nil, "wrong token", "NS-000004", "")
+	ErrForbidden  = NewAppError(nil, "forbidden", "NS-000007", "token has no role required for the request")
 )
 
 type AppError struct {
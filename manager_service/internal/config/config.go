@@ -4,6 +4,7 @@ import (
 	"github.com/ilyakaznacheev/cleanenv"
 	"log"
 	"sync"
+	"time"
 )
 
 type Config struct {
@@ -26,8 +27,22 @@ type Config struct {
 		Database string `env:"DATABASE" env-default:"manager-service"`
 		AuthDB   string `env:"AUTH_DB"`
 	}
+	ServiceAuth struct {
+		TokenURL     string `env:"SERVICE_TOKEN_URL" env-default:"http://localhost:10001/api/auth/token"`
+		ClientID     string `env:"SERVICE_CLIENT_ID" env-default:"manager_service"`
+		ClientSecret string `env:"SERVICE_CLIENT_SECRET" env-required:"true"`
+	}
 	Keys struct {
-		JWTSignKey string `env:"JWT_SIGN_KEY" env-default:"alsfjak12h4i1h2uas7f7241231o1u2io5u12asopua0w9812"`
+		JWKSURL string `env:"JWKS_URL" env-default:"http://localhost:10001/.well-known/jwks.json"`
+		// RevocationsURL is polled every RevocationsInterval, revoked tokens are rejected
+		RevocationsURL      string        `env:"REVOCATIONS_URL" env-default:"http://localhost:10001/api/auth/revocations"`
+		RevocationsInterval time.Duration `env:"REVOCATIONS_REFRESH_INTERVAL" env-default:"10s"`
+	}
+	// Dependencies are base URLs of services the readiness check calls
+	Dependencies struct {
+		LobbyServiceURL    string `env:"LOBBY_SERVICE_URL" env-default:"http://localhost:10006"`
+		QualificationsURL  string `env:"QUALIFICATIONS_SERVICE_URL" env-default:"http://localhost:10011"`
+		TrainingServiceURL string `env:"TRAINING_SERVICE_URL" env-default:"http://localhost:10003"`
 	}
 }
 
@@ -46,6 +61,9 @@ func GetConfig() *Config {
 			log.Println(help)
 			log.Fatal(err)
 		}
+		if instance.ServiceAuth.ClientSecret == "" {
+			log.Fatal("SERVICE_CLIENT_SECRET is empty")
+		}
 	})
 	return instance
 }
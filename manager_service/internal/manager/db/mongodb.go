@@ -9,6 +9,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"manager_service/internal/manager"
 	"manager_service/pkg/logging"
+	"manager_service/pkg/pagination"
 )
 
 type db struct {
@@ -64,6 +65,27 @@ func (d *db) FindAll(ctx context.Context) (lrs []manager.LobbyRecord, err error)
 	return lrs, nil
 }
 
+func (d *db) FindPage(ctx context.Context, filter manager.Filter, params pagination.Params) (lrs []manager.LobbyRecord, err error) {
+	query := bson.M{}
+	if filter.Type != "" {
+		query["type"] = filter.Type
+	}
+	if filter.GameType != "" {
+		query["game_type"] = filter.GameType
+	}
+	if filter.LobbyID != "" {
+		query["lobby_id"] = filter.LobbyID
+	}
+	cursor, err := d.collection.Find(ctx, params.Filter(query), params.Options())
+	if err != nil {
+		return lrs, fmt.Errorf("failed to find lrs due to: %v", err)
+	}
+	if err := cursor.All(ctx, &lrs); err != nil {
+		return lrs, fmt.Errorf("failed to read all documents from cursor due to: %v", err)
+	}
+	return lrs, nil
+}
+
 func (d *db) Update(ctx context.Context, lr manager.LobbyRecord) error {
 	objectID, err := primitive.ObjectIDFromHex(lr.ID)
 	if err != nil {
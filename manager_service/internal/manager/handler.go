@@ -7,6 +7,7 @@ import (
 	"log"
 	"manager_service/internal/apperror"
 	"manager_service/pkg/logging"
+	"manager_service/pkg/pagination"
 	"net/http"
 )
 
@@ -23,11 +24,11 @@ var (
 )
 
 func (h *Handler) Register(router *httprouter.Router) {
-	router.HandlerFunc(http.MethodPost, mainURL, apperror.NoAuthMiddleware(h.Create))
+	router.HandlerFunc(http.MethodPost, mainURL, apperror.RoleMiddleware(h.Create, apperror.RoleService))
 	router.HandlerFunc(http.MethodPost, getRLSURL, apperror.Middleware(h.GetLRS))
 	router.HandlerFunc(http.MethodPost, getRLURL, apperror.Middleware(h.GetLRById))
-	router.HandlerFunc(http.MethodDelete, getRLURL, apperror.Middleware(h.DeleteLR))
-	router.HandlerFunc(http.MethodDelete, deleteAllURL, apperror.Middleware(h.DeleteAll))
+	router.HandlerFunc(http.MethodDelete, getRLURL, apperror.RoleMiddleware(h.DeleteLR, apperror.RoleOperator, apperror.RoleAdmin))
+	router.HandlerFunc(http.MethodDelete, deleteAllURL, apperror.RoleMiddleware(h.DeleteAll, apperror.RoleAdmin))
 }
 
 func (h *Handler) Create(w http.ResponseWriter, r *http.Request) error {
@@ -57,7 +58,13 @@ func (h *Handler) GetLRS(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Info("GET LRS")
 	w.Header().Set("Content-Type", "application/json")
 
-	lobbies, err := h.ManagerService.GetAll(r.Context())
+	query := r.URL.Query()
+	params, err := pagination.Parse(query, SortFields...)
+	if err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	lobbies, err := h.ManagerService.GetPage(r.Context(), NewFilter(query), params)
 	if err != nil {
 		return err
 	}
@@ -3,6 +3,7 @@ package manager
 import (
 	"context"
 	"log"
+	"net/url"
 	"sync"
 	"time"
 )
@@ -19,6 +20,24 @@ func (lr LobbyRecord) Expired() bool {
 	return time.Now().Unix() >= lr.Expiration
 }
 
+// SortFields can be used in sort parameter of the lobby records list
+var SortFields = []string{"expiration"}
+
+// Filter of the lobby records list, empty fields match any record
+type Filter struct {
+	Type     string
+	GameType string
+	LobbyID  string
+}
+
+func NewFilter(query url.Values) Filter {
+	return Filter{
+		Type:     query.Get("type"),
+		GameType: query.Get("game_type"),
+		LobbyID:  query.Get("lobby_id"),
+	}
+}
+
 type LobbyRecordDTO struct {
 	Type       string `json:"type"`
 	LobbyID    string `json:"lobby_id"`
@@ -9,6 +9,8 @@ import (
 	"log"
 	"manager_service/internal/apperror"
 	"manager_service/pkg/logging"
+	"manager_service/pkg/pagination"
+	"manager_service/pkg/servicetoken"
 	"net/http"
 )
 
@@ -29,7 +31,9 @@ func NewService(managerStorage Storage, logger logging.Logger) (Service, error)
 type Service interface {
 	Create(ctx context.Context, dto LobbyRecordDTO) (string, error)
 	GetById(ctx context.Context, id string) (lobby LobbyRecord, err error)
+	// GetAll is for the expiration loop, which has to see every record
 	GetAll(ctx context.Context) ([]LobbyRecord, error)
+	GetPage(ctx context.Context, filter Filter, params pagination.Params) (pagination.Page[LobbyRecord], error)
 	UpdateLR(ctx context.Context, lr LobbyRecord) error
 	UpdateTime(ctx context.Context, lr LobbyRecord) (LRResponse, error)
 	Delete(ctx context.Context, id string) error
@@ -64,6 +68,14 @@ func (s service) GetAll(ctx context.Context) ([]LobbyRecord, error) {
 	return lrs, nil
 }
 
+func (s service) GetPage(ctx context.Context, filter Filter, params pagination.Params) (pagination.Page[LobbyRecord], error) {
+	lrs, err := s.storage.FindPage(ctx, filter, params)
+	if err != nil {
+		return pagination.Page[LobbyRecord]{}, fmt.Errorf("failed to get lrs due to: %v", err)
+	}
+	return pagination.NewPage(lrs, params)
+}
+
 func (s service) UpdateLR(ctx context.Context, lr LobbyRecord) error {
 	log.Println("UPDATE LR")
 	err := s.storage.Update(ctx, lr)
@@ -92,6 +104,10 @@ func (s service) UpdateTime(ctx context.Context, lr LobbyRecord) (res LRResponse
 	if err != nil {
 		return res, err
 	}
+	err = servicetoken.Authorize(ctx, request)
+	if err != nil {
+		return res, err
+	}
 	var client http.Client
 	response, err := client.Do(request)
 	if err != nil {
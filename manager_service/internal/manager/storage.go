@@ -1,11 +1,15 @@
 package manager
 
-import "context"
+import (
+	"context"
+	"manager_service/pkg/pagination"
+)
 
 type Storage interface {
 	Create(ctx context.Context, lr LobbyRecordDTO) (string, error)
 	FindById(ctx context.Context, id string) (lr LobbyRecord, err error)
 	FindAll(ctx context.Context) (lrs []LobbyRecord, err error)
+	FindPage(ctx context.Context, filter Filter, params pagination.Params) (lrs []LobbyRecord, err error)
 	Update(ctx context.Context, lr LobbyRecord) error
 	Delete(ctx context.Context, id string) error
 	DeleteAll(ctx context.Context) error
@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+
+	checkTimeout = 2 * time.Second
+)
+
+// Check returns an error if the dependency isn't ready
+type Check func(ctx context.Context) error
+
+type CheckResult struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+type ReadyResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+func (r ReadyResponse) Marshal() []byte {
+	bytes, err := json.Marshal(r)
+	if err != nil {
+		return nil
+	}
+	return bytes
+}
+
+// HTTPCheck checks that the service with the given base URL responds to heartbeat
+func HTTPCheck(baseURL string) Check {
+	client := http.Client{Timeout: checkTimeout}
+	return func(ctx context.Context) error {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+HeartbeatURL, nil)
+		if err != nil {
+			return fmt.Errorf("failed to build a request due to: %v", err)
+		}
+		response, err := client.Do(request)
+		if err != nil {
+			return fmt.Errorf("failed to do a request due to: %v", err)
+		}
+		defer response.Body.Close()
+		if response.StatusCode >= 300 {
+			return fmt.Errorf("got wrong status code: %d", response.StatusCode)
+		}
+		return nil
+	}
+}
+
+// RunChecks runs all checks concurrently, every check is limited with 2 seconds
+func RunChecks(ctx context.Context, checks map[string]Check) ReadyResponse {
+	response := ReadyResponse{
+		Status: StatusOK,
+		Checks: make(map[string]CheckResult, len(checks)),
+	}
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check(checkCtx)
+			result := CheckResult{
+				Status:    StatusOK,
+				LatencyMS: time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				result.Status = StatusFail
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			response.Checks[name] = result
+			if err != nil {
+				response.Status = StatusFail
+			}
+		}(name, check)
+	}
+	wg.Wait()
+	return response
+}
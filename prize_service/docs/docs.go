@@ -30,10 +30,10 @@ const docTemplate = `{
                 "summary": "Create lobby endpoint",
                 "responses": {
                     "201": {
-                        "description": ""
+                        "description": "Created"
                     },
                     "400": {
-                        "description": ""
+                        "description": "Bad Request"
                     }
                 }
             },
@@ -50,10 +50,10 @@ const docTemplate = `{
                 "summary": "Partially update lobby by lobby id",
                 "responses": {
                     "204": {
-                        "description": ""
+                        "description": "No Content"
                     },
                     "400": {
-                        "description": ""
+                        "description": "Bad Request"
                     }
                 }
             }
@@ -69,13 +69,39 @@ const docTemplate = `{
                 "tags": [
                     "Prizes"
                 ],
-                "summary": "Get all prizes",
+                "summary": "Get page of prizes",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page size, 20 by default, 100 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "id, game_type or date_time, - prefix for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "prizes of the game type",
+                        "name": "game_type",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
-                        "description": ""
+                        "description": "OK"
                     },
                     "400": {
-                        "description": ""
+                        "description": "Bad Request"
                     }
                 }
             }
@@ -94,10 +120,10 @@ const docTemplate = `{
                 "summary": "Get lobby by lobby id",
                 "responses": {
                     "200": {
-                        "description": ""
+                        "description": "OK"
                     },
                     "400": {
-                        "description": ""
+                        "description": "Bad Request"
                     }
                 }
             },
@@ -114,10 +140,41 @@ const docTemplate = `{
                 "summary": "Delete lobby by lobby id",
                 "responses": {
                     "204": {
-                        "description": ""
+                        "description": "No Content"
                     },
                     "400": {
-                        "description": ""
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/auth.AppError"
+                        }
+                    }
+                }
+            }
+        },
+        "/health/ready": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Metrics"
+                ],
+                "summary": "Readiness metric. Checks database and downstream services",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.ReadyResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.ReadyResponse"
+                        }
                     }
                 }
             }
@@ -130,11 +187,55 @@ const docTemplate = `{
                 "summary": "Heartbeat metric",
                 "responses": {
                     "204": {
-                        "description": ""
+                        "description": "No Content"
                     },
                     "400": {
-                        "description": ""
+                        "description": "Bad Request"
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "auth.AppError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "developer_message": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "metrics.CheckResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "metrics.ReadyResponse": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/metrics.CheckResult"
                     }
+                },
+                "status": {
+                    "type": "string"
                 }
             }
         }
@@ -9,6 +9,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"prize_service/internal/prize"
 	"prize_service/pkg/logging"
+	"prize_service/pkg/pagination"
 )
 
 type db struct {
@@ -50,15 +51,19 @@ func (d *db) FindById(ctx context.Context, id string) (prize prize.Prize, err er
 	return prize, nil
 }
 
-func (d *db) FindAll(ctx context.Context) (users []prize.Prize, err error) {
-	cursor, err := d.collection.Find(ctx, bson.M{})
-	if cursor.Err() != nil {
-		return users, fmt.Errorf("failed to find all tickets due to: %v", cursor.Err())
+func (d *db) FindPage(ctx context.Context, filter prize.Filter, params pagination.Params) (prizes []prize.Prize, err error) {
+	query := bson.M{}
+	if filter.GameType != "" {
+		query["game_type"] = filter.GameType
 	}
-	if err := cursor.All(ctx, &users); err != nil {
-		return users, fmt.Errorf("failed to read all documents from cursor")
+	cursor, err := d.collection.Find(ctx, params.Filter(query), params.Options())
+	if err != nil {
+		return prizes, fmt.Errorf("failed to find prizes due to: %v", err)
+	}
+	if err := cursor.All(ctx, &prizes); err != nil {
+		return prizes, fmt.Errorf("failed to read all documents from cursor due to: %v", err)
 	}
-	return users, nil
+	return prizes, nil
 }
 
 // Update by ticketID
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"prize_service/internal/auth"
 	"prize_service/pkg/logging"
+	"prize_service/pkg/pagination"
 )
 
 var (
@@ -25,7 +26,7 @@ func (h *Handler) Register(router *httprouter.Router) {
 	router.HandlerFunc(http.MethodPost, prizesUrl, auth.Middleware(h.CreatePrize))
 	router.HandlerFunc(http.MethodPost, prizeUrl, auth.Middleware(h.GetPrizeById))
 	router.HandlerFunc(http.MethodPost, getAllPrizesUrl, auth.Middleware(h.GetPrizes))
-	router.HandlerFunc(http.MethodDelete, prizeUrl, auth.Middleware(h.DeletePrize))
+	router.HandlerFunc(http.MethodDelete, prizeUrl, auth.RoleMiddleware(h.DeletePrize, auth.RoleOperator, auth.RoleAdmin))
 	router.HandlerFunc(http.MethodPatch, prizesUrl, auth.Middleware(h.PartiallyUpdatePrize))
 }
 
@@ -94,10 +95,14 @@ func (h *Handler) GetPrizeById(w http.ResponseWriter, r *http.Request) error {
 }
 
 // Get prizes
-// @Summary Get all prizes
+// @Summary Get page of prizes
 // @Accept json
 // @Produce json
 // @Tags Prizes
+// @Param limit query int false "page size, 20 by default, 100 at most"
+// @Param after query string false "next_cursor of the previous page"
+// @Param sort query string false "id, game_type or date_time, - prefix for descending"
+// @Param game_type query string false "prizes of the game type"
 // @Success 200
 // @Failure 400
 // @Router /api/prizes/all [post]
@@ -105,12 +110,16 @@ func (h *Handler) GetPrizes(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Info("GET PRIZES")
 	w.Header().Set("Content-Type", "application/json")
 
-	prizes, err := h.PrizeService.GetAll(r.Context())
+	query := r.URL.Query()
+	params, err := pagination.Parse(query, SortFields...)
 	if err != nil {
-		return err
+		return auth.BadRequestError(err.Error())
 	}
 
-	h.Logger.Println(prizes)
+	prizes, err := h.PrizeService.GetPage(r.Context(), NewFilter(query), params)
+	if err != nil {
+		return err
+	}
 
 	prizeBytes, err := json.Marshal(prizes)
 	if err != nil {
@@ -155,6 +164,7 @@ func (h *Handler) PartiallyUpdatePrize(w http.ResponseWriter, r *http.Request) e
 // @Tags Prizes
 // @Success 204
 // @Failure 400
+// @Failure 403 {object} auth.AppError
 // @Router /api/prizes/id/:id [delete]
 func (h *Handler) DeletePrize(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Info("DELETE PRIZE")
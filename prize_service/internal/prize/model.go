@@ -1,5 +1,19 @@
 package prize
 
+import "net/url"
+
+// SortFields can be used in sort parameter of the prizes list
+var SortFields = []string{"game_type", "date_time"}
+
+// Filter of the prizes list, empty fields match any prize
+type Filter struct {
+	GameType string
+}
+
+func NewFilter(query url.Values) Filter {
+	return Filter{GameType: query.Get("game_type")}
+}
+
 type Prize struct {
 	ID        string `json:"id" bson:"_id,omitempty"`
 	GameType  string `json:"game_type" bson:"game_type"`
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"prize_service/internal/auth"
 	"prize_service/pkg/logging"
+	"prize_service/pkg/pagination"
 )
 
 var _ Service = &service{}
@@ -24,7 +25,7 @@ func NewService(userStorage Storage, logger logging.Logger) (Service, error) {
 
 type Service interface {
 	Create(ctx context.Context, dto PrizeDTO) (string, error)
-	GetAll(ctx context.Context) ([]Prize, error)
+	GetPage(ctx context.Context, filter Filter, params pagination.Params) (pagination.Page[Prize], error)
 	GetById(ctx context.Context, id string) (Prize, error)
 	Update(ctx context.Context, dto Prize) error
 	Delete(ctx context.Context, id string) error
@@ -63,12 +64,12 @@ func (s service) GetById(ctx context.Context, id string) (prize Prize, err error
 	return prize, nil
 }
 
-func (s service) GetAll(ctx context.Context) ([]Prize, error) {
-	prizes, err := s.storage.FindAll(ctx)
+func (s service) GetPage(ctx context.Context, filter Filter, params pagination.Params) (pagination.Page[Prize], error) {
+	prizes, err := s.storage.FindPage(ctx, filter, params)
 	if err != nil {
-		return prizes, fmt.Errorf("failed to find prizes. error: %v", err)
+		return pagination.Page[Prize]{}, fmt.Errorf("failed to find prizes. error: %v", err)
 	}
-	return prizes, nil
+	return pagination.NewPage(prizes, params)
 }
 
 func (s service) Update(ctx context.Context, prize Prize) error {
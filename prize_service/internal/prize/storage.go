@@ -1,11 +1,14 @@
 package prize
 
-import "context"
+import (
+	"context"
+	"prize_service/pkg/pagination"
+)
 
 type Storage interface {
 	Create(ctx context.Context, prize Prize) (string, error)
 	FindById(ctx context.Context, id string) (Prize, error)
-	FindAll(ctx context.Context) ([]Prize, error)
+	FindPage(ctx context.Context, filter Filter, params pagination.Params) ([]Prize, error)
 	Update(ctx context.Context, prize Prize) error
 	Delete(ctx context.Context, id string) error
 }
@@ -40,3 +40,10 @@ func NewClient(ctx context.Context, host, port, username, password, database, au
 
 	return client.Database(database), nil
 }
+
+// Ping returns a function that checks connection to the database
+func Ping(db *mongo.Database) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return db.Client().Ping(ctx, nil)
+	}
+}
@@ -36,16 +36,22 @@ func NewApp(cfg *config.Config, logger *logging.Logger) (App, error) {
 	router.Handler(http.MethodGet, "/swagger", http.RedirectHandler("/swagger/index.html", http.StatusMovedPermanently))
 	router.Handler(http.MethodGet, "/swagger/*any", httpSwagger.WrapHandler)
 
-	logger.Println("heartbeat metric initializing")
-	metricHandler := metrics.Handler{}
-	metricHandler.Register(router)
-
 	mongodbClient, err := mongodb.NewClient(context.Background(), cfg.MongoDB.Host, cfg.MongoDB.Port,
 		cfg.MongoDB.Username, cfg.MongoDB.Password, cfg.MongoDB.Database, cfg.MongoDB.AuthDB)
 	if err != nil {
 		panic(err)
 	}
 
+	logger.Println("heartbeat metric initializing")
+	metricHandler := metrics.Handler{
+		Checks: map[string]metrics.Check{
+			"mongodb":         mongodb.Ping(mongodbClient),
+			"manager_service": metrics.HTTPCheck(cfg.Dependencies.ManagerServiceURL),
+			"ticket_service":  metrics.HTTPCheck(cfg.Dependencies.TicketServiceURL),
+		},
+	}
+	metricHandler.Register(router)
+
 	storage := db.NewStorage(mongodbClient, logger)
 	service, err := table.NewService(storage, *logger)
 	if err != nil {
@@ -2,11 +2,14 @@ package auth
 
 import (
 	"encoding/json"
+	"errors"
 )
 
 var (
 	ErrNotFound   = NewAppError(nil, "not found", "NS-000003", "")
 	ErrWrongToken = NewAppError(nil, "wrong token", "NS-000004", "")
+	ErrForbidden  = NewAppError(nil, "forbidden", "NS-000007", "token has no role required for the request")
+	ErrBanned     = NewAppError(errors.New("user is banned"), "user is banned", "NS-000013", "")
 )
 
 type AppError struct {
@@ -46,3 +49,8 @@ func BadRequestError(message string) *AppError {
 func systemError(developerMessage string) *AppError {
 	return NewAppError(nil, "system error", "NS-000001", developerMessage)
 }
+
+// BannedError tells the user why and until when the user is banned
+func BannedError(message string) *AppError {
+	return NewAppError(ErrBanned, message, ErrBanned.Code, "")
+}
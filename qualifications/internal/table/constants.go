@@ -5,8 +5,13 @@ import "time"
 const (
 	notifyMangerURL    = "http://localhost:10007/api/manager/"
 	createTicketURL    = "http://localhost:10004/api/tickets"
+	sanctionsURL       = "http://localhost:10002/api/users/internal/sanctions/"
+	shadowBannedURL    = "http://localhost:10002/api/users/internal/shadow-banned"
+	friendsURL         = "http://localhost:10002/api/users/internal/friends/"
 	typeQualifications = "qualifications"
 	ticketPrize        = 108
 	playersAmount      = 12
 	timeDelta          = 6 * time.Hour
+	userServiceTimeout = 5 * time.Second
+	shadowBansTTL      = time.Minute
 )
@@ -179,7 +179,7 @@ func (d *db) FindByUserId(ctx context.Context, dto table.RecordDTO) (record tabl
 	return record, nil
 }
 
-func (d *db) FindAll(ctx context.Context, dto table.RecordDTO) (users []table.Record, err error) {
+func (d *db) FindAll(ctx context.Context, dto table.RecordDTO, userIDs []string) (users []table.Record, err error) {
 	isCollection, err := d.IsCollection(ctx, dto.TableName)
 	if err != nil {
 		return users, err
@@ -191,7 +191,11 @@ func (d *db) FindAll(ctx context.Context, dto table.RecordDTO) (users []table.Re
 	collection := d.database.Collection(tableName)
 	findOptions := options.Find()
 	findOptions.SetSort(bson.D{{"user_score", -1}})
-	cursor, err := collection.Find(ctx, bson.M{}, findOptions)
+	filter := bson.M{}
+	if userIDs != nil {
+		filter["user_id"] = bson.M{"$in": userIDs}
+	}
+	cursor, err := collection.Find(ctx, filter, findOptions)
 	if cursor.Err() != nil {
 		return users, fmt.Errorf("failed to find all users due to: %v", cursor.Err())
 	}
@@ -285,6 +289,62 @@ func (d *db) Delete(ctx context.Context, dto table.RecordDTO) error {
 	return nil
 }
 
+// DeleteByUserID deletes records of the user from every collection, each collection is a table
+func (d *db) DeleteByUserID(ctx context.Context, userID string) (int64, error) {
+	names, err := d.database.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list collections due to: %v", err)
+	}
+	var deleted int64
+	for _, name := range names {
+		result, err := d.database.Collection(name).DeleteMany(ctx, bson.M{"user_id": userID})
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete records of user %s from %s due to: %v", userID, name, err)
+		}
+		deleted += result.DeletedCount
+	}
+	return deleted, nil
+}
+
+// FindStatsByUserID returns stats of the user in every table the user has records in
+func (d *db) FindStatsByUserID(ctx context.Context, userID string) ([]table.TableStats, error) {
+	names, err := d.database.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections due to: %v", err)
+	}
+	stats := make([]table.TableStats, 0, len(names))
+	bestFirst := options.FindOne().SetSort(bson.D{{Key: "user_score", Value: -1}})
+	for _, name := range names {
+		collection := d.database.Collection(name)
+		var best table.Record
+		err = collection.FindOne(ctx, bson.M{"user_id": userID}, bestFirst).Decode(&best)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to find best record of user %s in %s due to: %v", userID, name, err)
+		}
+		played, err := collection.CountDocuments(ctx, bson.M{"user_id": userID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to count records of user %s in %s due to: %v", userID, name, err)
+		}
+		higher, err := collection.CountDocuments(ctx, bson.M{"user_score": bson.M{"$gt": best.UserScore}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to count higher records in %s due to: %v", name, err)
+		}
+		tableStats := table.TableStats{
+			TableName:   name,
+			GamesPlayed: int(played),
+			BestScore:   best.UserScore,
+		}
+		if higher == 0 && best.UserScore > 0 {
+			tableStats.Wins = 1
+		}
+		stats = append(stats, tableStats)
+	}
+	return stats, nil
+}
+
 func NewStorage(database *mongo.Database, logger *logging.Logger) table.Storage {
 	return &db{
 		database: database,
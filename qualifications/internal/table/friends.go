@@ -0,0 +1,22 @@
+package table
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// FriendIDsDTO lists friends of the user
+type FriendIDsDTO struct {
+	UserIDs []string `json:"user_ids"`
+}
+
+// friendIDs returns ids of friends of the user for the friends filter of the leaderboard
+func friendIDs(ctx context.Context, userID string) ([]string, error) {
+	var dto FriendIDsDTO
+	err := getUserService(ctx, friendsURL+url.PathEscape(userID), &dto)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get friends of user due to: %v", err)
+	}
+	return dto.UserIDs, nil
+}
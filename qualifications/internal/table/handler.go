@@ -9,6 +9,7 @@ import (
 	"qualifications_service/internal/auth"
 	"qualifications_service/internal/config"
 	"qualifications_service/pkg/logging"
+	"strconv"
 )
 
 var (
@@ -19,6 +20,8 @@ var (
 	getAllCollectionsUrl = "/api/qualifications/collections/get/all"
 	collectionsUrl       = "/api/qualifications/collections"
 	updateTableURL       = "/api/qualifications/time/:game_type"
+	userRecordsURL       = "/api/qualifications/users/:id"
+	statsURL             = "/api/qualifications/stats/:id"
 )
 
 type Handler struct {
@@ -27,16 +30,18 @@ type Handler struct {
 }
 
 func (h *Handler) Register(router *httprouter.Router) {
-	router.HandlerFunc(http.MethodPost, recordsUrl, auth.Middleware(h.CreateRecord))
+	router.HandlerFunc(http.MethodPost, recordsUrl, auth.RoleMiddleware(h.CreateRecord, auth.AccountRoles...))
 	router.HandlerFunc(http.MethodPost, getRecordUrl, auth.Middleware(h.GetRecordById))
 	router.HandlerFunc(http.MethodPost, getRecordByUserIDUrl, auth.Middleware(h.GetRecordByUserId))
 	router.HandlerFunc(http.MethodPost, getAllRecordsUrl, auth.Middleware(h.GetRecords))
 	router.HandlerFunc(http.MethodGet, getAllCollectionsUrl, auth.Middleware(h.GetCollectionNames))
-	router.HandlerFunc(http.MethodDelete, recordsUrl, auth.Middleware(h.DeleteRecord))
+	router.HandlerFunc(http.MethodDelete, recordsUrl, auth.RoleMiddleware(h.DeleteRecord, auth.RoleOperator, auth.RoleAdmin))
 	router.HandlerFunc(http.MethodPatch, recordsUrl, auth.Middleware(h.PartiallyUpdateRecord))
 	router.HandlerFunc(http.MethodPost, collectionsUrl, auth.Middleware(h.CreateCollection))
-	router.HandlerFunc(http.MethodDelete, collectionsUrl, auth.Middleware(h.DeleteCollectionByName))
-	router.Handler(http.MethodPut, updateTableURL, auth.NoAuthMiddleware(h.UpdateTable))
+	router.HandlerFunc(http.MethodDelete, collectionsUrl, auth.RoleMiddleware(h.DeleteCollectionByName, auth.RoleAdmin))
+	router.Handler(http.MethodPut, updateTableURL, auth.RoleMiddleware(h.UpdateTable, auth.RoleService))
+	router.HandlerFunc(http.MethodDelete, userRecordsURL, auth.RoleMiddleware(h.DeleteUserRecords, auth.RoleService))
+	router.HandlerFunc(http.MethodGet, statsURL, auth.Middleware(h.GetStats))
 }
 
 // Create record
@@ -46,6 +51,7 @@ func (h *Handler) Register(router *httprouter.Router) {
 // @Tags Records
 // @Success 201
 // @Failure 400
+// @Failure 403 {object} auth.AppError
 // @Router /api/training [post]
 func (h *Handler) CreateRecord(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Info("POST CREATE RECORD")
@@ -146,6 +152,7 @@ func (h *Handler) GetRecordByUserId(w http.ResponseWriter, r *http.Request) erro
 // @Summary Get all records of a lobby
 // @Accept json
 // @Produce json
+// @Param friends query bool false "only records of the caller and the caller's friends"
 // @Tags Records
 // @Success 200
 // @Failure 400
@@ -161,7 +168,14 @@ func (h *Handler) GetRecords(w http.ResponseWriter, r *http.Request) error {
 		return auth.BadRequestError("invalid JSON scheme. check swagger API")
 	}
 
-	users, err := h.QualificationService.GetAll(r.Context(), dto)
+	var friends bool
+	if value := r.URL.Query().Get("friends"); value != "" {
+		if friends, err = strconv.ParseBool(value); err != nil {
+			return auth.BadRequestError("friends must be true or false")
+		}
+	}
+
+	users, err := h.QualificationService.GetAll(r.Context(), dto, friends)
 	if err != nil {
 		return err
 	}
@@ -241,6 +255,7 @@ func (h *Handler) PartiallyUpdateRecord(w http.ResponseWriter, r *http.Request)
 // @Tags Records
 // @Success 204
 // @Failure 400
+// @Failure 403 {object} auth.AppError
 // @Router /api/training [delete]
 func (h *Handler) DeleteRecord(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Info("DELETE RECORD")
@@ -261,6 +276,52 @@ func (h *Handler) DeleteRecord(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+// Get stats
+// @Summary Get stats of the user in every qualification table the user has records in
+// @Produce json
+// @Param id path string true "User ID"
+// @Tags Records
+// @Success 200 {array} TableStats
+// @Router /api/qualifications/stats/{id} [get]
+func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("GET STATS")
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	stats, err := h.QualificationService.GetStats(r.Context(), params.ByName("id"))
+	if err != nil {
+		return err
+	}
+	bytes, err := json.Marshal(stats)
+	if err != nil {
+		return fmt.Errorf("failed to marshal response: %v", err)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(bytes)
+	return nil
+}
+
+// Delete records of user
+// @Summary Delete records of the user from every table. Called by user service when the account is deleted
+// @Produce json
+// @Param id path string true "User ID"
+// @Tags Records
+// @Success 204
+// @Failure 403 {object} auth.AppError
+// @Router /api/qualifications/users/{id} [delete]
+func (h *Handler) DeleteUserRecords(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("DELETE USER RECORDS")
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	err := h.QualificationService.DeleteByUserID(r.Context(), params.ByName("id"))
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
 // Create collection
 // @Summary Create collection endpoint. Needs accept token
 // @Accept json
@@ -274,7 +335,6 @@ func (h *Handler) CreateCollection(w http.ResponseWriter, r *http.Request) error
 	w.Header().Set("Content-Type", "application/json")
 
 	var dto CollectionDTO
-	dto.JWTToken = r.Header.Get("Authorization")
 	defer r.Body.Close()
 	err := json.NewDecoder(r.Body).Decode(&dto)
 	if err != nil {
@@ -295,6 +355,7 @@ func (h *Handler) CreateCollection(w http.ResponseWriter, r *http.Request) error
 // @Tags Collections
 // @Success 204
 // @Failure 400
+// @Failure 403 {object} auth.AppError
 // @Router /api/training/collections [delete]
 func (h *Handler) DeleteCollectionByName(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Info("DELETE COLLECTION")
@@ -321,7 +382,6 @@ func (h *Handler) UpdateTable(w http.ResponseWriter, r *http.Request) error {
 	dto := CollectionDTO{
 		AccessKey: config.GetConfig().Keys.AccessKey,
 		Name:      gameType,
-		JWTToken:  r.Header.Get("Authorization"),
 	}
 	expiration, err := h.QualificationService.UpdateTable(r.Context(), dto)
 	if err != nil {
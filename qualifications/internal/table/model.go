@@ -21,7 +21,14 @@ type RecordDTO struct {
 	UserID    string `json:"user_id" bson:"user_id"`
 	Username  string `json:"username" bson:"username"`
 	UserScore int    `json:"user_score" bson:"user_score"`
-	JWTToken  string `json:"-" bson:"-"`
+}
+
+// TableStats of the user in a qualification table. The user wins the table while nobody has a higher score
+type TableStats struct {
+	TableName   string `json:"table_name"`
+	GamesPlayed int    `json:"games_played"`
+	Wins        int    `json:"wins"`
+	BestScore   int    `json:"best_score"`
 }
 
 type Collection struct {
@@ -31,13 +38,11 @@ type Collection struct {
 type CollectionDTO struct {
 	AccessKey string `json:"access_key"`
 	Name      string `json:"table_name"`
-	JWTToken  string `json:"-"`
 }
 
 type CreateTicketDTO struct {
 	UserID   string `json:"user_id"`
 	GameType string `json:"game_type"`
-	JWT      string `json:"-"`
 }
 
 // CTicketDTO includes fields to create ticket with changed signature
@@ -54,7 +59,6 @@ func NewCreateTicketDTO(gameType string, dto RecordDTO) CreateTicketDTO {
 	return CreateTicketDTO{
 		UserID:   dto.UserID,
 		GameType: gameType,
-		JWT:      dto.JWTToken,
 	}
 }
 
@@ -62,12 +66,10 @@ type AddTicketToUserDTO struct {
 	ID       string `json:"id"`
 	TicketID string `json:"ticket_id"`
 	GameType string `json:"game_type"`
-	JWTToken string ` json:"-"`
 }
 
 type TicketDTO struct {
 	TicketID string `json:"ticket_id"`
-	JWTToken string ` json:"-"`
 }
 
 type NotifyManagerDTO struct {
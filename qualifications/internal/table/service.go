@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"qualifications_service/internal/auth"
 	"qualifications_service/pkg/logging"
+	"qualifications_service/pkg/servicetoken"
 	"strings"
 	"time"
 )
@@ -17,14 +18,16 @@ import (
 var _ Service = &service{}
 
 type service struct {
-	storage Storage
-	logger  logging.Logger
+	storage    Storage
+	shadowBans *shadowBans
+	logger     logging.Logger
 }
 
 func NewService(userStorage Storage, logger logging.Logger) (Service, error) {
 	return &service{
-		storage: userStorage,
-		logger:  logger,
+		storage:    userStorage,
+		shadowBans: newShadowBans(logger),
+		logger:     logger,
 	}, nil
 }
 
@@ -32,12 +35,14 @@ type Service interface {
 	Create(ctx context.Context, dto RecordDTO) (string, error)
 	CreateCollection(ctx context.Context, dto CollectionDTO) error
 	DeleteCollection(ctx context.Context, dto CollectionDTO) error
-	GetAll(ctx context.Context, dto RecordDTO) ([]Record, error)
+	GetAll(ctx context.Context, dto RecordDTO, friends bool) ([]Record, error)
 	GetCollectionNames(ctx context.Context) ([]Collection, error)
 	GetById(ctx context.Context, dto RecordDTO) (Record, error)
 	GetByUserId(ctx context.Context, dto RecordDTO) (u Record, err error)
 	Update(ctx context.Context, dto RecordDTO) error
 	Delete(ctx context.Context, dto RecordDTO) error
+	DeleteByUserID(ctx context.Context, userID string) error
+	GetStats(ctx context.Context, userID string) ([]TableStats, error)
 	UpdateTable(ctx context.Context, dto CollectionDTO) (int64, error)
 }
 
@@ -68,6 +73,13 @@ func NotifyManager(ctx context.Context, gameType string, startTime int64) error
 	}
 	body := io.NopCloser(strings.NewReader(string(bytes)))
 	request, err := http.NewRequestWithContext(ctx, http.MethodPost, u, body)
+	if err != nil {
+		return fmt.Errorf("failed to make request due to: %v", err)
+	}
+	err = servicetoken.Authorize(ctx, request)
+	if err != nil {
+		return fmt.Errorf("failed to authorize request due to: %v", err)
+	}
 	var client http.Client
 	response, err := client.Do(request)
 	if err != nil {
@@ -91,6 +103,10 @@ func NotifyManager(ctx context.Context, gameType string, startTime int64) error
 }
 
 func (s service) Create(ctx context.Context, dto RecordDTO) (recordID string, err error) {
+	err = checkBan(ctx, dto.UserID)
+	if err != nil {
+		return recordID, err
+	}
 
 	recordID, err = s.storage.Create(ctx, dto)
 	if err != nil {
@@ -128,11 +144,26 @@ func (s service) GetByUserId(ctx context.Context, dto RecordDTO) (u Record, err
 	return u, nil
 }
 
-func (s service) GetAll(ctx context.Context, dto RecordDTO) ([]Record, error) {
-	users, err := s.storage.FindAll(ctx, dto)
+// GetAll returns the leaderboard, records of shadow banned users are shown only to themselves.
+// With friends only records of the viewer and the viewer's friends are returned
+func (s service) GetAll(ctx context.Context, dto RecordDTO, friends bool) ([]Record, error) {
+	var viewerID string
+	if claims, ok := auth.Claims(ctx); ok {
+		viewerID = claims.Id
+	}
+	var userIDs []string
+	if friends {
+		ids, err := friendIDs(ctx, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		userIDs = append(ids, viewerID)
+	}
+	users, err := s.storage.FindAll(ctx, dto, userIDs)
 	if err != nil {
 		return users, fmt.Errorf("failed to find records. error: %v", err)
 	}
+	users = s.shadowBans.hide(ctx, users, viewerID)
 	if dto.TableName == "checkers" {
 		ReverseArray(users)
 	}
@@ -176,6 +207,24 @@ func (s service) Delete(ctx context.Context, dto RecordDTO) error {
 	return err
 }
 
+func (s service) GetStats(ctx context.Context, userID string) ([]TableStats, error) {
+	stats, err := s.storage.FindStatsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find stats of user. error: %w", err)
+	}
+	return stats, nil
+}
+
+// DeleteByUserID deletes records of the user from every table
+func (s service) DeleteByUserID(ctx context.Context, userID string) error {
+	deleted, err := s.storage.DeleteByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete records of user. error: %w", err)
+	}
+	s.logger.Infof("deleted %d records of user %s", deleted, userID)
+	return nil
+}
+
 func (s service) DeleteCollection(ctx context.Context, dto CollectionDTO) error {
 	s.logger.Debug("delete collection")
 
@@ -207,7 +256,10 @@ func (s service) CreateTicket(ctx context.Context, dto CreateTicketDTO) (string,
 	if err != nil {
 		return "", fmt.Errorf("failed to make request due to: %v", err)
 	}
-	request.Header.Add("Authorization", dto.JWT)
+	err = servicetoken.Authorize(ctx, request)
+	if err != nil {
+		return "", fmt.Errorf("failed to authorize request due to: %v", err)
+	}
 	var client http.Client
 	response, err := client.Do(request)
 	if err != nil {
@@ -234,10 +286,12 @@ func (s service) CreateTicket(ctx context.Context, dto CreateTicketDTO) (string,
 }
 
 func (s service) AddTicketToWinner(ctx context.Context, dto RecordDTO) error {
-	records, err := s.storage.FindAll(ctx, dto)
+	records, err := s.storage.FindAll(ctx, dto, nil)
 	if err != nil {
 		return fmt.Errorf("failed to find all records due to: %v", err)
 	}
+	// shadow banned users don't win tickets
+	records = s.shadowBans.hide(ctx, records, "")
 	if len(records) == 0 {
 		s.logger.Println("EMPTY RECORDS LIST")
 		return nil
@@ -260,7 +314,6 @@ func (s service) UpdateTable(ctx context.Context, dto CollectionDTO) (int64, err
 	newExpiration := time.Now().Add(timeDelta).Unix()
 	recordDTO := RecordDTO{
 		TableName: dto.Name,
-		JWTToken:  dto.JWTToken,
 	}
 	s.logger.Printf("CREATED RECORD DTO: %+v", recordDTO)
 	err := s.AddTicketToWinner(ctx, recordDTO)
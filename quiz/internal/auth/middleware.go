@@ -10,7 +10,19 @@ import (
 
 type appHandler func(http.ResponseWriter, *http.Request) error
 
+const (
+	RolePlayer   = "player"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+	RoleService  = "service"
+)
+
 func Middleware(h appHandler) http.HandlerFunc {
+	return RoleMiddleware(h)
+}
+
+// RoleMiddleware verifies token and requires it to have one of the roles. Any role is accepted if none given
+func RoleMiddleware(h appHandler, roles ...string) http.HandlerFunc {
 	log.Println("got into auth middleware")
 	return func(w http.ResponseWriter, r *http.Request) {
 		var appErr *AppError
@@ -28,12 +40,17 @@ func Middleware(h appHandler) http.HandlerFunc {
 			return
 		}
 		tokenString := authHeaderArr[1]
-		_, err := jwt_setup.ParseToken(tokenString)
+		claims, err := jwt_setup.ParseClaims(tokenString)
 		if err != nil {
 			w.WriteHeader(http.StatusUnauthorized)
 			w.Write(ErrWrongToken.Marshal())
 			return
 		}
+		if len(roles) > 0 && !claims.HasRole(roles...) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write(ErrForbidden.Marshal())
+			return
+		}
 		err = h(w, r)
 		if err != nil {
 			if errors.As(err, &appErr) {
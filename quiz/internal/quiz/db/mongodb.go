@@ -9,6 +9,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"quiz_service/internal/quiz"
 	"quiz_service/pkg/logging"
+	"quiz_service/pkg/pagination"
 )
 
 type db struct {
@@ -16,9 +17,21 @@ type db struct {
 	logger     *logging.Logger
 }
 
+// Create inserts the game server, the game server with ID is created with it once, creating it again
+// returns the ID
 func (d *db) Create(ctx context.Context, gs quiz.Quiz) (string, error) {
-	result, err := d.collection.InsertOne(ctx, gs)
+	var document interface{} = gs
+	if gs.ID != "" {
+		var err error
+		if document, err = withObjectID(gs, gs.ID); err != nil {
+			return "", err
+		}
+	}
+	result, err := d.collection.InsertOne(ctx, document)
 	if err != nil {
+		if gs.ID != "" && mongo.IsDuplicateKeyError(err) {
+			return gs.ID, nil
+		}
 		return "", fmt.Errorf("failed to create lobby due to: %v", err)
 	}
 	d.logger.Debug("convert InsertedID to objectID")
@@ -50,15 +63,31 @@ func (d *db) FindById(ctx context.Context, id string) (gs quiz.Quiz, err error)
 	return gs, nil
 }
 
-func (d *db) FindAll(ctx context.Context) (users []quiz.Quiz, err error) {
-	cursor, err := d.collection.Find(ctx, bson.M{})
-	if cursor.Err() != nil {
-		return users, fmt.Errorf("failed to find all gss due to: %v", cursor.Err())
+func (d *db) FindPage(ctx context.Context, filter quiz.Filter, params pagination.Params) (games []quiz.Quiz, err error) {
+	query := bson.M{}
+	if filter.Player != "" {
+		query["players"] = filter.Player
+	}
+	cursor, err := d.collection.Find(ctx, params.Filter(query), params.Options())
+	if err != nil {
+		return games, fmt.Errorf("failed to find gss due to: %v", err)
+	}
+	if err := cursor.All(ctx, &games); err != nil {
+		return games, fmt.Errorf("failed to read all documents from cursor due to: %v", err)
+	}
+	return games, nil
+}
+
+func (d *db) FindEndedByPlayer(ctx context.Context, userID string, before int64) (games []quiz.Quiz, err error) {
+	filter := bson.M{"players": userID, "end_time": bson.M{"$lte": before}}
+	cursor, err := d.collection.Find(ctx, filter)
+	if err != nil {
+		return games, fmt.Errorf("failed to find games of player %s due to: %v", userID, err)
 	}
-	if err := cursor.All(ctx, &users); err != nil {
-		return users, fmt.Errorf("failed to read all documents from cursor")
+	if err := cursor.All(ctx, &games); err != nil {
+		return games, fmt.Errorf("failed to read games of player from cursor due to: %v", err)
 	}
-	return users, nil
+	return games, nil
 }
 
 // Update by gsID
@@ -117,6 +146,24 @@ func (d *db) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// withObjectID returns the document with _id converted from hex, so it's found by the id later
+func withObjectID(v interface{}, id string) (bson.M, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert hex to objectID, hex: %s", id)
+	}
+	bytes, err := bson.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal document due to: %v", err)
+	}
+	var document bson.M
+	if err = bson.Unmarshal(bytes, &document); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal document bytes due to: %v", err)
+	}
+	document["_id"] = objectID
+	return document, nil
+}
+
 func NewStorage(database *mongo.Database, collection string, logger *logging.Logger) quiz.Storage {
 
 	return &db{
@@ -1,5 +1,19 @@
 package quiz
 
+import "net/url"
+
+// SortFields can be used in sort parameter of the games list
+var SortFields = []string{"start_time", "end_time"}
+
+// Filter of the games list, empty fields match any game
+type Filter struct {
+	Player string
+}
+
+func NewFilter(query url.Values) Filter {
+	return Filter{Player: query.Get("player")}
+}
+
 type Quiz struct {
 	ID        string   `json:"id" bson:"_id,omitempty"`
 	Players   []string `json:"players" bson:"players"`
@@ -11,6 +25,7 @@ type Quiz struct {
 
 func NewQuiz(dto QuizDTO) Quiz {
 	return Quiz{
+		ID:        dto.ID,
 		Players:   dto.Players,
 		Results:   nil,
 		StartTime: dto.StartTime,
@@ -31,6 +46,8 @@ func NewPlayer(dto SendResultDTO) Player {
 }
 
 type QuizDTO struct {
+	// ID is optional, the lobby service generates it, so a retried creation returns the same game server
+	ID        string   `json:"id" bson:"_id,omitempty"`
 	Players   []string `json:"players" bson:"players"`
 	StartTime int64    `json:"start_time" bson:"start_time"`
 	EndTime   int64    `json:"end_time" bson:"end_time"`
@@ -41,3 +58,40 @@ type SendResultDTO struct {
 	UserID       string `json:"user_id"`
 	Result       int    `json:"result"`
 }
+
+// Stats of the player in ended games. The game is won if nobody has a higher result
+// and the result is above zero
+type Stats struct {
+	UserID      string `json:"user_id"`
+	GamesPlayed int    `json:"games_played"`
+	Wins        int    `json:"wins"`
+	BestScore   int    `json:"best_score"`
+}
+
+// NewStats aggregates results of the player in the games
+func NewStats(userID string, games []Quiz) Stats {
+	stats := Stats{UserID: userID}
+	for _, game := range games {
+		stats.GamesPlayed++
+		result, found, best := 0, false, 0
+		for _, player := range game.Results {
+			if player.UserID == userID {
+				result, found = player.Result, true
+			}
+			if player.Result > best {
+				best = player.Result
+			}
+		}
+		if !found {
+			continue
+		}
+		// nobody wins a game where every player scored 0
+		if best > 0 && result == best {
+			stats.Wins++
+		}
+		if result > stats.BestScore {
+			stats.BestScore = result
+		}
+	}
+	return stats
+}
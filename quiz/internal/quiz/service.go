@@ -7,6 +7,7 @@ import (
 	"math/rand"
 	"quiz_service/internal/auth"
 	"quiz_service/pkg/logging"
+	"quiz_service/pkg/pagination"
 	"time"
 )
 
@@ -26,12 +27,13 @@ func NewService(storage Storage, logger logging.Logger) (Service, error) {
 
 type Service interface {
 	Create(ctx context.Context, dto QuizDTO) (string, error)
-	GetAll(ctx context.Context) ([]Quiz, error)
+	GetPage(ctx context.Context, filter Filter, params pagination.Params) (pagination.Page[Quiz], error)
 	GetById(ctx context.Context, id string) (Quiz, error)
 	Update(ctx context.Context, dto Quiz) error
 	Delete(ctx context.Context, id string) error
 	SendResult(ctx context.Context, dto SendResultDTO) error
 	GetGameStatus(ctx context.Context, gsID string) (int, error)
+	GetStats(ctx context.Context, userID string) (Stats, error)
 }
 
 func (s service) GenerateQuestions(amount, from, to int) []int {
@@ -69,12 +71,12 @@ func (s service) GetById(ctx context.Context, id string) (t Quiz, err error) {
 	return t, nil
 }
 
-func (s service) GetAll(ctx context.Context) ([]Quiz, error) {
-	users, err := s.storage.FindAll(ctx)
+func (s service) GetPage(ctx context.Context, filter Filter, params pagination.Params) (pagination.Page[Quiz], error) {
+	games, err := s.storage.FindPage(ctx, filter, params)
 	if err != nil {
-		return users, fmt.Errorf("failed to find game servers. error: %v", err)
+		return pagination.Page[Quiz]{}, fmt.Errorf("failed to find game servers. error: %v", err)
 	}
-	return users, nil
+	return pagination.NewPage(games, params)
 }
 
 func (s service) Update(ctx context.Context, quiz Quiz) error {
@@ -139,3 +141,12 @@ func (s service) GetGameStatus(ctx context.Context, gsID string) (int, error) {
 	}
 	return StatusNotStarted, nil
 }
+
+// GetStats aggregates results of the player in ended games
+func (s service) GetStats(ctx context.Context, userID string) (Stats, error) {
+	games, err := s.storage.FindEndedByPlayer(ctx, userID, time.Now().Unix())
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to find games of player due to: %v", err)
+	}
+	return NewStats(userID, games), nil
+}
@@ -1,11 +1,16 @@
 package quiz
 
-import "context"
+import (
+	"context"
+	"quiz_service/pkg/pagination"
+)
 
 type Storage interface {
 	Create(ctx context.Context, snake Quiz) (string, error)
 	FindById(ctx context.Context, id string) (Quiz, error)
-	FindAll(ctx context.Context) ([]Quiz, error)
+	FindPage(ctx context.Context, filter Filter, params pagination.Params) ([]Quiz, error)
 	Update(ctx context.Context, snake Quiz) error
 	Delete(ctx context.Context, id string) error
+	// FindEndedByPlayer returns games of the player which ended before the time
+	FindEndedByPlayer(ctx context.Context, userID string, before int64) ([]Quiz, error)
 }
@@ -0,0 +1,197 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+
+	idField = "_id"
+)
+
+// Sort orders the list by the field, documents with the same value are ordered by id
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+func (s Sort) String() string {
+	field := s.Field
+	if field == idField {
+		field = "id"
+	}
+	if s.Desc {
+		return "-" + field
+	}
+	return field
+}
+
+// Params is one page request: limit, sort and position after the last item of the previous page
+type Params struct {
+	Limit int
+	Sort  Sort
+	after *cursor
+}
+
+// Page is the response envelope of list endpoints, NextCursor is empty on the last page
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor"`
+}
+
+// cursor keeps sort value and id of the last item, so the next page starts right after it
+// even if documents were inserted or deleted in between
+type cursor struct {
+	Sort  string             `bson:"s"`
+	Value bson.RawValue      `bson:"v"`
+	ID    primitive.ObjectID `bson:"id"`
+}
+
+// Parse reads limit, sort and after from the query. Sort is a field name, "-" prefix means descending,
+// only id and the sortable fields are allowed
+func Parse(query url.Values, sortable ...string) (Params, error) {
+	p := Params{Limit: DefaultLimit, Sort: Sort{Field: idField}}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > MaxLimit {
+			return p, fmt.Errorf("limit must be a number from 1 to %d", MaxLimit)
+		}
+		p.Limit = n
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		field := strings.TrimPrefix(sort, "-")
+		p.Sort.Desc = field != sort
+		if field == "id" {
+			field = idField
+		} else if !contains(sortable, field) {
+			return p, fmt.Errorf("can't sort by %s, allowed: %s", field, strings.Join(append([]string{"id"}, sortable...), ", "))
+		}
+		p.Sort.Field = field
+	}
+
+	if after := query.Get("after"); after != "" {
+		c, err := decodeCursor(after)
+		if err != nil {
+			return p, errors.New("invalid cursor")
+		}
+		if c.Sort != p.Sort.String() {
+			return p, errors.New("cursor was issued for another sort")
+		}
+		p.after = &c
+	}
+	return p, nil
+}
+
+// Filter adds the position of the cursor to the filter
+func (p Params) Filter(filter bson.M) bson.M {
+	if p.after == nil {
+		return filter
+	}
+	op := "$gt"
+	if p.Sort.Desc {
+		op = "$lt"
+	}
+	var position bson.M
+	if p.Sort.Field == idField {
+		position = bson.M{idField: bson.M{op: p.after.ID}}
+	} else {
+		position = bson.M{"$or": bson.A{
+			bson.M{p.Sort.Field: bson.M{op: p.after.Value}},
+			bson.M{p.Sort.Field: p.after.Value, idField: bson.M{op: p.after.ID}},
+		}}
+	}
+	if len(filter) == 0 {
+		return position
+	}
+	return bson.M{"$and": bson.A{filter, position}}
+}
+
+// Options sorts by the field and id and asks for one extra document to know if there is a next page
+func (p Params) Options() *options.FindOptions {
+	direction := 1
+	if p.Sort.Desc {
+		direction = -1
+	}
+	sort := bson.D{{Key: p.Sort.Field, Value: direction}}
+	if p.Sort.Field != idField {
+		sort = append(sort, bson.E{Key: idField, Value: direction})
+	}
+	return options.Find().SetSort(sort).SetLimit(int64(p.Limit + 1))
+}
+
+// NewPage cuts the extra document found by Options and makes the cursor from the last item
+func NewPage[T any](items []T, p Params) (Page[T], error) {
+	if items == nil {
+		items = []T{}
+	}
+	if len(items) <= p.Limit {
+		return Page[T]{Items: items}, nil
+	}
+	items = items[:p.Limit]
+	next, err := encodeCursor(items[len(items)-1], p.Sort)
+	if err != nil {
+		return Page[T]{}, err
+	}
+	return Page[T]{Items: items, NextCursor: next}, nil
+}
+
+func encodeCursor(item interface{}, sort Sort) (string, error) {
+	raw, err := bson.Marshal(item)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal last item of page due to: %v", err)
+	}
+	doc := bson.Raw(raw)
+
+	c := cursor{Sort: sort.String(), Value: bson.RawValue{Type: bsontype.Null}}
+	switch id := doc.Lookup(idField); id.Type {
+	case bsontype.ObjectID:
+		c.ID = id.ObjectID()
+	case bsontype.String:
+		if c.ID, err = primitive.ObjectIDFromHex(id.StringValue()); err != nil {
+			return "", fmt.Errorf("failed to convert id of last item of page due to: %v", err)
+		}
+	default:
+		return "", fmt.Errorf("last item of page has no id")
+	}
+	// missing field stays null, mongo sorts it as null too
+	if value, err := doc.LookupErr(sort.Field); err == nil && sort.Field != idField {
+		c.Value = value
+	}
+
+	bytes, err := bson.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal cursor due to: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+func decodeCursor(s string) (c cursor, err error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = bson.Unmarshal(bytes, &c)
+	return c, err
+}
+
+func contains(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"snake_service/internal/auth"
 	"snake_service/pkg/logging"
+	"snake_service/pkg/pagination"
 )
 
 var (
@@ -17,6 +18,7 @@ var (
 	gameServerIDUrl = "/api/snake/id/:id"
 	sendResultURL   = "/api/snake/res/"
 	getStatusURL    = "/api/snake/status/:id"
+	statsURL        = "/api/snake/stats/:id"
 )
 
 type Handler struct {
@@ -28,10 +30,11 @@ func (h *Handler) Register(router *httprouter.Router) {
 	router.HandlerFunc(http.MethodPost, gameServersUrl, auth.Middleware(h.CreateGS))
 	router.HandlerFunc(http.MethodPost, gameServerIDUrl, auth.Middleware(h.GetGSById))
 	router.HandlerFunc(http.MethodPost, getAllSnakesUrl, auth.Middleware(h.GetGameServers))
-	router.HandlerFunc(http.MethodDelete, gameServerIDUrl, auth.Middleware(h.DeleteGS))
+	router.HandlerFunc(http.MethodDelete, gameServerIDUrl, auth.RoleMiddleware(h.DeleteGS, auth.RoleOperator, auth.RoleAdmin))
 	router.HandlerFunc(http.MethodPut, gameServersUrl, auth.Middleware(h.PartiallyUpdateGS))
 	router.HandlerFunc(http.MethodPost, sendResultURL, auth.Middleware(h.SendResult))
 	router.HandlerFunc(http.MethodPost, getStatusURL, auth.Middleware(h.GetGameStatus))
+	router.HandlerFunc(http.MethodGet, statsURL, auth.Middleware(h.GetStats))
 }
 
 // Create game server
@@ -99,23 +102,31 @@ func (h *Handler) GetGSById(w http.ResponseWriter, r *http.Request) error {
 }
 
 // Get snakes
-// @Summary Get all snakes
+// @Summary Get page of games
 // @Accept json
 // @Produce json
 // @Tags Snakes
+// @Param limit query int false "page size, 20 by default, 100 at most"
+// @Param after query string false "next_cursor of the previous page"
+// @Param sort query string false "id, start_time or end_time, - prefix for descending"
+// @Param player query string false "games of the user"
 // @Success 200
 // @Failure 400
-// @Router /api/snakes/get/all [post]
+// @Router /api/snake/all/ [post]
 func (h *Handler) GetGameServers(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Info("GET GAME SERVERS")
 	w.Header().Set("Content-Type", "application/json")
 
-	snakes, err := h.GameService.GetAll(r.Context())
+	query := r.URL.Query()
+	params, err := pagination.Parse(query, SortFields...)
 	if err != nil {
-		return err
+		return auth.BadRequestError(err.Error())
 	}
 
-	h.Logger.Println(snakes)
+	snakes, err := h.GameService.GetPage(r.Context(), NewFilter(query), params)
+	if err != nil {
+		return err
+	}
 
 	userBytes, err := json.Marshal(snakes)
 	if err != nil {
@@ -160,6 +171,7 @@ func (h *Handler) PartiallyUpdateGS(w http.ResponseWriter, r *http.Request) erro
 // @Tags Snakes
 // @Success 204
 // @Failure 400
+// @Failure 403 {object} auth.AppError
 // @Router /api/snakes [delete]
 func (h *Handler) DeleteGS(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Info("DELETE GAME SERVER")
@@ -212,3 +224,28 @@ func (h *Handler) GetGameStatus(w http.ResponseWriter, r *http.Request) error {
 	w.Write(bytes)
 	return nil
 }
+
+// Get stats
+// @Summary Get games played, wins and best score of the player in ended games
+// @Produce json
+// @Param id path string true "User ID"
+// @Tags Snakes
+// @Success 200 {object} Stats
+// @Router /api/snake/stats/{id} [get]
+func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("GET STATS")
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	stats, err := h.GameService.GetStats(r.Context(), params.ByName("id"))
+	if err != nil {
+		return err
+	}
+	bytes, err := json.Marshal(stats)
+	if err != nil {
+		return fmt.Errorf("failed to marshal response: %v", err)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(bytes)
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"snake_service/internal/auth"
 	"snake_service/pkg/logging"
+	"snake_service/pkg/pagination"
 	"time"
 )
 
@@ -25,12 +26,13 @@ func NewService(storage Storage, logger logging.Logger) (Service, error) {
 
 type Service interface {
 	Create(ctx context.Context, dto SnakeDTO) (string, error)
-	GetAll(ctx context.Context) ([]Snake, error)
+	GetPage(ctx context.Context, filter Filter, params pagination.Params) (pagination.Page[Snake], error)
 	GetById(ctx context.Context, id string) (Snake, error)
 	Update(ctx context.Context, dto Snake) error
 	Delete(ctx context.Context, id string) error
 	SendResult(ctx context.Context, dto SendResultDTO) error
 	GetGameStatus(ctx context.Context, gsID string) (int, error)
+	GetStats(ctx context.Context, userID string) (Stats, error)
 }
 
 func (s service) Create(ctx context.Context, dto SnakeDTO) (snakeID string, err error) {
@@ -58,12 +60,12 @@ func (s service) GetById(ctx context.Context, id string) (t Snake, err error) {
 	return t, nil
 }
 
-func (s service) GetAll(ctx context.Context) ([]Snake, error) {
-	users, err := s.storage.FindAll(ctx)
+func (s service) GetPage(ctx context.Context, filter Filter, params pagination.Params) (pagination.Page[Snake], error) {
+	games, err := s.storage.FindPage(ctx, filter, params)
 	if err != nil {
-		return users, fmt.Errorf("failed to find game servers. error: %v", err)
+		return pagination.Page[Snake]{}, fmt.Errorf("failed to find game servers. error: %v", err)
 	}
-	return users, nil
+	return pagination.NewPage(games, params)
 }
 
 func (s service) Update(ctx context.Context, snake Snake) error {
@@ -128,3 +130,12 @@ func (s service) GetGameStatus(ctx context.Context, gsID string) (int, error) {
 	}
 	return StatusNotStarted, nil
 }
+
+// GetStats aggregates results of the player in ended games
+func (s service) GetStats(ctx context.Context, userID string) (Stats, error) {
+	games, err := s.storage.FindEndedByPlayer(ctx, userID, time.Now().Unix())
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to find games of player due to: %v", err)
+	}
+	return NewStats(userID, games), nil
+}
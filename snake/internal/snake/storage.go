@@ -1,11 +1,16 @@
 package snake
 
-import "context"
+import (
+	"context"
+	"snake_service/pkg/pagination"
+)
 
 type Storage interface {
 	Create(ctx context.Context, snake Snake) (string, error)
 	FindById(ctx context.Context, id string) (Snake, error)
-	FindAll(ctx context.Context) ([]Snake, error)
+	FindPage(ctx context.Context, filter Filter, params pagination.Params) ([]Snake, error)
 	Update(ctx context.Context, snake Snake) error
 	Delete(ctx context.Context, id string) error
+	// FindEndedByPlayer returns games of the player which ended before the time
+	FindEndedByPlayer(ctx context.Context, userID string, before int64) ([]Snake, error)
 }
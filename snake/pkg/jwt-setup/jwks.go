@@ -0,0 +1,126 @@
+package jwt_setup
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	jwksRefreshInterval    = 10 * time.Minute
+	jwksMinRefreshInterval = 30 * time.Second
+	jwksRequestTimeout     = 5 * time.Second
+)
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+// JWKS caches public keys of the auth service. Keys are refreshed periodically
+// and when a token is signed with unknown key after rotation
+type JWKS struct {
+	url         string
+	client      *http.Client
+	mu          sync.Mutex
+	keys        map[string]*rsa.PublicKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+}
+
+func NewJWKS(url string) *JWKS {
+	return &JWKS{
+		url:    url,
+		client: &http.Client{Timeout: jwksRequestTimeout},
+		keys:   map[string]*rsa.PublicKey{},
+	}
+}
+
+// Keyfunc accepts only RS256 tokens with kid header and returns key to verify them
+func (j *JWKS) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, fmt.Errorf("token has no kid header")
+	}
+	return j.Key(kid)
+}
+
+// Key returns public key by id. If the auth service is unavailable cached keys are used
+func (j *JWKS) Key(kid string) (*rsa.PublicKey, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	key, found := j.keys[kid]
+	stale := time.Since(j.fetchedAt) > jwksRefreshInterval
+	if (stale || !found) && time.Since(j.attemptedAt) > jwksMinRefreshInterval {
+		if err := j.fetch(); err != nil && !found {
+			return nil, err
+		}
+		key, found = j.keys[kid]
+	}
+	if !found {
+		return nil, fmt.Errorf("unknown key id: %s", kid)
+	}
+	return key, nil
+}
+
+func (j *JWKS) fetch() error {
+	j.attemptedAt = time.Now()
+	response, err := j.client.Get(j.url)
+	if err != nil {
+		return fmt.Errorf("failed to fetch jwks due to: %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch jwks, status: %d", response.StatusCode)
+	}
+	var set jwkSet
+	if err := json.NewDecoder(response.Body).Decode(&set); err != nil {
+		return fmt.Errorf("failed to decode jwks due to: %v", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return err
+		}
+		keys[k.Kid] = key
+	}
+	j.keys = keys
+	j.fetchedAt = j.attemptedAt
+	return nil
+}
+
+func (k jwk) publicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode modulus of key %s due to: %v", k.Kid, err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode exponent of key %s due to: %v", k.Kid, err)
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
@@ -1,5 +1,5 @@
 package ticket
 
 const (
-	AddTicketByIDURL = "http://localhost:10002/api/users/tickets/"
+	GrantTicketURL = "http://localhost:10002/api/users/internal/tickets/grant"
 )
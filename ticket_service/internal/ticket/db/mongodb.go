@@ -9,6 +9,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"ticket_service/internal/ticket"
 	"ticket_service/pkg/logging"
+	"ticket_service/pkg/pagination"
 )
 
 type db struct {
@@ -50,15 +51,25 @@ func (d *db) FindById(ctx context.Context, id string) (ticket ticket.Ticket, err
 	return ticket, nil
 }
 
-func (d *db) FindAll(ctx context.Context) (users []ticket.Ticket, err error) {
-	cursor, err := d.collection.Find(ctx, bson.M{})
-	if cursor.Err() != nil {
-		return users, fmt.Errorf("failed to find all tickets due to: %v", cursor.Err())
+func (d *db) FindPage(ctx context.Context, filter ticket.Filter, params pagination.Params) (tickets []ticket.Ticket, err error) {
+	query := bson.M{}
+	if filter.UserID != "" {
+		query["user_id"] = filter.UserID
 	}
-	if err := cursor.All(ctx, &users); err != nil {
-		return users, fmt.Errorf("failed to read all documents from cursor")
+	if filter.GameType != "" {
+		query["game_type"] = filter.GameType
 	}
-	return users, nil
+	if filter.IsActive != nil {
+		query["is_active"] = *filter.IsActive
+	}
+	cursor, err := d.collection.Find(ctx, params.Filter(query), params.Options())
+	if err != nil {
+		return tickets, fmt.Errorf("failed to find tickets due to: %v", err)
+	}
+	if err := cursor.All(ctx, &tickets); err != nil {
+		return tickets, fmt.Errorf("failed to read all documents from cursor due to: %v", err)
+	}
+	return tickets, nil
 }
 
 // Update by ticketID
@@ -117,6 +128,14 @@ func (d *db) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+func (d *db) DeleteByUserID(ctx context.Context, userID string) (int64, error) {
+	result, err := d.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete tickets of user %s due to: %v", userID, err)
+	}
+	return result.DeletedCount, nil
+}
+
 func NewStorage(database *mongo.Database, collection string, logger *logging.Logger) ticket.Storage {
 
 	return &db{
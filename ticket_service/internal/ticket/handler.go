@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"ticket_service/internal/auth"
 	"ticket_service/pkg/logging"
+	"ticket_service/pkg/pagination"
 )
 
 var (
@@ -15,8 +16,10 @@ var (
 	getTicketUrl           = "/api/tickets/get/id"
 	getTicketStatusURL     = "/api/tickets/get/status/id"
 	useTicketURL           = "/api/tickets/use/:id"
+	releaseTicketURL       = "/api/tickets/release/:id"
 	getFreeTicketStatusURL = "/api/tickets/free/get/status"
 	setFreeTicketStatusURL = "/api/tickets/free/set/status"
+	userTicketsURL         = "/api/tickets/users/:id"
 )
 
 type Handler struct {
@@ -28,12 +31,14 @@ func (h *Handler) Register(router *httprouter.Router) {
 	router.HandlerFunc(http.MethodPost, ticketsUrl, auth.NoAuthMiddleware(h.CreateTicket))
 	router.HandlerFunc(http.MethodPost, getTicketUrl, auth.Middleware(h.GetTicketById))
 	router.HandlerFunc(http.MethodPost, getAllTicketsUrl, auth.Middleware(h.GetTickets))
-	router.HandlerFunc(http.MethodDelete, ticketsUrl, auth.Middleware(h.DeleteTicket))
+	router.HandlerFunc(http.MethodDelete, ticketsUrl, auth.RoleMiddleware(h.DeleteTicket, auth.RoleOperator, auth.RoleAdmin))
 	router.HandlerFunc(http.MethodPatch, ticketsUrl, auth.Middleware(h.PartiallyUpdateTicket))
 	router.HandlerFunc(http.MethodPost, getTicketStatusURL, auth.Middleware(h.GetTicketStatusById))
 	router.HandlerFunc(http.MethodPost, setFreeTicketStatusURL, auth.Middleware(h.SetFreeTicketStatus))
 	router.HandlerFunc(http.MethodPost, getFreeTicketStatusURL, auth.Middleware(h.GetFreeTicketStatus))
-	router.HandlerFunc(http.MethodPost, useTicketURL, auth.NoAuthMiddleware(h.UseTicket))
+	router.HandlerFunc(http.MethodPost, useTicketURL, auth.RoleMiddleware(h.UseTicket, auth.RoleService))
+	router.HandlerFunc(http.MethodPost, releaseTicketURL, auth.RoleMiddleware(h.ReleaseTicket, auth.RoleService))
+	router.HandlerFunc(http.MethodDelete, userTicketsURL, auth.RoleMiddleware(h.DeleteUserTickets, auth.RoleService))
 }
 
 // Create lobby
@@ -54,7 +59,6 @@ func (h *Handler) CreateTicket(w http.ResponseWriter, r *http.Request) error {
 	if err != nil {
 		return auth.BadRequestError("invalid JSON scheme. check swagger API")
 	}
-	dto.JWTToken = r.Header.Get("Authorization")
 	ticketID, err := h.TicketService.Create(r.Context(), dto)
 	if err != nil {
 		return err
@@ -143,10 +147,16 @@ func (h *Handler) GetTicketStatusById(w http.ResponseWriter, r *http.Request) er
 }
 
 // Get tickets
-// @Summary Get all tickets
+// @Summary Get page of tickets
 // @Accept json
 // @Produce json
 // @Tags Tickets
+// @Param limit query int false "page size, 20 by default, 100 at most"
+// @Param after query string false "next_cursor of the previous page"
+// @Param sort query string false "id, user_id, game_type, ticket_price or player_amount, - prefix for descending"
+// @Param user_id query string false "tickets of the user"
+// @Param game_type query string false "tickets of the game type"
+// @Param is_active query bool false "active or used tickets"
 // @Success 200
 // @Failure 400
 // @Router /api/tickets/get/all [post]
@@ -154,12 +164,20 @@ func (h *Handler) GetTickets(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Info("GET TICKETS")
 	w.Header().Set("Content-Type", "application/json")
 
-	tickets, err := h.TicketService.GetAll(r.Context())
+	query := r.URL.Query()
+	params, err := pagination.Parse(query, SortFields...)
 	if err != nil {
-		return err
+		return auth.BadRequestError(err.Error())
+	}
+	filter, err := NewFilter(query)
+	if err != nil {
+		return auth.BadRequestError(err.Error())
 	}
 
-	h.Logger.Println(tickets)
+	tickets, err := h.TicketService.GetPage(r.Context(), filter, params)
+	if err != nil {
+		return err
+	}
 
 	userBytes, err := json.Marshal(tickets)
 	if err != nil {
@@ -204,6 +222,7 @@ func (h *Handler) PartiallyUpdateTicket(w http.ResponseWriter, r *http.Request)
 // @Tags Tickets
 // @Success 204
 // @Failure 400
+// @Failure 403 {object} auth.AppError
 // @Router /api/tickets [delete]
 func (h *Handler) DeleteTicket(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Info("DELETE TICKET")
@@ -224,6 +243,27 @@ func (h *Handler) DeleteTicket(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+// Delete tickets of user
+// @Summary Delete all tickets of the user. Called by user service when the account is deleted
+// @Produce json
+// @Param id path string true "User ID"
+// @Tags Tickets
+// @Success 204
+// @Failure 403 {object} auth.AppError
+// @Router /api/tickets/users/{id} [delete]
+func (h *Handler) DeleteUserTickets(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("DELETE USER TICKETS")
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	err := h.TicketService.DeleteByUserID(r.Context(), params.ByName("id"))
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
 func (h *Handler) UseTicket(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Info("USE TICKET")
 	w.Header().Set("Content-Type", "application/json")
@@ -238,6 +278,29 @@ func (h *Handler) UseTicket(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+// Release ticket
+// @Summary Make the used ticket active again. Lobby service calls it when joining the lobby fails after the ticket was used
+// @Accept json
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Tags Tickets
+// @Success 204
+// @Failure 403
+// @Failure 404
+// @Router /api/tickets/release/{id} [post]
+func (h *Handler) ReleaseTicket(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("RELEASE TICKET")
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	err := h.TicketService.ReleaseTicket(r.Context(), params.ByName("id"))
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
 // Set free lobby status
 // @Summary Set free lobby status endpoint. Requires authorization and access key
 // @Accept json
@@ -1,5 +1,14 @@
 package ticket
 
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// SortFields can be used in sort parameter of the tickets list
+var SortFields = []string{"user_id", "game_type", "ticket_price", "player_amount"}
+
 type Ticket struct {
 	ID           string `json:"id" bson:"_id,omitempty"`
 	UserID       string `json:"user_id" bson:"user_id"`
@@ -11,6 +20,28 @@ type Ticket struct {
 	PrizeId      string `json:"prize_id" bson:"prize_id"`
 }
 
+// Filter of the tickets list, empty fields match any ticket
+type Filter struct {
+	UserID   string
+	GameType string
+	IsActive *bool
+}
+
+func NewFilter(query url.Values) (Filter, error) {
+	filter := Filter{
+		UserID:   query.Get("user_id"),
+		GameType: query.Get("game_type"),
+	}
+	if isActive := query.Get("is_active"); isActive != "" {
+		value, err := strconv.ParseBool(isActive)
+		if err != nil {
+			return filter, fmt.Errorf("is_active must be true or false")
+		}
+		filter.IsActive = &value
+	}
+	return filter, nil
+}
+
 type TicketDTO struct {
 	UserID       string `json:"user_id"`
 	IsGift       bool   `json:"is_gift"`
@@ -18,7 +49,6 @@ type TicketDTO struct {
 	PlayerAmount int    `json:"player_amount"`
 	GameType     string `json:"game_type"`
 	PrizeId      string `json:"prize_id"`
-	JWTToken     string `json:"-"`
 }
 
 type TicketIDDTO struct {
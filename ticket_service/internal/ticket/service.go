@@ -12,6 +12,8 @@ import (
 	"ticket_service/internal/auth"
 	"ticket_service/internal/config"
 	"ticket_service/pkg/logging"
+	"ticket_service/pkg/pagination"
+	"ticket_service/pkg/servicetoken"
 )
 
 var _ Service = &service{}
@@ -30,11 +32,13 @@ func NewService(userStorage Storage, logger logging.Logger) (Service, error) {
 
 type Service interface {
 	Create(ctx context.Context, dto TicketDTO) (string, error)
-	GetAll(ctx context.Context) ([]Ticket, error)
+	GetPage(ctx context.Context, filter Filter, params pagination.Params) (pagination.Page[Ticket], error)
 	GetById(ctx context.Context, id string) (Ticket, error)
 	Update(ctx context.Context, dto Ticket) error
 	Delete(ctx context.Context, id string) error
+	DeleteByUserID(ctx context.Context, userID string) error
 	UseTicket(ctx context.Context, ticketID string) error
+	ReleaseTicket(ctx context.Context, ticketID string) error
 	SetFreeTicketStatus(dto FreeTicketStatusDTO) error
 	GetFreeTicketStatus() bool
 }
@@ -64,11 +68,14 @@ func (s service) Create(ctx context.Context, dto TicketDTO) (ticketID string, er
 		return "", fmt.Errorf("failed to marshal data due to: %v", err)
 	}
 	reqBody := io.NopCloser(strings.NewReader(string(bytes)))
-	request, err := http.NewRequestWithContext(ctx, http.MethodPut, AddTicketByIDURL, reqBody)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, GrantTicketURL, reqBody)
 	if err != nil {
 		return "", fmt.Errorf("failed to make request due to: %v", err)
 	}
-	request.Header.Set("Authorization", dto.JWTToken)
+	err = servicetoken.Authorize(ctx, request)
+	if err != nil {
+		return "", fmt.Errorf("failed to authorize request due to: %v", err)
+	}
 	response, err := client.Do(request)
 	if err != nil {
 		return "", fmt.Errorf("failed to do request due to: %v", err)
@@ -76,8 +83,9 @@ func (s service) Create(ctx context.Context, dto TicketDTO) (ticketID string, er
 	if response == nil {
 		return "", fmt.Errorf("response is nil")
 	}
-	if response.StatusCode != 201 {
-		if response.StatusCode == 418 {
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		if response.StatusCode == 418 || response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusConflict {
 			bytes, err = io.ReadAll(response.Body)
 			if err != nil {
 				return "", fmt.Errorf("faileed to read response body due to: %v", err)
@@ -103,12 +111,12 @@ func (s service) GetById(ctx context.Context, id string) (t Ticket, err error) {
 	return t, nil
 }
 
-func (s service) GetAll(ctx context.Context) ([]Ticket, error) {
-	users, err := s.storage.FindAll(ctx)
+func (s service) GetPage(ctx context.Context, filter Filter, params pagination.Params) (pagination.Page[Ticket], error) {
+	tickets, err := s.storage.FindPage(ctx, filter, params)
 	if err != nil {
-		return users, fmt.Errorf("failed to find Tickets. error: %v", err)
+		return pagination.Page[Ticket]{}, fmt.Errorf("failed to find Tickets. error: %v", err)
 	}
-	return users, nil
+	return pagination.NewPage(tickets, params)
 }
 
 func (s service) Update(ctx context.Context, ticket Ticket) error {
@@ -139,6 +147,19 @@ func (s service) UseTicket(ctx context.Context, ticketID string) error {
 
 }
 
+// ReleaseTicket reverts UseTicket, releasing an active ticket isn't an error
+func (s service) ReleaseTicket(ctx context.Context, ticketID string) error {
+	ticket, err := s.GetById(ctx, ticketID)
+	if err != nil {
+		return err
+	}
+	if ticket.IsActive {
+		return nil
+	}
+	ticket.IsActive = true
+	return s.Update(ctx, ticket)
+}
+
 func (s service) Delete(ctx context.Context, id string) error {
 	err := s.storage.Delete(ctx, id)
 
@@ -151,6 +172,16 @@ func (s service) Delete(ctx context.Context, id string) error {
 	return err
 }
 
+// DeleteByUserID deletes all tickets of the user, deleting tickets of a user without them is not an error
+func (s service) DeleteByUserID(ctx context.Context, userID string) error {
+	deleted, err := s.storage.DeleteByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete tickets of user. error: %w", err)
+	}
+	s.logger.Infof("deleted %d tickets of user %s", deleted, userID)
+	return nil
+}
+
 func (s service) SetFreeTicketStatus(dto FreeTicketStatusDTO) error {
 	cfg := config.GetConfig()
 	if dto.AccessKey != cfg.Keys.AccessKey {
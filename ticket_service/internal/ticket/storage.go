@@ -1,11 +1,15 @@
 package ticket
 
-import "context"
+import (
+	"context"
+	"ticket_service/pkg/pagination"
+)
 
 type Storage interface {
 	Create(ctx context.Context, ticket Ticket) (string, error)
 	FindById(ctx context.Context, id string) (Ticket, error)
-	FindAll(ctx context.Context) ([]Ticket, error)
+	FindPage(ctx context.Context, filter Filter, params pagination.Params) ([]Ticket, error)
 	Update(ctx context.Context, ticket Ticket) error
 	Delete(ctx context.Context, id string) error
+	DeleteByUserID(ctx context.Context, userID string) (int64, error)
 }
@@ -5,13 +5,20 @@ import (
 	"net/http"
 )
 
-const HeartbeatURL = "/userapi/heartbeat"
+const (
+	HeartbeatURL = "/userapi/heartbeat"
+	ReadyURL     = "/health/ready"
+)
 
+// Handler serves liveness and readiness metrics.
+// Checks are run on every readiness request, service is ready if all of them pass
 type Handler struct {
+	Checks map[string]Check
 }
 
 func (h *Handler) Register(router *httprouter.Router) {
 	router.HandlerFunc(http.MethodGet, HeartbeatURL, h.Heartbeat)
+	router.HandlerFunc(http.MethodGet, ReadyURL, h.Ready)
 }
 
 // Heartbeat
@@ -24,3 +31,21 @@ func (h *Handler) Heartbeat(w http.ResponseWriter, r *http.Request) {
 	// Check if the server is up
 	w.WriteHeader(204)
 }
+
+// Ready
+// @Summary Readiness metric. Checks database and downstream services
+// @Tags Metrics
+// @Produce json
+// @Success 200 {object} ReadyResponse
+// @Failure 503 {object} ReadyResponse
+// @Router /health/ready [get]
+func (h *Handler) Ready(w http.ResponseWriter, r *http.Request) {
+	response := RunChecks(r.Context(), h.Checks)
+	w.Header().Set("Content-Type", "application/json")
+	if response.Status != StatusOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.Write(response.Marshal())
+}
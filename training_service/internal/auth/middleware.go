@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"log"
 	"net/http"
@@ -10,7 +11,27 @@ import (
 
 type appHandler func(http.ResponseWriter, *http.Request) error
 
+const (
+	RolePlayer   = "player"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+	RoleService  = "service"
+)
+
+type contextKey struct{}
+
+// Claims returns claims of the verified token, RoleMiddleware puts them into the request context
+func Claims(ctx context.Context) (*jwt_setup.RegisteredClaims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*jwt_setup.RegisteredClaims)
+	return claims, ok
+}
+
 func Middleware(h appHandler) http.HandlerFunc {
+	return RoleMiddleware(h)
+}
+
+// RoleMiddleware verifies token and requires it to have one of the roles. Any role is accepted if none given
+func RoleMiddleware(h appHandler, roles ...string) http.HandlerFunc {
 	log.Println("got into auth middleware")
 	return func(w http.ResponseWriter, r *http.Request) {
 		var appErr *AppError
@@ -28,13 +49,18 @@ func Middleware(h appHandler) http.HandlerFunc {
 			return
 		}
 		tokenString := authHeaderArr[1]
-		_, err := jwt_setup.ParseToken(tokenString)
+		claims, err := jwt_setup.ParseClaims(tokenString)
 		if err != nil {
 			w.WriteHeader(http.StatusUnauthorized)
 			w.Write(ErrWrongToken.Marshal())
 			return
 		}
-		err = h(w, r)
+		if len(roles) > 0 && !claims.HasRole(roles...) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write(ErrForbidden.Marshal())
+			return
+		}
+		err = h(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, claims)))
 		if err != nil {
 			if errors.As(err, &appErr) {
 				if errors.Is(err, ErrNotFound) {
@@ -42,6 +68,11 @@ func Middleware(h appHandler) http.HandlerFunc {
 					w.Write(ErrNotFound.Marshal())
 					return
 				}
+				if errors.Is(err, ErrBanned) {
+					w.WriteHeader(http.StatusForbidden)
+					w.Write(appErr.Marshal())
+					return
+				}
 				err := err.(*AppError)
 				w.WriteHeader(http.StatusBadRequest)
 				w.Write(err.Marshal())
@@ -66,6 +97,11 @@ func NoAuthMiddleware(h appHandler) http.HandlerFunc {
 					w.Write(ErrNotFound.Marshal())
 					return
 				}
+				if errors.Is(err, ErrBanned) {
+					w.WriteHeader(http.StatusForbidden)
+					w.Write(appErr.Marshal())
+					return
+				}
 				err := err.(*AppError)
 				w.WriteHeader(http.StatusBadRequest)
 				w.Write(err.Marshal())
@@ -3,7 +3,12 @@ package table
 import "time"
 
 const (
-	timeDelta       = 48 * time.Hour
-	notifyMangerURL = "http://localhost:10007/api/manager/"
-	typeTraining    = "training"
+	timeDelta          = 48 * time.Hour
+	notifyMangerURL    = "http://localhost:10007/api/manager/"
+	sanctionsURL       = "http://localhost:10002/api/users/internal/sanctions/"
+	shadowBannedURL    = "http://localhost:10002/api/users/internal/shadow-banned"
+	friendsURL         = "http://localhost:10002/api/users/internal/friends/"
+	typeTraining       = "training"
+	userServiceTimeout = 5 * time.Second
+	shadowBansTTL      = time.Minute
 )
@@ -178,7 +178,7 @@ func (d *db) FindByUserId(ctx context.Context, dto table.RecordDTO) (record tabl
 	return record, nil
 }
 
-func (d *db) FindAll(ctx context.Context, dto table.RecordDTO) (users []table.Record, err error) {
+func (d *db) FindAll(ctx context.Context, dto table.RecordDTO, userIDs []string) (users []table.Record, err error) {
 	isCollection, err := d.IsCollection(ctx, dto.TableName)
 	if err != nil {
 		return users, err
@@ -188,7 +188,11 @@ func (d *db) FindAll(ctx context.Context, dto table.RecordDTO) (users []table.Re
 	}
 	tableName := dto.TableName
 	collection := d.database.Collection(tableName)
-	cursor, err := collection.Find(ctx, bson.M{})
+	filter := bson.M{}
+	if userIDs != nil {
+		filter["user_id"] = bson.M{"$in": userIDs}
+	}
+	cursor, err := collection.Find(ctx, filter)
 	if cursor.Err() != nil {
 		return users, fmt.Errorf("failed to find all users due to: %v", cursor.Err())
 	}
@@ -286,6 +290,23 @@ func (d *db) Delete(ctx context.Context, dto table.RecordDTO) error {
 	return nil
 }
 
+// DeleteByUserID deletes records of the user from every collection, each collection is a table
+func (d *db) DeleteByUserID(ctx context.Context, userID string) (int64, error) {
+	names, err := d.database.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list collections due to: %v", err)
+	}
+	var deleted int64
+	for _, name := range names {
+		result, err := d.database.Collection(name).DeleteMany(ctx, bson.M{"user_id": userID})
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete records of user %s from %s due to: %v", userID, name, err)
+		}
+		deleted += result.DeletedCount
+	}
+	return deleted, nil
+}
+
 func NewStorage(database *mongo.Database, logger *logging.Logger) table.Storage {
 	return &db{
 		database: database,
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/julienschmidt/httprouter"
 	"net/http"
+	"strconv"
 	"training_service/internal/auth"
 	"training_service/pkg/logging"
 )
@@ -18,6 +19,7 @@ var (
 	getAllCollectionsUrl = "/api/training/collections/get/all"
 	collectionsUrl       = "/api/training/collections"
 	updateTimeURL        = "/api/training/time/:game_type"
+	userRecordsURL       = "/api/training/users/:id"
 )
 
 type Handler struct {
@@ -31,11 +33,12 @@ func (h *Handler) Register(router *httprouter.Router) {
 	router.HandlerFunc(http.MethodPost, getRecordByUserIDUrl, auth.Middleware(h.GetRecordByUserId))
 	router.HandlerFunc(http.MethodPost, getAllRecordsUrl, auth.Middleware(h.GetRecords))
 	router.HandlerFunc(http.MethodGet, getAllCollectionsUrl, auth.Middleware(h.GetCollectionNames))
-	router.HandlerFunc(http.MethodDelete, recordsUrl, auth.Middleware(h.DeleteRecord))
+	router.HandlerFunc(http.MethodDelete, recordsUrl, auth.RoleMiddleware(h.DeleteRecord, auth.RoleOperator, auth.RoleAdmin))
 	router.HandlerFunc(http.MethodPatch, recordsUrl, auth.Middleware(h.PartiallyUpdateRecord))
 	router.HandlerFunc(http.MethodPost, collectionsUrl, auth.Middleware(h.CreateCollection))
-	router.HandlerFunc(http.MethodDelete, collectionsUrl, auth.Middleware(h.DeleteCollectionByName))
-	router.Handler(http.MethodPut, updateTimeURL, auth.NoAuthMiddleware(h.UpdateTime))
+	router.HandlerFunc(http.MethodDelete, collectionsUrl, auth.RoleMiddleware(h.DeleteCollectionByName, auth.RoleAdmin))
+	router.Handler(http.MethodPut, updateTimeURL, auth.RoleMiddleware(h.UpdateTime, auth.RoleService))
+	router.HandlerFunc(http.MethodDelete, userRecordsURL, auth.RoleMiddleware(h.DeleteUserRecords, auth.RoleService))
 }
 
 // Create record
@@ -47,6 +50,7 @@ func (h *Handler) Register(router *httprouter.Router) {
 // @Tags Records
 // @Success 201
 // @Failure 400
+// @Failure 403 {object} auth.AppError "the user is banned"
 // @Router /api/training [post]
 func (h *Handler) CreateRecord(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Info("POST CREATE RECORD")
@@ -152,7 +156,7 @@ func (h *Handler) GetRecordByUserId(w http.ResponseWriter, r *http.Request) erro
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
-
+// @Param friends query bool false "only records of the caller and the caller's friends"
 // @Tags Records
 // @Success 200
 // @Failure 400
@@ -168,7 +172,14 @@ func (h *Handler) GetRecords(w http.ResponseWriter, r *http.Request) error {
 		return auth.BadRequestError("invalid JSON scheme. check swagger API")
 	}
 
-	users, err := h.TrainingService.GetAll(r.Context(), dto)
+	var friends bool
+	if value := r.URL.Query().Get("friends"); value != "" {
+		if friends, err = strconv.ParseBool(value); err != nil {
+			return auth.BadRequestError("friends must be true or false")
+		}
+	}
+
+	users, err := h.TrainingService.GetAll(r.Context(), dto, friends)
 	if err != nil {
 		return err
 	}
@@ -254,6 +265,7 @@ func (h *Handler) PartiallyUpdateRecord(w http.ResponseWriter, r *http.Request)
 // @Tags Records
 // @Success 204
 // @Failure 400
+// @Failure 403 {object} auth.AppError
 // @Router /api/training [delete]
 func (h *Handler) DeleteRecord(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Info("DELETE RECORD")
@@ -274,6 +286,27 @@ func (h *Handler) DeleteRecord(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+// Delete records of user
+// @Summary Delete records of the user from every table. Called by user service when the account is deleted
+// @Produce json
+// @Param id path string true "User ID"
+// @Tags Records
+// @Success 204
+// @Failure 403 {object} auth.AppError
+// @Router /api/training/users/{id} [delete]
+func (h *Handler) DeleteUserRecords(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("DELETE USER RECORDS")
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	err := h.TrainingService.DeleteByUserID(r.Context(), params.ByName("id"))
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
 // Create collection
 // @Summary Create collection endpoint. Needs accept token
 // @Accept json
@@ -294,8 +327,7 @@ func (h *Handler) CreateCollection(w http.ResponseWriter, r *http.Request) error
 	if err != nil {
 		return auth.BadRequestError("invalid JSON scheme. check swagger API")
 	}
-	ctx := context.WithValue(r.Context(), "Authorization", r.Header.Get("Authorization"))
-	err = h.TrainingService.CreateCollection(ctx, dto)
+	err = h.TrainingService.CreateCollection(r.Context(), dto)
 	if err != nil {
 		return err
 	}
@@ -312,6 +344,7 @@ func (h *Handler) CreateCollection(w http.ResponseWriter, r *http.Request) error
 // @Tags Collections
 // @Success 204
 // @Failure 400
+// @Failure 403 {object} auth.AppError
 // @Router /api/training/collections [delete]
 func (h *Handler) DeleteCollectionByName(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Info("DELETE COLLECTION")
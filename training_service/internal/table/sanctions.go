@@ -0,0 +1,116 @@
+package table
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+	"training_service/internal/auth"
+	"training_service/pkg/logging"
+	"training_service/pkg/servicetoken"
+)
+
+// SanctionStatus is the part of active sanctions of the user which the table checks
+type SanctionStatus struct {
+	Banned  bool   `json:"banned"`
+	Message string `json:"message"`
+}
+
+// ShadowBannedDTO lists users whose results are hidden from leaderboards
+type ShadowBannedDTO struct {
+	UserIDs []string `json:"user_ids"`
+}
+
+// checkBan returns BannedError if the user is banned, banned users can't add records
+func checkBan(ctx context.Context, userID string) error {
+	var status SanctionStatus
+	err := getUserService(ctx, sanctionsURL+url.PathEscape(userID), &status)
+	if err != nil {
+		return fmt.Errorf("failed to check sanctions of user due to: %v", err)
+	}
+	if status.Banned {
+		return auth.BannedError(status.Message)
+	}
+	return nil
+}
+
+// shadowBans caches ids of shadow banned users for leaderboards. If user service is unavailable
+// the last list is used, so leaderboards keep working
+type shadowBans struct {
+	mu        sync.Mutex
+	ids       map[string]struct{}
+	fetchedAt time.Time
+	logger    logging.Logger
+}
+
+func newShadowBans(logger logging.Logger) *shadowBans {
+	return &shadowBans{
+		ids:    map[string]struct{}{},
+		logger: logger,
+	}
+}
+
+func (b *shadowBans) list(ctx context.Context) map[string]struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if time.Since(b.fetchedAt) < shadowBansTTL {
+		return b.ids
+	}
+	// failed fetch isn't repeated until ttl passes
+	b.fetchedAt = time.Now()
+
+	var dto ShadowBannedDTO
+	if err := getUserService(ctx, shadowBannedURL, &dto); err != nil {
+		b.logger.Errorf("failed to get shadow banned users due to: %v", err)
+		return b.ids
+	}
+	ids := make(map[string]struct{}, len(dto.UserIDs))
+	for _, id := range dto.UserIDs {
+		ids[id] = struct{}{}
+	}
+	b.ids = ids
+	return b.ids
+}
+
+// hide removes records of shadow banned users, except the viewer's own record, so the user doesn't notice the ban
+func (b *shadowBans) hide(ctx context.Context, records []Record, viewerID string) []Record {
+	ids := b.list(ctx)
+	if len(ids) == 0 {
+		return records
+	}
+	visible := make([]Record, 0, len(records))
+	for _, record := range records {
+		if _, hidden := ids[record.UserID]; hidden && record.UserID != viewerID {
+			continue
+		}
+		visible = append(visible, record)
+	}
+	return visible
+}
+
+func getUserService(ctx context.Context, u string, v interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("failed to make request due to: %v", err)
+	}
+	err = servicetoken.Authorize(ctx, request)
+	if err != nil {
+		return fmt.Errorf("failed to authorize request due to: %v", err)
+	}
+	client := http.Client{Timeout: userServiceTimeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to do request due to: %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("user service returned wrong status code: %d", response.StatusCode)
+	}
+	if err := json.NewDecoder(response.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response body due to: %v", err)
+	}
+	return nil
+}
@@ -13,19 +13,22 @@ import (
 	"training_service/internal/auth"
 	"training_service/internal/config"
 	"training_service/pkg/logging"
+	"training_service/pkg/servicetoken"
 )
 
 var _ Service = &service{}
 
 type service struct {
-	storage Storage
-	logger  logging.Logger
+	storage    Storage
+	shadowBans *shadowBans
+	logger     logging.Logger
 }
 
 func NewService(userStorage Storage, logger logging.Logger) (Service, error) {
 	return &service{
-		storage: userStorage,
-		logger:  logger,
+		storage:    userStorage,
+		shadowBans: newShadowBans(logger),
+		logger:     logger,
 	}, nil
 }
 
@@ -33,11 +36,12 @@ type Service interface {
 	Create(ctx context.Context, dto RecordDTO) (string, error)
 	CreateCollection(ctx context.Context, dto CollectionDTO) error
 	DeleteCollection(ctx context.Context, dto CollectionDTO) error
-	GetAll(ctx context.Context, dto RecordDTO) ([]Record, error)
+	GetAll(ctx context.Context, dto RecordDTO, friends bool) ([]Record, error)
 	GetCollectionNames(ctx context.Context) ([]Collection, error)
 	GetById(ctx context.Context, dto RecordDTO) (Record, error)
 	GetByUserId(ctx context.Context, dto RecordDTO) (u Record, err error)
 	Delete(ctx context.Context, dto RecordDTO) error
+	DeleteByUserID(ctx context.Context, userID string) error
 	Update(ctx context.Context, dto RecordDTO) error
 	UpdateTime(ctx context.Context, tableName string) (int64, error)
 }
@@ -69,6 +73,13 @@ func (s service) NotifyManager(ctx context.Context, gameType string, startTime i
 	}
 	body := io.NopCloser(strings.NewReader(string(bytes)))
 	request, err := http.NewRequestWithContext(ctx, http.MethodPost, u, body)
+	if err != nil {
+		return fmt.Errorf("failed to make request due to: %v", err)
+	}
+	err = servicetoken.Authorize(ctx, request)
+	if err != nil {
+		return fmt.Errorf("failed to authorize request due to: %v", err)
+	}
 	var client http.Client
 	response, err := client.Do(request)
 	if err != nil {
@@ -91,7 +102,10 @@ func (s service) NotifyManager(ctx context.Context, gameType string, startTime i
 }
 
 func (s service) Create(ctx context.Context, dto RecordDTO) (recordID string, err error) {
-	s.logger.Debug("check password")
+	err = checkBan(ctx, dto.UserID)
+	if err != nil {
+		return recordID, err
+	}
 	recordID, err = s.storage.Create(ctx, dto)
 	if err != nil {
 		if errors.Is(err, auth.ErrNotFound) {
@@ -127,12 +141,26 @@ func (s service) GetByUserId(ctx context.Context, dto RecordDTO) (u Record, err
 	return u, nil
 }
 
-func (s service) GetAll(ctx context.Context, dto RecordDTO) ([]Record, error) {
-	users, err := s.storage.FindAll(ctx, dto)
+// GetAll returns the leaderboard, records of shadow banned users are shown only to themselves.
+// With friends only records of the viewer and the viewer's friends are returned
+func (s service) GetAll(ctx context.Context, dto RecordDTO, friends bool) ([]Record, error) {
+	var viewerID string
+	if claims, ok := auth.Claims(ctx); ok {
+		viewerID = claims.Id
+	}
+	var userIDs []string
+	if friends {
+		ids, err := friendIDs(ctx, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		userIDs = append(ids, viewerID)
+	}
+	users, err := s.storage.FindAll(ctx, dto, userIDs)
 	if err != nil {
 		return users, fmt.Errorf("failed to find records. error: %v", err)
 	}
-	return users, nil
+	return s.shadowBans.hide(ctx, users, viewerID), nil
 }
 
 func (s service) GetCollectionNames(ctx context.Context) ([]Collection, error) {
@@ -172,6 +200,16 @@ func (s service) Delete(ctx context.Context, dto RecordDTO) error {
 	return err
 }
 
+// DeleteByUserID deletes records of the user from every table
+func (s service) DeleteByUserID(ctx context.Context, userID string) error {
+	deleted, err := s.storage.DeleteByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete records of user. error: %w", err)
+	}
+	s.logger.Infof("deleted %d records of user %s", deleted, userID)
+	return nil
+}
+
 func (s service) DeleteCollection(ctx context.Context, dto CollectionDTO) error {
 	s.logger.Debug("delete collection")
 
@@ -7,9 +7,11 @@ type Storage interface {
 	CreateCollection(ctx context.Context, dto CollectionDTO) error
 	FindById(ctx context.Context, dto RecordDTO) (Record, error)
 	FindByUserId(ctx context.Context, dto RecordDTO) (Record, error)
-	FindAll(ctx context.Context, dto RecordDTO) ([]Record, error)
+	// FindAll returns records of the table, with user ids only records of these users
+	FindAll(ctx context.Context, dto RecordDTO, userIDs []string) ([]Record, error)
 	FindCollectionNames(ctx context.Context) ([]string, error)
 	Update(ctx context.Context, dto RecordDTO) error
 	Delete(ctx context.Context, dto RecordDTO) error
 	DeleteCollectionByName(ctx context.Context, dto CollectionDTO) error
+	DeleteByUserID(ctx context.Context, userID string) (int64, error)
 }
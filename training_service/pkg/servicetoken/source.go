@@ -0,0 +1,96 @@
+package servicetoken
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"training_service/internal/config"
+)
+
+const (
+	requestTimeout = 5 * time.Second
+	// expiryMargin is how long before expiration the token is renewed
+	expiryMargin = 30 * time.Second
+)
+
+var (
+	defaultSource *Source
+	defaultOnce   sync.Once
+)
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// Source gets service tokens from the auth service with client credentials and caches them until they expire
+type Source struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	client       *http.Client
+	mu           sync.Mutex
+	token        string
+	expiresAt    time.Time
+}
+
+func NewSource(tokenURL, clientID, clientSecret string) *Source {
+	return &Source{
+		tokenURL:     tokenURL,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		client:       &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Token returns cached service token or requests a new one
+func (s *Source) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && time.Now().Before(s.expiresAt.Add(-expiryMargin)) {
+		return s.token, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to build service token request due to: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.SetBasicAuth(s.clientID, s.clientSecret)
+	response, err := s.client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to request service token due to: %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get service token, status: %d", response.StatusCode)
+	}
+	var dto tokenResponse
+	if err := json.NewDecoder(response.Body).Decode(&dto); err != nil {
+		return "", fmt.Errorf("failed to decode service token response due to: %v", err)
+	}
+	s.token = dto.AccessToken
+	s.expiresAt = time.Now().Add(time.Duration(dto.ExpiresIn) * time.Second)
+	return s.token, nil
+}
+
+// Authorize sets Authorization header of the request to the service token of this service
+func Authorize(ctx context.Context, request *http.Request) error {
+	defaultOnce.Do(func() {
+		cfg := config.GetConfig()
+		defaultSource = NewSource(cfg.ServiceAuth.TokenURL, cfg.ServiceAuth.ClientID, cfg.ServiceAuth.ClientSecret)
+	})
+	token, err := defaultSource.Token(ctx)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	return nil
+}
@@ -14,6 +14,7 @@ import (
 	"path/filepath"
 	"time"
 	"user_service/internal/config"
+	"user_service/internal/notifier"
 	"user_service/internal/user"
 	"user_service/internal/user/db"
 	"user_service/pkg/client/mongodb"
@@ -36,21 +37,40 @@ func NewApp(cfg *config.Config, logger *logging.Logger) (App, error) {
 	router.Handler(http.MethodGet, "/swagger", http.RedirectHandler("/swagger/index.html", http.StatusMovedPermanently))
 	router.Handler(http.MethodGet, "/swagger/*any", httpSwagger.WrapHandler)
 
-	logger.Println("heartbeat metric initializing")
-	metricHandler := metrics.Handler{}
-	metricHandler.Register(router)
-
 	mongodbClient, err := mongodb.NewClient(context.Background(), cfg.MongoDB.Host, cfg.MongoDB.Port,
 		cfg.MongoDB.Username, cfg.MongoDB.Password, cfg.MongoDB.Database, cfg.MongoDB.AuthDB)
 	if err != nil {
 		panic(err)
 	}
 
+	logger.Println("heartbeat metric initializing")
+	metricHandler := metrics.Handler{
+		Checks: map[string]metrics.Check{
+			"mongodb": mongodb.Ping(mongodbClient),
+		},
+	}
+	metricHandler.Register(router)
+
 	storage := db.NewStorage(mongodbClient, "users", logger)
-	service, err := user.NewService(storage, *logger)
+	resetCodes := db.NewResetCodeStorage(mongodbClient, "reset_codes", logger)
+	sanctions := db.NewSanctionStorage(mongodbClient, "sanctions", logger)
+	relations := db.NewRelationStorage(mongodbClient, "relations", logger)
+	userNotifier, err := notifier.New(cfg.PasswordReset.Notifier, cfg.PasswordReset.NotifierFile, logger)
 	if err != nil {
 		panic(err)
 	}
+	resetPolicy := user.ResetPolicy{
+		CodeTTL:     cfg.PasswordReset.CodeTTL,
+		MaxAttempts: cfg.PasswordReset.MaxAttempts,
+	}
+	service, err := user.NewService(storage, resetCodes, sanctions, relations, userNotifier, cfg.AppConfig.Admins, cfg.AppConfig.BcryptCost, resetPolicy, *logger)
+	if err != nil {
+		panic(err)
+	}
+	err = service.EnsureAdmins(context.Background())
+	if err != nil {
+		logger.Error(err)
+	}
 
 	usersHandler := user.Handler{
 		Logger:      logging.GetLogger(cfg.AppConfig.LogLevel),
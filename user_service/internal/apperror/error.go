@@ -2,11 +2,20 @@ package apperror
 
 import (
 	"encoding/json"
+	"errors"
 )
 
 var (
-	ErrNotFound   = NewAppError(nil, "not found", "NS-000003", "")
-	ErrWrongToken = NewAppError(nil, "wrong token", "NS-000004", "")
+	ErrNotFound     = NewAppError(nil, "not found", "NS-000003", "")
+	ErrWrongToken   = NewAppError(nil, "wrong token", "NS-000004", "")
+	ErrUnauthorized = NewAppError(nil, "wrong username or password", "NS-000009", "")
+	ErrConflict     = NewAppError(nil, "already exists", "NS-000010", "")
+	ErrForbidden    = NewAppError(nil, "forbidden", "NS-000007", "token has no role required for the request")
+	// ErrVersionConflict is returned when the user was changed after the version in the request was read
+	ErrVersionConflict = NewAppError(nil, "version conflict", "NS-000011", "user was changed by another request, read it again")
+	ErrNoTickets       = NewAppError(nil, "no tickets", "NS-000012", "user has no tickets of the game type left or doesn't own the ticket")
+	// ErrBanned is wrapped by BannedError, which tells the reason and the end of the ban
+	ErrBanned = NewAppError(errors.New("user is banned"), "user is banned", "NS-000013", "")
 )
 
 type AppError struct {
@@ -43,6 +52,10 @@ func BadRequestError(message string) *AppError {
 	return NewAppError(nil, message, "NS-000002", "something wrong with user data")
 }
 
+func BannedError(message string) *AppError {
+	return NewAppError(ErrBanned, message, ErrBanned.Code, "")
+}
+
 func systemError(developerMessage string) *AppError {
 	return NewAppError(nil, "system error", "NS-000001", developerMessage)
 }
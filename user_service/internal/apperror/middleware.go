@@ -1,13 +1,62 @@
 package apperror
 
 import (
+	"context"
 	"errors"
 	"log"
 	"net/http"
+	"strings"
+	jwt_setup "user_service/pkg/jwt-setup"
 )
 
+const (
+	RolePlayer   = "player"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+	RoleService  = "service"
+	RoleGuest    = "guest"
+)
+
+// AccountRoles are roles of users with a full account, guests and services are not among them
+var AccountRoles = []string{RolePlayer, RoleOperator, RoleAdmin}
+
 type appHandler func(http.ResponseWriter, *http.Request) error
 
+type contextKey struct{}
+
+// Claims returns claims of the token verified by RoleMiddleware
+func Claims(ctx context.Context) (*jwt_setup.RegisteredClaims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*jwt_setup.RegisteredClaims)
+	return claims, ok
+}
+
+// RoleMiddleware verifies token and requires it to have one of the roles. Any role is accepted if none given
+func RoleMiddleware(h appHandler, roles ...string) http.HandlerFunc {
+	log.Println("got into auth middleware")
+	next := Middleware(h)
+	return func(w http.ResponseWriter, r *http.Request) {
+		headerVal := r.Header.Get("Authorization")
+		authHeaderArr := strings.Split(headerVal, " ")
+		if len(authHeaderArr) != 2 {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write(ErrWrongToken.Marshal())
+			return
+		}
+		claims, err := jwt_setup.ParseClaims(authHeaderArr[1])
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write(ErrWrongToken.Marshal())
+			return
+		}
+		if len(roles) > 0 && !claims.HasRole(roles...) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write(ErrForbidden.Marshal())
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, claims)))
+	}
+}
+
 func Middleware(h appHandler) http.HandlerFunc {
 	log.Println("got into middleware")
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -20,6 +69,26 @@ func Middleware(h appHandler) http.HandlerFunc {
 					w.Write(ErrNotFound.Marshal())
 					return
 				}
+				if errors.Is(err, ErrUnauthorized) {
+					w.WriteHeader(http.StatusUnauthorized)
+					w.Write(ErrUnauthorized.Marshal())
+					return
+				}
+				if errors.Is(err, ErrConflict) {
+					w.WriteHeader(http.StatusConflict)
+					w.Write(ErrConflict.Marshal())
+					return
+				}
+				if errors.Is(err, ErrVersionConflict) || errors.Is(err, ErrNoTickets) {
+					w.WriteHeader(http.StatusConflict)
+					w.Write(appErr.Marshal())
+					return
+				}
+				if errors.Is(err, ErrBanned) {
+					w.WriteHeader(http.StatusForbidden)
+					w.Write(appErr.Marshal())
+					return
+				}
 				err := err.(*AppError)
 				w.WriteHeader(http.StatusBadRequest)
 				w.Write(err.Marshal())
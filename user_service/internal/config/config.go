@@ -4,6 +4,7 @@ import (
 	"github.com/ilyakaznacheev/cleanenv"
 	"log"
 	"sync"
+	"time"
 )
 
 type Config struct {
@@ -17,6 +18,11 @@ type Config struct {
 	}
 	AppConfig struct {
 		LogLevel string `env:"LOG_LEVEL" env-default:"trace"`
+		// Admins are usernames of registered users which get admin role on start, so the first admin can grant
+		// roles to others
+		Admins []string `env:"ADMIN_USERNAMES"`
+		// BcryptCost is used for new password hashes, weaker hashes are rehashed on login
+		BcryptCost int `env:"BCRYPT_COST" env-default:"12"`
 	}
 	MongoDB struct {
 		Host     string `env:"HOST" env-default:"localhost"`
@@ -26,8 +32,24 @@ type Config struct {
 		Database string `env:"DATABASE" env-default:"user-service"`
 		AuthDB   string `env:"AUTH_DB"`
 	}
+	ServiceAuth struct {
+		TokenURL     string `env:"SERVICE_TOKEN_URL" env-default:"http://localhost:10001/api/auth/token"`
+		ClientID     string `env:"SERVICE_CLIENT_ID" env-default:"user_service"`
+		ClientSecret string `env:"SERVICE_CLIENT_SECRET" env-required:"true"`
+	}
+	// PasswordReset codes are single use, a code is dropped after MaxAttempts wrong guesses.
+	// Codes are delivered by Notifier: log writes them to the log, file appends them to NotifierFile
+	PasswordReset struct {
+		CodeTTL      time.Duration `env:"RESET_CODE_TTL" env-default:"15m"`
+		MaxAttempts  int           `env:"RESET_CODE_MAX_ATTEMPTS" env-default:"5"`
+		Notifier     string        `env:"NOTIFIER" env-default:"log"`
+		NotifierFile string        `env:"NOTIFIER_FILE" env-default:"notifications.log"`
+	}
 	Keys struct {
-		JWTSignKey string `env:"JWT_SIGN_KEY" env-default:"alsfjak12h4i1h2uas7f7241231o1u2io5u12asopua0w9812"`
+		JWKSURL string `env:"JWKS_URL" env-default:"http://localhost:10001/.well-known/jwks.json"`
+		// RevocationsURL is polled every RevocationsInterval, revoked tokens are rejected
+		RevocationsURL      string        `env:"REVOCATIONS_URL" env-default:"http://localhost:10001/api/auth/revocations"`
+		RevocationsInterval time.Duration `env:"REVOCATIONS_REFRESH_INTERVAL" env-default:"10s"`
 	}
 }
 
@@ -46,6 +68,9 @@ func GetConfig() *Config {
 			log.Println(help)
 			log.Fatal(err)
 		}
+		if instance.ServiceAuth.ClientSecret == "" {
+			log.Fatal("SERVICE_CLIENT_SECRET is empty")
+		}
 	})
 	return instance
 }
@@ -0,0 +1,82 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+	"user_service/pkg/logging"
+)
+
+const (
+	TypeLog  = "log"
+	TypeFile = "file"
+)
+
+// Message is addressed to the user, a real sink (email, push) finds the contact by user id
+type Message struct {
+	UserID   string    `json:"user_id"`
+	Username string    `json:"username"`
+	Subject  string    `json:"subject"`
+	Text     string    `json:"text"`
+	SentAt   time.Time `json:"sent_at"`
+}
+
+// Notifier delivers messages to users
+type Notifier interface {
+	Notify(ctx context.Context, message Message) error
+}
+
+// New returns notifier of the type: log or file
+func New(notifierType, file string, logger *logging.Logger) (Notifier, error) {
+	switch notifierType {
+	case TypeLog:
+		return &logNotifier{logger: logger}, nil
+	case TypeFile:
+		return &fileNotifier{path: file}, nil
+	}
+	return nil, fmt.Errorf("unknown notifier type: %s", notifierType)
+}
+
+// logNotifier writes messages to the log, for local testing only
+type logNotifier struct {
+	logger *logging.Logger
+}
+
+func (n *logNotifier) Notify(ctx context.Context, message Message) error {
+	n.logger.ExtraFields(map[string]interface{}{
+		"notification": message.Subject,
+		"user_id":      message.UserID,
+	}).Infof("to %s: %s", message.Username, message.Text)
+	return nil
+}
+
+// fileNotifier appends messages to the file as JSON lines
+type fileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func (n *fileNotifier) Notify(ctx context.Context, message Message) error {
+	if message.SentAt.IsZero() {
+		message.SentAt = time.Now()
+	}
+	line, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification due to: %v", err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	file, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open notifications file due to: %v", err)
+	}
+	defer file.Close()
+	if _, err = file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write notification due to: %v", err)
+	}
+	return nil
+}
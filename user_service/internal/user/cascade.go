@@ -0,0 +1,45 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+	"user_service/pkg/servicetoken"
+)
+
+const cascadeTimeout = 5 * time.Second
+
+// userDataURLs are called in order when the account is deleted, deleting data of a user without it succeeds
+var userDataURLs = []string{
+	deleteUserTicketsURL,
+	deleteUserQualificationsURL,
+	deleteUserTrainingURL,
+}
+
+// deleteUserData deletes tickets and records of the user in other services with the service token.
+// It stops at the first failure, the account is kept so the deletion can be repeated
+func deleteUserData(ctx context.Context, userID string) error {
+	client := http.Client{Timeout: cascadeTimeout}
+	for _, u := range userDataURLs {
+		request, err := http.NewRequestWithContext(ctx, http.MethodDelete, u+userID, nil)
+		if err != nil {
+			return fmt.Errorf("failed to make request due to: %v", err)
+		}
+		err = servicetoken.Authorize(ctx, request)
+		if err != nil {
+			return fmt.Errorf("failed to authorize request due to: %v", err)
+		}
+		response, err := client.Do(request)
+		if err != nil {
+			return fmt.Errorf("failed to delete data of user at %s due to: %v", u, err)
+		}
+		bytes, _ := io.ReadAll(io.LimitReader(response.Body, 4096))
+		response.Body.Close()
+		if response.StatusCode != http.StatusNoContent {
+			return fmt.Errorf("failed to delete data of user at %s, status: %d, response body: %s", u, response.StatusCode, string(bytes))
+		}
+	}
+	return nil
+}
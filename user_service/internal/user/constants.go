@@ -0,0 +1,18 @@
+package user
+
+// Services that keep data of the user, DELETE with the user id appended deletes it
+const (
+	deleteUserTicketsURL        = "http://localhost:10004/api/tickets/users/"
+	deleteUserQualificationsURL = "http://localhost:10011/api/qualifications/users/"
+	deleteUserTrainingURL       = "http://localhost:10003/api/training/users/"
+)
+
+// Services that aggregate stats of the user, GET with the user id appended
+const (
+	snakeStatsURL          = "http://localhost:10008/api/snake/stats/"
+	quizStatsURL           = "http://localhost:10009/api/quiz/stats/"
+	qualificationsStatsURL = "http://localhost:10011/api/qualifications/stats/"
+)
+
+// revokeTokensURL revokes access and refresh tokens of the banned user or the user who changed the password
+const revokeTokensURL = "http://localhost:10001/api/auth/revoke"
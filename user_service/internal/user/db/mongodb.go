@@ -7,8 +7,12 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+	"user_service/internal/apperror"
 	"user_service/internal/user"
 	"user_service/pkg/logging"
+	"user_service/pkg/pagination"
 )
 
 type db struct {
@@ -20,6 +24,9 @@ func (d *db) Create(ctx context.Context, user user.User) (string, error) {
 	d.logger.Debug("create user")
 	result, err := d.collection.InsertOne(ctx, user)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return "", apperror.ErrConflict
+		}
 		return "", fmt.Errorf("failed to create user due to: %v", err)
 	}
 	oid, ok := result.InsertedID.(primitive.ObjectID)
@@ -31,13 +38,14 @@ func (d *db) Create(ctx context.Context, user user.User) (string, error) {
 func (d *db) FindById(ctx context.Context, id string) (u user.User, err error) {
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return u, fmt.Errorf("failed to convert hex to objectID, hex: %s", id)
+		// ids come from paths of the public API, malformed id is an unknown user
+		return u, apperror.ErrNotFound
 	}
 	filter := bson.M{"_id": oid}
 	result := d.collection.FindOne(ctx, filter)
 	if result.Err() != nil {
 		if errors.Is(result.Err(), mongo.ErrNoDocuments) {
-			// TODO ErrEntityNotFound
+			return u, apperror.ErrNotFound
 		}
 		return u, fmt.Errorf("failed to find one user by id: %s due to error: %v", id, result.Err())
 	}
@@ -53,7 +61,7 @@ func (d *db) FindByUsername(ctx context.Context, username string) (u user.User,
 	result := d.collection.FindOne(ctx, filter)
 	if result.Err() != nil {
 		if errors.Is(result.Err(), mongo.ErrNoDocuments) {
-			// TODO ErrEntityNotFound
+			return u, apperror.ErrNotFound
 		}
 		return u, fmt.Errorf("failed to find one user by username: %s due to error: %v", username, result.Err())
 	}
@@ -63,24 +71,54 @@ func (d *db) FindByUsername(ctx context.Context, username string) (u user.User,
 	return u, nil
 }
 
-func (d *db) FindAll(ctx context.Context) (users []user.User, err error) {
-	cursor, err := d.collection.Find(ctx, bson.M{})
-	if cursor.Err() != nil {
-		return users, fmt.Errorf("failed to find all users due to: %v", cursor.Err())
+func (d *db) FindPage(ctx context.Context, filter user.Filter, params pagination.Params) (users []user.User, err error) {
+	query := bson.M{}
+	if filter.Role != "" {
+		query["roles"] = filter.Role
+	}
+	if filter.Country != "" {
+		query["country"] = filter.Country
+	}
+	if filter.IsGuest != nil {
+		query["is_guest"] = *filter.IsGuest
+	}
+	cursor, err := d.collection.Find(ctx, params.Filter(query), params.Options())
+	if err != nil {
+		return users, fmt.Errorf("failed to find users due to: %v", err)
 	}
 	if err := cursor.All(ctx, &users); err != nil {
-		return users, fmt.Errorf("failed to read all documents from cursor")
+		return users, fmt.Errorf("failed to read all documents from cursor due to: %v", err)
 	}
 	return users, nil
 }
 
-func (d *db) Update(ctx context.Context, user user.User) error {
+func (d *db) FindByIDs(ctx context.Context, ids []string) (users []user.User, err error) {
+	oids := make(bson.A, 0, len(ids))
+	for _, id := range ids {
+		if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+			oids = append(oids, oid)
+		}
+	}
+	if len(oids) == 0 {
+		return users, nil
+	}
+	cursor, err := d.collection.Find(ctx, bson.M{"_id": bson.M{"$in": oids}})
+	if err != nil {
+		return users, fmt.Errorf("failed to find users due to: %v", err)
+	}
+	if err := cursor.All(ctx, &users); err != nil {
+		return users, fmt.Errorf("failed to read all documents from cursor due to: %v", err)
+	}
+	return users, nil
+}
+
+func (d *db) Update(ctx context.Context, user user.User, version *int64) error {
 	objectID, err := primitive.ObjectIDFromHex(user.ID)
 	if err != nil {
 		return fmt.Errorf("failed to convert user ID to ObjectID. ID=%v", user.ID)
 	}
 
-	filter := bson.M{"_id": objectID}
+	filter := withVersion(bson.M{"_id": objectID}, version)
 
 	userBytes, err := bson.Marshal(user)
 	if err != nil {
@@ -93,8 +131,17 @@ func (d *db) Update(ctx context.Context, user user.User) error {
 		return fmt.Errorf("failed to unmarshal user bytes due to: %v", err)
 	}
 	delete(updateUserObj, "_id")
+	// password, roles, guest flag and profile have their own update paths
+	delete(updateUserObj, "password")
+	delete(updateUserObj, "roles")
+	delete(updateUserObj, "is_guest")
+	delete(updateUserObj, "display_name")
+	delete(updateUserObj, "avatar_url")
+	delete(updateUserObj, "country")
+	delete(updateUserObj, "version")
 	update := bson.M{
 		"$set": updateUserObj,
+		"$inc": bson.M{"version": 1},
 	}
 	result, err := d.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
@@ -102,12 +149,97 @@ func (d *db) Update(ctx context.Context, user user.User) error {
 	}
 
 	if result.MatchedCount == 0 {
-		// TODO ErrEntityNotFound
-		return fmt.Errorf("not found")
+		return d.notMatched(ctx, objectID, version, apperror.ErrVersionConflict)
+	}
+
+	return nil
+}
+func (d *db) SetRoles(ctx context.Context, id string, roles []string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("failed to convert user ID to ObjectID. ID=%v", id)
+	}
+
+	filter := bson.M{"_id": objectID}
+	update := bson.M{
+		"$set": bson.M{"roles": roles},
+	}
+	result, err := d.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to execute update user roles query due to: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
+func (d *db) SetPassword(ctx context.Context, id string, hash string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("failed to convert user ID to ObjectID. ID=%v", id)
+	}
+
+	filter := bson.M{"_id": objectID}
+	update := bson.M{
+		"$set": bson.M{"password": hash},
+	}
+	result, err := d.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to execute update user password query due to: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return apperror.ErrNotFound
 	}
+	return nil
+}
 
+func (d *db) SetProfile(ctx context.Context, id string, displayName, avatarURL, country string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("failed to convert user ID to ObjectID. ID=%v", id)
+	}
+
+	filter := bson.M{"_id": objectID}
+	update := bson.M{
+		"$set": bson.M{
+			"display_name": displayName,
+			"avatar_url":   avatarURL,
+			"country":      country,
+		},
+	}
+	result, err := d.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to execute update user profile query due to: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
+// SetLastSeen skips the write if last seen is newer than the time minus precision
+func (d *db) SetLastSeen(ctx context.Context, id string, at time.Time, precision time.Duration) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("failed to convert user ID to ObjectID. ID=%v", id)
+	}
+
+	filter := bson.M{
+		"_id": objectID,
+		"$or": bson.A{
+			bson.M{"last_seen": bson.M{"$exists": false}},
+			bson.M{"last_seen": bson.M{"$lt": at.Add(-precision)}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"last_seen": at}}
+	_, err = d.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to execute update last seen query due to: %v", err)
+	}
 	return nil
 }
+
 func (d *db) Delete(ctx context.Context, id string) error {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -122,17 +254,144 @@ func (d *db) Delete(ctx context.Context, id string) error {
 	}
 
 	if result.DeletedCount == 0 {
-		// TODO ErrEntityNotFound
-		return fmt.Errorf("not found")
+		return apperror.ErrNotFound
 	}
 	d.logger.Tracef("Deleted %d documents", result.DeletedCount)
 	return nil
 }
 
-func NewStorage(database *mongo.Database, collection string, logger *logging.Logger) user.Storage {
+func (d *db) FindGuestByDeviceID(ctx context.Context, deviceID string) (u user.User, err error) {
+	filter := bson.M{"device_id": deviceID, "is_guest": true}
+	result := d.collection.FindOne(ctx, filter)
+	if result.Err() != nil {
+		if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+			return u, apperror.ErrNotFound
+		}
+		return u, fmt.Errorf("failed to find guest by device id due to error: %v", result.Err())
+	}
+	if err = result.Decode(&u); err != nil {
+		return u, fmt.Errorf("failed to decode guest from DB due to error: %v", err)
+	}
+	return u, nil
+}
+
+func (d *db) SetDeviceSecret(ctx context.Context, id, hash string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("failed to convert user ID to ObjectID. ID=%v", id)
+	}
+
+	filter := bson.M{"_id": objectID, "is_guest": true, "device_secret": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"device_secret": hash}}
+	result, err := d.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to execute set device secret query due to: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return apperror.ErrConflict
+	}
+	return nil
+}
+
+// Claim turns guest into a full account, other fields (tickets, free ticket) are kept.
+// Device id is removed, so the device can get a new guest account
+func (d *db) Claim(ctx context.Context, id string, username, hash string, roles []string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("failed to convert user ID to ObjectID. ID=%v", id)
+	}
+
+	filter := bson.M{"_id": objectID, "is_guest": true}
+	update := bson.M{
+		"$set": bson.M{
+			"username": username,
+			"password": hash,
+			"roles":    roles,
+			"is_guest": false,
+		},
+		"$unset": bson.M{"device_id": "", "device_secret": ""},
+	}
+	result, err := d.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return apperror.ErrConflict
+		}
+		return fmt.Errorf("failed to execute claim guest query due to: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
 
-	return &db{
+func (d *db) FindByIdentity(ctx context.Context, identity user.Identity) (u user.User, err error) {
+	filter := bson.M{"identities": bson.M{"$elemMatch": bson.M{"provider": identity.Provider, "subject": identity.Subject}}}
+	result := d.collection.FindOne(ctx, filter)
+	if result.Err() != nil {
+		if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+			return u, apperror.ErrNotFound
+		}
+		return u, fmt.Errorf("failed to find user by identity due to error: %v", result.Err())
+	}
+	if err = result.Decode(&u); err != nil {
+		return u, fmt.Errorf("failed to decode user from DB due to error: %v", err)
+	}
+	return u, nil
+}
+
+func (d *db) AddIdentity(ctx context.Context, id string, identity user.Identity) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("failed to convert user ID to ObjectID. ID=%v", id)
+	}
+
+	filter := bson.M{"_id": objectID}
+	update := bson.M{
+		"$addToSet": bson.M{"identities": identity},
+	}
+	result, err := d.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return apperror.ErrConflict
+		}
+		return fmt.Errorf("failed to execute add identity query due to: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
+// NewStorage creates unique index on username, so two users can't sign up with the same username concurrently.
+// Device id is unique too, a device has at most one guest account. Identity of a provider belongs to one user
+func NewStorage(database *mongo.Database, collection string, logger *logging.Logger) user.Storage {
+	d := &db{
 		collection: database.Collection(collection),
 		logger:     logger,
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := d.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "username", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		logger.Errorf("failed to create unique index on username due to: %v", err)
+	}
+	_, err = d.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "device_id", Value: 1}},
+		Options: options.Index().SetUnique(true).SetSparse(true),
+	})
+	if err != nil {
+		logger.Errorf("failed to create unique index on device id due to: %v", err)
+	}
+	_, err = d.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "identities.provider", Value: 1}, {Key: "identities.subject", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"identities": bson.M{"$exists": true}}),
+	})
+	if err != nil {
+		logger.Errorf("failed to create unique index on identities due to: %v", err)
+	}
+	return d
 }
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+	"user_service/internal/apperror"
+	"user_service/internal/user"
+	"user_service/pkg/logging"
+)
+
+type relations struct {
+	collection *mongo.Collection
+	logger     *logging.Logger
+}
+
+func (d *relations) Create(ctx context.Context, relation user.Relation) error {
+	_, err := d.collection.InsertOne(ctx, relation)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return apperror.ErrConflict
+		}
+		return fmt.Errorf("failed to create relation due to: %v", err)
+	}
+	return nil
+}
+
+func (d *relations) FindBetween(ctx context.Context, userID, otherID string) ([]user.Relation, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"from": userID, "to": otherID},
+		bson.M{"from": otherID, "to": userID},
+	}}
+	return d.find(ctx, filter)
+}
+
+func (d *relations) FindOf(ctx context.Context, userID, status string) ([]user.Relation, error) {
+	filter := bson.M{
+		"status": status,
+		"$or":    bson.A{bson.M{"from": userID}, bson.M{"to": userID}},
+	}
+	return d.find(ctx, filter)
+}
+
+func (d *relations) SetStatus(ctx context.Context, from, to, status, newStatus string, at time.Time) error {
+	filter := bson.M{"from": from, "to": to, "status": status}
+	update := bson.M{"$set": bson.M{"status": newStatus, "updated_at": at}}
+	result, err := d.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update relation due to: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
+func (d *relations) Delete(ctx context.Context, from, to string, statuses ...string) error {
+	filter := bson.M{"from": from, "to": to, "status": bson.M{"$in": statuses}}
+	result, err := d.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete relation due to: %v", err)
+	}
+	if result.DeletedCount == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
+func (d *relations) DeleteByUserID(ctx context.Context, userID string) error {
+	filter := bson.M{"$or": bson.A{bson.M{"from": userID}, bson.M{"to": userID}}}
+	_, err := d.collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete relations of user %s due to: %v", userID, err)
+	}
+	return nil
+}
+
+func (d *relations) find(ctx context.Context, filter bson.M) (list []user.Relation, err error) {
+	opts := options.Find().SetSort(bson.D{{Key: "updated_at", Value: -1}})
+	cursor, err := d.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find relations due to: %v", err)
+	}
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, fmt.Errorf("failed to read all documents from cursor due to: %v", err)
+	}
+	return list, nil
+}
+
+// NewRelationStorage creates unique index on the pair of users, so a request can't be sent twice,
+// and index on the addressee for incoming relations
+func NewRelationStorage(database *mongo.Database, collection string, logger *logging.Logger) user.RelationStorage {
+	d := &relations{
+		collection: database.Collection(collection),
+		logger:     logger,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := d.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "from", Value: 1}, {Key: "to", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		logger.Errorf("failed to create unique index on relations due to: %v", err)
+	}
+	_, err = d.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "to", Value: 1}, {Key: "status", Value: 1}},
+	})
+	if err != nil {
+		logger.Errorf("failed to create index on relations due to: %v", err)
+	}
+	return d
+}
@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+	"user_service/internal/apperror"
+	"user_service/internal/user"
+	"user_service/pkg/logging"
+)
+
+type resetCodes struct {
+	collection *mongo.Collection
+	logger     *logging.Logger
+}
+
+func (d *resetCodes) Replace(ctx context.Context, code user.ResetCode) error {
+	filter := bson.M{"user_id": code.UserID}
+	_, err := d.collection.ReplaceOne(ctx, filter, code, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to save reset code due to: %v", err)
+	}
+	return nil
+}
+
+// Take deletes the code in the same query that checks it, so the code can be used once
+func (d *resetCodes) Take(ctx context.Context, userID, hash string) error {
+	filter := bson.M{
+		"user_id":   userID,
+		"hash":      hash,
+		"expire_at": bson.M{"$gt": time.Now()},
+	}
+	result := d.collection.FindOneAndDelete(ctx, filter)
+	if result.Err() != nil {
+		if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+			return apperror.ErrNotFound
+		}
+		return fmt.Errorf("failed to take reset code due to: %v", result.Err())
+	}
+	return nil
+}
+
+func (d *resetCodes) Fail(ctx context.Context, userID string, maxAttempts int) error {
+	filter := bson.M{"user_id": userID}
+	_, err := d.collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"attempts": 1}})
+	if err != nil {
+		return fmt.Errorf("failed to count reset code attempt due to: %v", err)
+	}
+	filter = bson.M{"user_id": userID, "attempts": bson.M{"$gte": maxAttempts}}
+	_, err = d.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete reset code due to: %v", err)
+	}
+	return nil
+}
+
+func (d *resetCodes) DeleteByUserID(ctx context.Context, userID string) error {
+	_, err := d.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return fmt.Errorf("failed to delete reset codes of user %s due to: %v", userID, err)
+	}
+	return nil
+}
+
+// NewResetCodeStorage creates unique index on user id, so the user has one code at a time,
+// and TTL index, so expired codes are removed
+func NewResetCodeStorage(database *mongo.Database, collection string, logger *logging.Logger) user.ResetCodeStorage {
+	d := &resetCodes{
+		collection: database.Collection(collection),
+		logger:     logger,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := d.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		logger.Errorf("failed to create unique index on reset codes due to: %v", err)
+	}
+	_, err = d.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expire_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		logger.Errorf("failed to create ttl index on reset codes due to: %v", err)
+	}
+	return d
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+	"user_service/internal/apperror"
+	"user_service/internal/user"
+	"user_service/pkg/logging"
+)
+
+type sanctions struct {
+	collection *mongo.Collection
+	logger     *logging.Logger
+}
+
+// activeAt matches sanctions which are not lifted and not expired
+func activeAt(now time.Time) bson.M {
+	return bson.M{
+		"lifted_at": nil,
+		"$or": bson.A{
+			bson.M{"expire_at": nil},
+			bson.M{"expire_at": bson.M{"$gt": now}},
+		},
+	}
+}
+
+func (d *sanctions) Create(ctx context.Context, sanction user.Sanction) (string, error) {
+	result, err := d.collection.InsertOne(ctx, sanction)
+	if err != nil {
+		return "", fmt.Errorf("failed to create sanction due to: %v", err)
+	}
+	oid, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return "", fmt.Errorf("failed to convert objectId to hex. probable oid: %v", result.InsertedID)
+	}
+	return oid.Hex(), nil
+}
+
+func (d *sanctions) FindByUserID(ctx context.Context, userID string) (list []user.Sanction, err error) {
+	opts := options.Find().SetSort(bson.D{{Key: "issued_at", Value: -1}})
+	return d.find(ctx, bson.M{"user_id": userID}, opts)
+}
+
+func (d *sanctions) FindActive(ctx context.Context, userID string, now time.Time) ([]user.Sanction, error) {
+	filter := activeAt(now)
+	filter["user_id"] = userID
+	return d.find(ctx, filter, options.Find())
+}
+
+func (d *sanctions) FindShadowBanned(ctx context.Context, now time.Time) ([]string, error) {
+	filter := activeAt(now)
+	filter["type"] = user.SanctionShadowBan
+	ids, err := d.collection.Distinct(ctx, "user_id", filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find shadow banned users due to: %v", err)
+	}
+	userIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if s, ok := id.(string); ok {
+			userIDs = append(userIDs, s)
+		}
+	}
+	return userIDs, nil
+}
+
+func (d *sanctions) Lift(ctx context.Context, userID, id, liftedBy string, at time.Time) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return apperror.ErrNotFound
+	}
+	filter := activeAt(at)
+	filter["_id"] = oid
+	filter["user_id"] = userID
+	update := bson.M{"$set": bson.M{"lifted_by": liftedBy, "lifted_at": at}}
+	result, err := d.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to lift sanction due to: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
+func (d *sanctions) find(ctx context.Context, filter bson.M, opts *options.FindOptions) (list []user.Sanction, err error) {
+	cursor, err := d.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find sanctions due to: %v", err)
+	}
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, fmt.Errorf("failed to read all documents from cursor due to: %v", err)
+	}
+	return list, nil
+}
+
+// NewSanctionStorage creates index on user id, sanctions are looked up on every sign in
+func NewSanctionStorage(database *mongo.Database, collection string, logger *logging.Logger) user.SanctionStorage {
+	d := &sanctions{
+		collection: database.Collection(collection),
+		logger:     logger,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := d.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "issued_at", Value: -1}},
+	})
+	if err != nil {
+		logger.Errorf("failed to create index on sanctions due to: %v", err)
+	}
+	return d
+}
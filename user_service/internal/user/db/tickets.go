@@ -0,0 +1,161 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"user_service/internal/apperror"
+	"user_service/internal/user"
+)
+
+// fields of user.GameTickets, it has no bson tags
+const (
+	gameTypeField = "gametype"
+	amountField   = "amount"
+	ticketsField  = "idsofgt"
+)
+
+// withVersion adds the version to the filter, users created before versions were stored have none, it is 0
+func withVersion(filter bson.M, version *int64) bson.M {
+	if version == nil {
+		return filter
+	}
+	if *version == 0 {
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	} else {
+		filter["version"] = *version
+	}
+	return filter
+}
+
+func (d *db) GrantTicket(ctx context.Context, id, gameType, ticketID string, version *int64) (u user.User, err error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return u, apperror.ErrNotFound
+	}
+
+	// the second attempt is for the case when a concurrent grant added the first ticket of the game type
+	for attempt := 0; attempt < 2; attempt++ {
+		u, err = d.pushTicket(ctx, oid, gameType, ticketID, version)
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return u, err
+		}
+		u, err = d.pushGameTickets(ctx, oid, gameType, ticketID, version)
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return u, err
+		}
+	}
+	return u, d.notMatched(ctx, oid, version, apperror.ErrConflict)
+}
+
+// pushTicket adds the ticket if the user has tickets of the game type and doesn't have this one
+func (d *db) pushTicket(ctx context.Context, oid primitive.ObjectID, gameType, ticketID string, version *int64) (user.User, error) {
+	filter := withVersion(bson.M{
+		"_id":                      oid,
+		"tickets." + gameTypeField: gameType,
+		"tickets." + ticketsField:  bson.M{"$ne": ticketID},
+	}, version)
+	update := bson.M{
+		"$inc":  bson.M{"tickets.$[gt]." + amountField: 1, "version": 1},
+		"$push": bson.M{"tickets.$[gt]." + ticketsField: ticketID},
+	}
+	opts := options.FindOneAndUpdate().
+		SetArrayFilters(options.ArrayFilters{Filters: bson.A{bson.M{"gt." + gameTypeField: gameType}}}).
+		SetReturnDocument(options.After)
+	return d.findOneAndUpdate(ctx, filter, update, opts)
+}
+
+// pushGameTickets adds the first ticket of the game type, tickets of old users may be null
+func (d *db) pushGameTickets(ctx context.Context, oid primitive.ObjectID, gameType, ticketID string, version *int64) (user.User, error) {
+	filter := withVersion(bson.M{
+		"_id":                      oid,
+		"tickets." + gameTypeField: bson.M{"$ne": gameType},
+	}, version)
+	gameTickets := user.GameTickets{GameType: gameType, Amount: 1, IDsOfGT: []string{ticketID}}
+	pipeline := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"tickets": bson.M{"$concatArrays": bson.A{
+			bson.M{"$ifNull": bson.A{"$tickets", bson.A{}}},
+			bson.A{bson.M{"$literal": gameTickets}},
+		}},
+		"version": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$version", 0}}, 1}},
+	}}}}
+	return d.findOneAndUpdate(ctx, filter, pipeline, options.FindOneAndUpdate().SetReturnDocument(options.After))
+}
+
+func (d *db) ConsumeTicket(ctx context.Context, id, gameType, ticketID string, version *int64) (u user.User, err error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return u, apperror.ErrNotFound
+	}
+
+	match := bson.M{gameTypeField: gameType, amountField: bson.M{"$gt": 0}}
+	if ticketID != "" {
+		match[ticketsField] = ticketID
+	}
+	filter := withVersion(bson.M{"_id": oid, "tickets": bson.M{"$elemMatch": match}}, version)
+	update := bson.M{"$inc": bson.M{"tickets.$." + amountField: -1, "version": 1}}
+	if ticketID != "" {
+		// the consumed ticket isn't owned anymore, granting it again refunds it
+		update["$pull"] = bson.M{"tickets.$." + ticketsField: ticketID}
+	}
+	u, err = d.findOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return u, err
+	}
+	return u, d.notMatched(ctx, oid, version, apperror.ErrNoTickets)
+}
+
+func (d *db) RemoveTicket(ctx context.Context, id, gameType, ticketID string, version *int64) (u user.User, err error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return u, apperror.ErrNotFound
+	}
+
+	match := bson.M{gameTypeField: gameType, ticketsField: ticketID}
+	filter := withVersion(bson.M{"_id": oid, "tickets": bson.M{"$elemMatch": match}}, version)
+	update := bson.M{
+		"$inc":  bson.M{"tickets.$." + amountField: -1, "version": 1},
+		"$pull": bson.M{"tickets.$." + ticketsField: ticketID},
+	}
+	u, err = d.findOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return u, err
+	}
+	return u, d.notMatched(ctx, oid, version, apperror.ErrNoTickets)
+}
+
+// findOneAndUpdate returns mongo.ErrNoDocuments as is, so the caller can find out why nothing matched
+func (d *db) findOneAndUpdate(ctx context.Context, filter, update interface{}, opts *options.FindOneAndUpdateOptions) (u user.User, err error) {
+	result := d.collection.FindOneAndUpdate(ctx, filter, update, opts)
+	if err = result.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return u, err
+		}
+		return u, fmt.Errorf("failed to update tickets of user due to: %v", err)
+	}
+	if err = result.Decode(&u); err != nil {
+		return u, fmt.Errorf("failed to decode user from DB due to: %v", err)
+	}
+	return u, nil
+}
+
+// notMatched tells why the update matched no user: there is no such user,
+// the user has another version or the operation isn't possible (conflict)
+func (d *db) notMatched(ctx context.Context, oid primitive.ObjectID, version *int64, conflict error) error {
+	var u user.User
+	err := d.collection.FindOne(ctx, bson.M{"_id": oid}).Decode(&u)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrNotFound
+		}
+		return fmt.Errorf("failed to find user by id: %s due to error: %v", oid.Hex(), err)
+	}
+	if version != nil && *version != u.Version {
+		return apperror.ErrVersionConflict
+	}
+	return conflict
+}
@@ -0,0 +1,68 @@
+package user
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	RelationPending  = "pending"
+	RelationAccepted = "accepted"
+	RelationBlocked  = "blocked"
+)
+
+// Relation between two users is directed: From sent the friend request or blocked To.
+// Friends have one accepted relation, each of two users can block the other
+type Relation struct {
+	ID        string    `json:"-" bson:"_id,omitempty"`
+	From      string    `json:"from" bson:"from"`
+	To        string    `json:"to" bson:"to"`
+	Status    string    `json:"status" bson:"status"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// Other returns the other user of the relation
+func (r Relation) Other(userID string) string {
+	if r.From == userID {
+		return r.To
+	}
+	return r.From
+}
+
+// Friend is the public profile of the friend and the time the request was accepted
+type Friend struct {
+	PublicProfile
+	Since time.Time `json:"since"`
+}
+
+type FriendRequest struct {
+	User   PublicProfile `json:"user"`
+	SentAt time.Time     `json:"sent_at"`
+}
+
+// FriendRequests are pending requests sent to the user and by the user
+type FriendRequests struct {
+	Incoming []FriendRequest `json:"incoming"`
+	Outgoing []FriendRequest `json:"outgoing"`
+}
+
+// FriendIDsDTO lists friends of the user for leaderboards of other services
+type FriendIDsDTO struct {
+	UserIDs []string `json:"user_ids"`
+}
+
+type RelationStorage interface {
+	// Create returns ErrConflict if the relation from the user to the other one exists
+	Create(ctx context.Context, relation Relation) error
+	// FindBetween returns relations between the users in both directions
+	FindBetween(ctx context.Context, userID, otherID string) ([]Relation, error)
+	// FindOf returns relations of the user with the status in both directions
+	FindOf(ctx context.Context, userID, status string) ([]Relation, error)
+	// SetStatus changes status of the relation from one user to the other, returns ErrNotFound if there is no
+	// relation with the status
+	SetStatus(ctx context.Context, from, to, status, newStatus string, at time.Time) error
+	// Delete deletes the relation from one user to the other with one of the statuses, returns ErrNotFound if there is none
+	Delete(ctx context.Context, from, to string, statuses ...string) error
+	DeleteByUserID(ctx context.Context, userID string) error
+}
@@ -1,22 +1,54 @@
 package user
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/julienschmidt/httprouter"
 	"net/http"
 	"user_service/internal/apperror"
 	"user_service/pkg/logging"
+	"user_service/pkg/pagination"
 )
 
+// Internal API is called by other services and requires service role, the gateway doesn't expose it
 const (
-	usersURL      = "/api/users/"
-	authUrl       = "/api/users/auth/"
-	userIdURL     = "/api/users/id/:id"
-	usernameURL   = "/api/users/username/:username"
-	ticketsURL    = "/api/users/tickets/"
-	freeTicketURL = "/api/users/tickets/free/:id"
-	updateURL     = "/api/users/update"
+	internalUsersURL  = "/api/users/internal/"
+	authUrl           = "/api/users/internal/auth/"
+	internalUserIdURL = "/api/users/internal/id/:id"
+	usernameURL       = "/api/users/internal/username/:username"
+	ticketsURL        = "/api/users/internal/tickets/"
+	grantTicketURL    = "/api/users/internal/tickets/grant"
+	consumeTicketURL  = "/api/users/internal/tickets/consume"
+	freeTicketURL     = "/api/users/internal/tickets/free/:id"
+	updateURL         = "/api/users/internal/update"
+	guestsURL         = "/api/users/internal/guests/"
+	claimURL          = "/api/users/internal/claim/:id"
+	identitiesURL     = "/api/users/internal/identities/"
+	identityURL       = "/api/users/internal/identities/:id"
+	sanctionStatusURL = "/api/users/internal/sanctions/:id"
+	shadowBannedURL   = "/api/users/internal/shadow-banned"
+	friendIDsURL      = "/api/users/internal/friends/:id"
+)
+
+// Public API returns the caller's own user or public profiles of others
+const (
+	userIdURL    = "/api/users/id/:id"
+	rolesURL     = "/api/users/roles/:id"
+	profileURL   = "/api/users/profiles/:id"
+	statsURL     = "/api/users/profiles/:id/stats"
+	passwordURL  = "/api/users/password"
+	resetURL     = "/api/users/password/reset"
+	resetCodeURL = "/api/users/password/reset/code"
+	meURL        = "/api/users/me"
+	sanctionsURL = "/api/users/sanctions/:id"
+	sanctionURL  = "/api/users/sanctions/:id/:sanction"
+	friendsURL   = "/api/users/friends"
+	friendURL    = "/api/users/friends/id/:id"
+	requestsURL  = "/api/users/friends/requests"
+	requestURL   = "/api/users/friends/requests/:id"
+	blocksURL    = "/api/users/friends/blocks"
+	blockURL     = "/api/users/friends/blocks/:id"
 )
 
 type Handler struct {
@@ -25,16 +57,46 @@ type Handler struct {
 }
 
 func (h *Handler) Register(router *httprouter.Router) {
-	router.HandlerFunc(http.MethodPost, usersURL, apperror.Middleware(h.CreateUser))
-	router.HandlerFunc(http.MethodGet, usersURL, apperror.Middleware(h.GetUsers))
-	router.HandlerFunc(http.MethodPost, userIdURL, apperror.Middleware(h.GetUserById))
-	router.HandlerFunc(http.MethodPost, usernameURL, apperror.Middleware(h.GetUserByUsername))
-	router.HandlerFunc(http.MethodPost, authUrl, apperror.Middleware(h.GetUserByUsernameAndPassword))
-	router.HandlerFunc(http.MethodPost, updateURL, apperror.Middleware(h.PartiallyUpdateUser))
-	router.HandlerFunc(http.MethodDelete, userIdURL, apperror.Middleware(h.DeleteUser))
-	router.HandlerFunc(http.MethodPut, ticketsURL, apperror.Middleware(h.AddTicket))
-	router.HandlerFunc(http.MethodDelete, ticketsURL, apperror.Middleware(h.DeleteTicket))
-	router.HandlerFunc(http.MethodPost, freeTicketURL, apperror.Middleware(h.GetFreeTicketStatus))
+	router.HandlerFunc(http.MethodPost, internalUsersURL, apperror.RoleMiddleware(h.CreateUser, apperror.RoleService))
+	router.HandlerFunc(http.MethodGet, internalUsersURL, apperror.RoleMiddleware(h.GetUsers, apperror.RoleService))
+	router.HandlerFunc(http.MethodPost, internalUserIdURL, apperror.RoleMiddleware(h.GetUserById, apperror.RoleService))
+	router.HandlerFunc(http.MethodPost, usernameURL, apperror.RoleMiddleware(h.GetUserByUsername, apperror.RoleService))
+	router.HandlerFunc(http.MethodPost, authUrl, apperror.RoleMiddleware(h.GetUserByUsernameAndPassword, apperror.RoleService))
+	router.HandlerFunc(http.MethodPost, updateURL, apperror.RoleMiddleware(h.PartiallyUpdateUser, apperror.RoleService))
+	router.HandlerFunc(http.MethodPost, grantTicketURL, apperror.RoleMiddleware(h.GrantTicket, apperror.RoleService))
+	router.HandlerFunc(http.MethodPost, consumeTicketURL, apperror.RoleMiddleware(h.ConsumeTicket, apperror.RoleService))
+	router.HandlerFunc(http.MethodDelete, ticketsURL, apperror.RoleMiddleware(h.DeleteTicket, apperror.RoleService))
+	router.HandlerFunc(http.MethodPost, freeTicketURL, apperror.RoleMiddleware(h.GetFreeTicketStatus, apperror.RoleService))
+	router.HandlerFunc(http.MethodPost, guestsURL, apperror.RoleMiddleware(h.CreateGuest, apperror.RoleService))
+	router.HandlerFunc(http.MethodPut, claimURL, apperror.RoleMiddleware(h.ClaimGuest, apperror.RoleService))
+	router.HandlerFunc(http.MethodPost, identitiesURL, apperror.RoleMiddleware(h.SignInWithIdentity, apperror.RoleService))
+	router.HandlerFunc(http.MethodPut, identityURL, apperror.RoleMiddleware(h.LinkIdentity, apperror.RoleService))
+	router.HandlerFunc(http.MethodGet, sanctionStatusURL, apperror.RoleMiddleware(h.GetSanctionStatus, apperror.RoleService))
+	router.HandlerFunc(http.MethodGet, shadowBannedURL, apperror.RoleMiddleware(h.GetShadowBanned, apperror.RoleService))
+	router.HandlerFunc(http.MethodGet, friendIDsURL, apperror.RoleMiddleware(h.GetFriendIDs, apperror.RoleService))
+
+	router.HandlerFunc(http.MethodDelete, userIdURL, apperror.RoleMiddleware(h.DeleteUser, apperror.RoleAdmin))
+	router.HandlerFunc(http.MethodPut, rolesURL, apperror.RoleMiddleware(h.SetRoles, apperror.RoleAdmin))
+	router.HandlerFunc(http.MethodGet, meURL, apperror.RoleMiddleware(h.GetMe, apperror.AccountRoles...))
+	router.HandlerFunc(http.MethodPatch, meURL, apperror.RoleMiddleware(h.UpdateProfile, apperror.AccountRoles...))
+	router.HandlerFunc(http.MethodGet, profileURL, apperror.RoleMiddleware(h.GetProfile))
+	router.HandlerFunc(http.MethodGet, statsURL, apperror.RoleMiddleware(h.GetStats))
+	router.HandlerFunc(http.MethodPost, passwordURL, apperror.RoleMiddleware(h.ChangePassword, apperror.AccountRoles...))
+	router.HandlerFunc(http.MethodPost, resetCodeURL, apperror.Middleware(h.RequestPasswordReset))
+	router.HandlerFunc(http.MethodPost, resetURL, apperror.Middleware(h.ResetPassword))
+	router.HandlerFunc(http.MethodDelete, meURL, apperror.RoleMiddleware(h.DeleteAccount, apperror.AccountRoles...))
+	router.HandlerFunc(http.MethodPost, sanctionsURL, apperror.RoleMiddleware(h.Sanction, apperror.RoleOperator, apperror.RoleAdmin))
+	router.HandlerFunc(http.MethodGet, sanctionsURL, apperror.RoleMiddleware(h.GetSanctions, apperror.RoleOperator, apperror.RoleAdmin))
+	router.HandlerFunc(http.MethodDelete, sanctionURL, apperror.RoleMiddleware(h.LiftSanction, apperror.RoleOperator, apperror.RoleAdmin))
+	router.HandlerFunc(http.MethodGet, friendsURL, apperror.RoleMiddleware(h.GetFriends, apperror.AccountRoles...))
+	router.HandlerFunc(http.MethodDelete, friendURL, apperror.RoleMiddleware(h.RemoveFriend, apperror.AccountRoles...))
+	router.HandlerFunc(http.MethodGet, requestsURL, apperror.RoleMiddleware(h.GetFriendRequests, apperror.AccountRoles...))
+	router.HandlerFunc(http.MethodPost, requestURL, apperror.RoleMiddleware(h.SendFriendRequest, apperror.AccountRoles...))
+	router.HandlerFunc(http.MethodPut, requestURL, apperror.RoleMiddleware(h.AcceptFriendRequest, apperror.AccountRoles...))
+	router.HandlerFunc(http.MethodDelete, requestURL, apperror.RoleMiddleware(h.DeleteFriendRequest, apperror.AccountRoles...))
+	router.HandlerFunc(http.MethodGet, blocksURL, apperror.RoleMiddleware(h.GetBlocked, apperror.AccountRoles...))
+	router.HandlerFunc(http.MethodPut, blockURL, apperror.RoleMiddleware(h.Block, apperror.AccountRoles...))
+	router.HandlerFunc(http.MethodDelete, blockURL, apperror.RoleMiddleware(h.Unblock, apperror.AccountRoles...))
 }
 
 // Get user by id
@@ -46,7 +108,7 @@ func (h *Handler) Register(router *httprouter.Router) {
 // @Success 200
 // @Failure 400
 // @Failure 418 {object} apperror.AppError
-// @Router /api/users/id/{id} [post]
+// @Router /api/users/internal/id/{id} [post]
 func (h *Handler) GetUserById(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Info("GET USER BY ID")
 	w.Header().Set("Content-Type", "application/json")
@@ -59,6 +121,8 @@ func (h *Handler) GetUserById(w http.ResponseWriter, r *http.Request) error {
 	if err != nil {
 		return err
 	}
+	// services look the user up on token refresh and lobby join
+	h.UserService.Seen(r.Context(), user.ID)
 
 	h.Logger.Debug("marshal user")
 	userBytes, err := json.Marshal(user)
@@ -80,7 +144,7 @@ func (h *Handler) GetUserById(w http.ResponseWriter, r *http.Request) error {
 // @Success 200
 // @Failure 400
 // @Failure 418 {object} apperror.AppError
-// @Router /api/users/username/{username} [post]
+// @Router /api/users/internal/username/{username} [post]
 func (h *Handler) GetUserByUsername(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Info("GET USER BY USERNAME")
 	w.Header().Set("Content-Type", "application/json")
@@ -113,8 +177,9 @@ func (h *Handler) GetUserByUsername(w http.ResponseWriter, r *http.Request) erro
 // @Tags Users
 // @Success 200
 // @Failure 400
+// @Failure 401 {object} apperror.AppError
 // @Failure 418 {object} apperror.AppError
-// @Router /api/users/auth [post]
+// @Router /api/users/internal/auth [post]
 func (h *Handler) GetUserByUsernameAndPassword(w http.ResponseWriter, r *http.Request) error {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -142,27 +207,39 @@ func (h *Handler) GetUserByUsernameAndPassword(w http.ResponseWriter, r *http.Re
 	return nil
 }
 
-// Get all users
-// @Summary Get all users endpoint
+// Get users
+// @Summary Get page of users endpoint
 // @Accept json
 // @Produce json
 // @Tags Users
+// @Param limit query int false "page size, 20 by default, 100 at most"
+// @Param after query string false "next_cursor of the previous page"
+// @Param sort query string false "id, username or last_seen, - prefix for descending"
+// @Param role query string false "users with the role"
+// @Param country query string false "users from the country"
+// @Param is_guest query bool false "guests or registered users"
 // @Success 200
 // @Failure 400
-// @Router /api/users [get]
+// @Router /api/users/internal [get]
 func (h *Handler) GetUsers(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Info("GET USERS")
 	w.Header().Set("Content-Type", "application/json")
 
-	h.Logger.Debug("get uuid from context")
+	query := r.URL.Query()
+	params, err := pagination.Parse(query, SortFields...)
+	if err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+	filter, err := NewFilter(query)
+	if err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
 
-	users, err := h.UserService.GetAll(r.Context())
+	users, err := h.UserService.GetPage(r.Context(), filter, params)
 	if err != nil {
 		return err
 	}
 
-	h.Logger.Println(users)
-
 	h.Logger.Debug("marshal user")
 	userBytes, err := json.Marshal(users)
 	if err != nil {
@@ -182,8 +259,9 @@ func (h *Handler) GetUsers(w http.ResponseWriter, r *http.Request) error {
 // @Tags Users
 // @Success 201
 // @Failure 400
+// @Failure 409 {object} apperror.AppError
 // @Failure 418 {object} apperror.AppError
-// @Router /api/users [post]
+// @Router /api/users/internal [post]
 func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Info("CREATE USER")
 	w.Header().Set("Content-Type", "application/json")
@@ -200,7 +278,7 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) error {
 	if err != nil {
 		return err
 	}
-	w.Header().Set("Location", fmt.Sprintf("%s/%s", usersURL, userUUID))
+	w.Header().Set("Location", fmt.Sprintf("%s%s", internalUsersURL, userUUID))
 	w.WriteHeader(http.StatusCreated)
 
 	return nil
@@ -214,8 +292,9 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) error {
 // @Tags Users
 // @Success 204
 // @Failure 400
+// @Failure 409 {object} apperror.AppError "version is given and the user has another one"
 // @Failure 418 {object} apperror.AppError
-// @Router /api/users [patch]
+// @Router /api/users/internal/update [post]
 func (h *Handler) PartiallyUpdateUser(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Info("PARTIALLY UPDATE USER")
 	w.Header().Set("Content-Type", "application/json")
@@ -244,7 +323,8 @@ func (h *Handler) PartiallyUpdateUser(w http.ResponseWriter, r *http.Request) er
 // @Success 204
 // @Failure 400
 // @Failure 418 {object} apperror.AppError
-// @Router /api/users [delete]
+// @Failure 403 {object} apperror.AppError
+// @Router /api/users/id/{id} [delete]
 func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Info("DELETE USER")
 	w.Header().Set("Content-Type", "application/json")
@@ -262,47 +342,56 @@ func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
-// Add user
-// @Summary Add user by user id and user id
+// Grant ticket
+// @Summary Grant the ticket to the user
 // @Accept json
 // @Produce json
-// @Param data body TicketDTO true "ticket dto struct"
+// @Param data body TicketDTO true "user id, game type, ticket id and optional version"
 // @Tags Tickets
-// @Success 201
+// @Success 200 {object} TicketBalance
 // @Failure 400
-// @Failure 418 {object} apperror.AppError
-// @Router /api/users/tickets [put]
-func (h *Handler) AddTicket(w http.ResponseWriter, r *http.Request) error {
-	h.Logger.Info("POST ADD TICKET")
-	w.Header().Set("Content-Type", "application/json")
-
-	var dto TicketDTO
-	defer r.Body.Close()
-	err := json.NewDecoder(r.Body).Decode(&dto)
-	if err != nil {
-		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
-	}
-	err = h.UserService.AddTicket(r.Context(), dto)
-	if err != nil {
-		return fmt.Errorf("unable to add user due to: %v", err)
-	}
-	w.WriteHeader(http.StatusCreated)
+// @Failure 404 {object} apperror.AppError
+// @Failure 409 {object} apperror.AppError "the user has the ticket already or another version"
+// @Router /api/users/internal/tickets/grant [post]
+func (h *Handler) GrantTicket(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("GRANT TICKET")
+	return h.changeTickets(w, r, h.UserService.GrantTicket)
+}
 
-	return nil
+// Consume ticket
+// @Summary Take one ticket of the game type from the user
+// @Accept json
+// @Produce json
+// @Param data body TicketDTO true "user id, game type, optional ticket id the user must own and optional version"
+// @Tags Tickets
+// @Success 200 {object} TicketBalance
+// @Failure 400
+// @Failure 404 {object} apperror.AppError
+// @Failure 409 {object} apperror.AppError "no tickets left or another version"
+// @Router /api/users/internal/tickets/consume [post]
+func (h *Handler) ConsumeTicket(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("CONSUME TICKET")
+	return h.changeTickets(w, r, h.UserService.ConsumeTicket)
 }
 
-// Delete user
-// @Summary Delete user by user id and user id
+// Delete ticket
+// @Summary Delete the ticket of the user
 // @Accept json
 // @Produce json
-// @Param data path string true "User ID"
+// @Param data body TicketDTO true "user id, game type, ticket id and optional version"
 // @Tags Tickets
-// @Success 204
+// @Success 200 {object} TicketBalance
 // @Failure 400
-// @Failure 418 {object} apperror.AppError
-// @Router /api/users/tickets [delete]
+// @Failure 404 {object} apperror.AppError
+// @Failure 409 {object} apperror.AppError "the user doesn't own the ticket or has another version"
+// @Router /api/users/internal/tickets [delete]
 func (h *Handler) DeleteTicket(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Info("DELETE TICKET")
+	return h.changeTickets(w, r, h.UserService.DeleteTicket)
+}
+
+// changeTickets decodes TicketDTO, applies the operation and responds with the tickets of the user
+func (h *Handler) changeTickets(w http.ResponseWriter, r *http.Request, operation func(context.Context, TicketDTO) (TicketBalance, error)) error {
 	w.Header().Set("Content-Type", "application/json")
 
 	var dto TicketDTO
@@ -311,12 +400,17 @@ func (h *Handler) DeleteTicket(w http.ResponseWriter, r *http.Request) error {
 	if err != nil {
 		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
 	}
-	err = h.UserService.DeleteTicket(r.Context(), dto)
+	balance, err := operation(r.Context(), dto)
 	if err != nil {
-		return fmt.Errorf("unable to delete user due to: %v", err)
+		return err
 	}
-	w.WriteHeader(http.StatusNoContent)
 
+	balanceBytes, err := json.Marshal(balance)
+	if err != nil {
+		return fmt.Errorf("failed to marshall tickets. error: %w", err)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(balanceBytes)
 	return nil
 }
 
@@ -329,7 +423,7 @@ func (h *Handler) DeleteTicket(w http.ResponseWriter, r *http.Request) error {
 // @Success 200
 // @Failure 404
 // @Failure 418 {object} apperror.AppError
-// @Router /api/users/tickets/free/{id} [post]
+// @Router /api/users/internal/tickets/free/{id} [post]
 func (h *Handler) GetFreeTicketStatus(w http.ResponseWriter, r *http.Request) error {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -354,3 +448,771 @@ func (h *Handler) GetFreeTicketStatus(w http.ResponseWriter, r *http.Request) er
 	w.Write(userBytes)
 	return nil
 }
+
+// Set user roles
+// @Summary Replace roles of the user. Available for admins only
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param data body RolesDTO true "roles: player, operator, admin"
+// @Tags Users
+// @Success 204
+// @Failure 400 {object} apperror.AppError
+// @Failure 403 {object} apperror.AppError
+// @Failure 404 {object} apperror.AppError
+// @Router /api/users/roles/{id} [put]
+func (h *Handler) SetRoles(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("SET USER ROLES")
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	userUUID := params.ByName("id")
+
+	var dto RolesDTO
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+	err := h.UserService.SetRoles(r.Context(), userUUID, dto)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+// Create guest
+// @Summary Create guest account of the device. Responds with 200 and the existing account if the device has one
+// @Description The device secret is returned once, when it's issued. The existing account requires it
+// @Accept json
+// @Produce json
+// @Param data body GuestDTO true "device id and secret"
+// @Tags Users
+// @Success 201 {object} GuestSecretDTO
+// @Success 200 {object} GuestSecretDTO
+// @Failure 400 {object} apperror.AppError
+// @Failure 401 {object} apperror.AppError "wrong device secret"
+// @Failure 418 {object} apperror.AppError
+// @Router /api/users/internal/guests [post]
+func (h *Handler) CreateGuest(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("CREATE GUEST")
+	w.Header().Set("Content-Type", "application/json")
+
+	var dto GuestDTO
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+
+	userUUID, secret, created, err := h.UserService.CreateGuest(r.Context(), dto)
+	if err != nil {
+		return err
+	}
+	bytes, err := json.Marshal(GuestSecretDTO{DeviceSecret: secret})
+	if err != nil {
+		return fmt.Errorf("failed to marshal device secret due to: %v", err)
+	}
+	w.Header().Set("Location", fmt.Sprintf("%s%s", internalUsersURL, userUUID))
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.Write(bytes)
+
+	return nil
+}
+
+// Claim guest
+// @Summary Set username and password of the guest account, tickets and records are kept
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param data body ClaimDTO true "username and password"
+// @Tags Users
+// @Success 204
+// @Failure 400 {object} apperror.AppError
+// @Failure 404 {object} apperror.AppError
+// @Failure 409 {object} apperror.AppError
+// @Router /api/users/internal/claim/{id} [put]
+func (h *Handler) ClaimGuest(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("CLAIM GUEST")
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	userUUID := params.ByName("id")
+
+	var dto ClaimDTO
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+	err := h.UserService.Claim(r.Context(), userUUID, dto)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+// Sign in with identity
+// @Summary Find user by identity of external provider. Responds with 201 if a new user is created for the identity
+// @Accept json
+// @Produce json
+// @Param data body IdentityDTO true "provider, subject and username hint"
+// @Tags Users
+// @Success 200
+// @Success 201
+// @Failure 400 {object} apperror.AppError
+// @Failure 409 {object} apperror.AppError
+// @Router /api/users/internal/identities [post]
+func (h *Handler) SignInWithIdentity(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("SIGN IN WITH IDENTITY")
+	w.Header().Set("Content-Type", "application/json")
+
+	var dto IdentityDTO
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+
+	userUUID, created, err := h.UserService.SignInWithIdentity(r.Context(), dto)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Location", fmt.Sprintf("%s%s", internalUsersURL, userUUID))
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return nil
+}
+
+// Link identity
+// @Summary Link identity of external provider to the user
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param data body IdentityDTO true "provider and subject"
+// @Tags Users
+// @Success 204
+// @Failure 400 {object} apperror.AppError
+// @Failure 404 {object} apperror.AppError
+// @Failure 409 {object} apperror.AppError
+// @Router /api/users/internal/identities/{id} [put]
+func (h *Handler) LinkIdentity(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("LINK IDENTITY")
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	userUUID := params.ByName("id")
+
+	var dto IdentityDTO
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+	err := h.UserService.LinkIdentity(r.Context(), userUUID, dto)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+// Get me
+// @Summary Get the signed in user with tickets and linked identities
+// @Produce json
+// @Tags Users
+// @Success 200 {object} User
+// @Failure 401 {object} apperror.AppError
+// @Failure 403 {object} apperror.AppError
+// @Router /api/users/me [get]
+func (h *Handler) GetMe(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("GET ME")
+	w.Header().Set("Content-Type", "application/json")
+	claims, _ := apperror.Claims(r.Context())
+	user, err := h.UserService.GetById(r.Context(), claims.Id)
+	if err != nil {
+		return err
+	}
+	userBytes, err := json.Marshal(user)
+	if err != nil {
+		return fmt.Errorf("failed to marshall user. error: %w", err)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(userBytes)
+	return nil
+}
+
+// Get profile
+// @Summary Get public profile of the user, tickets and identities are not included
+// @Produce json
+// @Param id path string true "User ID"
+// @Tags Users
+// @Success 200 {object} PublicProfile
+// @Failure 401 {object} apperror.AppError
+// @Failure 404 {object} apperror.AppError
+// @Router /api/users/profiles/{id} [get]
+func (h *Handler) GetProfile(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("GET PROFILE")
+	w.Header().Set("Content-Type", "application/json")
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	profile, err := h.UserService.GetProfile(r.Context(), params.ByName("id"))
+	if err != nil {
+		return err
+	}
+	profileBytes, err := json.Marshal(profile)
+	if err != nil {
+		return fmt.Errorf("failed to marshall profile. error: %w", err)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(profileBytes)
+	return nil
+}
+
+// Update profile
+// @Summary Update display name, avatar url (https) or country (ISO 3166-1 alpha-2) of the signed in user. Fields which are not sent are kept, empty string clears the field
+// @Accept json
+// @Produce json
+// @Param data body ProfileDTO true "profile fields"
+// @Tags Users
+// @Success 204
+// @Failure 400 {object} apperror.AppError
+// @Failure 401 {object} apperror.AppError
+// @Failure 403 {object} apperror.AppError
+// @Router /api/users/me [patch]
+func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("UPDATE PROFILE")
+	w.Header().Set("Content-Type", "application/json")
+	claims, _ := apperror.Claims(r.Context())
+	var dto ProfileDTO
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+	err := h.UserService.UpdateProfile(r.Context(), claims.Id, dto)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// Get stats
+// @Summary Get games played, wins and best score of the user in snake, quiz and qualification tables. Sources which didn't respond are listed in unavailable
+// @Produce json
+// @Param id path string true "User ID"
+// @Tags Users
+// @Success 200 {object} Stats
+// @Failure 401 {object} apperror.AppError
+// @Failure 404 {object} apperror.AppError
+// @Router /api/users/profiles/{id}/stats [get]
+func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("GET STATS")
+	w.Header().Set("Content-Type", "application/json")
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	stats, err := h.UserService.GetStats(r.Context(), params.ByName("id"))
+	if err != nil {
+		return err
+	}
+	statsBytes, err := json.Marshal(stats)
+	if err != nil {
+		return fmt.Errorf("failed to marshall stats. error: %w", err)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(statsBytes)
+	return nil
+}
+
+// Change password
+// @Summary Change password of the signed in user
+// @Accept json
+// @Produce json
+// @Param data body ChangePasswordDTO true "old and new password"
+// @Tags Users
+// @Success 204
+// @Failure 400 {object} apperror.AppError
+// @Failure 401 {object} apperror.AppError
+// @Failure 403 {object} apperror.AppError
+// @Router /api/users/password [post]
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("CHANGE PASSWORD")
+	w.Header().Set("Content-Type", "application/json")
+	claims, _ := apperror.Claims(r.Context())
+	var dto ChangePasswordDTO
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+	err := h.UserService.ChangePassword(r.Context(), claims.Id, dto)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// Request password reset
+// @Summary Send password reset code to the user. Responds with 202 whether the user exists or not
+// @Accept json
+// @Produce json
+// @Param data body ResetCodeDTO true "username"
+// @Tags Users
+// @Success 202
+// @Failure 400 {object} apperror.AppError
+// @Router /api/users/password/reset/code [post]
+func (h *Handler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("REQUEST PASSWORD RESET")
+	w.Header().Set("Content-Type", "application/json")
+	var dto ResetCodeDTO
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+	err := h.UserService.RequestPasswordReset(r.Context(), dto)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusAccepted)
+	return nil
+}
+
+// Reset password
+// @Summary Set new password with the reset code, the code is used once
+// @Accept json
+// @Produce json
+// @Param data body ResetPasswordDTO true "username, code and new password"
+// @Tags Users
+// @Success 204
+// @Failure 400 {object} apperror.AppError
+// @Router /api/users/password/reset [post]
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("RESET PASSWORD")
+	w.Header().Set("Content-Type", "application/json")
+	var dto ResetPasswordDTO
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+	err := h.UserService.ResetPassword(r.Context(), dto)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// Delete account
+// @Summary Delete account of the signed in user with its tickets and leaderboard records
+// @Accept json
+// @Produce json
+// @Param data body DeleteAccountDTO true "password confirms the deletion"
+// @Tags Users
+// @Success 204
+// @Failure 401 {object} apperror.AppError
+// @Failure 403 {object} apperror.AppError
+// @Failure 418 {object} apperror.AppError
+// @Router /api/users/me [delete]
+func (h *Handler) DeleteAccount(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("DELETE ACCOUNT")
+	w.Header().Set("Content-Type", "application/json")
+	claims, _ := apperror.Claims(r.Context())
+	var dto DeleteAccountDTO
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+	err := h.UserService.DeleteAccount(r.Context(), claims.Id, dto)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// Sanction user
+// @Summary Ban or shadow-ban the user. Available for operators and admins
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param data body SanctionDTO true "type: ban or shadow_ban, reason and optional duration, e.g. 72h"
+// @Tags Sanctions
+// @Success 201 {object} Sanction
+// @Failure 400 {object} apperror.AppError
+// @Failure 403 {object} apperror.AppError
+// @Failure 404 {object} apperror.AppError
+// @Router /api/users/sanctions/{id} [post]
+func (h *Handler) Sanction(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("SANCTION USER")
+	w.Header().Set("Content-Type", "application/json")
+	claims, _ := apperror.Claims(r.Context())
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	userUUID := params.ByName("id")
+
+	var dto SanctionDTO
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+	sanction, err := h.UserService.Sanction(r.Context(), userUUID, claims.Id, dto)
+	if err != nil {
+		return err
+	}
+
+	sanctionBytes, err := json.Marshal(sanction)
+	if err != nil {
+		return fmt.Errorf("failed to marshall sanction. error: %w", err)
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write(sanctionBytes)
+	return nil
+}
+
+// Get sanctions
+// @Summary Get all sanctions of the user, the latest first. Available for operators and admins
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Tags Sanctions
+// @Success 200 {array} Sanction
+// @Failure 403 {object} apperror.AppError
+// @Router /api/users/sanctions/{id} [get]
+func (h *Handler) GetSanctions(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("GET SANCTIONS")
+	w.Header().Set("Content-Type", "application/json")
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	userUUID := params.ByName("id")
+
+	sanctions, err := h.UserService.GetSanctions(r.Context(), userUUID)
+	if err != nil {
+		return err
+	}
+
+	sanctionsBytes, err := json.Marshal(sanctions)
+	if err != nil {
+		return fmt.Errorf("failed to marshall sanctions. error: %w", err)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(sanctionsBytes)
+	return nil
+}
+
+// Lift sanction
+// @Summary Lift the active sanction of the user. Available for operators and admins
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param sanction path string true "Sanction ID"
+// @Tags Sanctions
+// @Success 204
+// @Failure 403 {object} apperror.AppError
+// @Failure 404 {object} apperror.AppError "the user has no such active sanction"
+// @Router /api/users/sanctions/{id}/{sanction} [delete]
+func (h *Handler) LiftSanction(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("LIFT SANCTION")
+	w.Header().Set("Content-Type", "application/json")
+	claims, _ := apperror.Claims(r.Context())
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+
+	err := h.UserService.LiftSanction(r.Context(), params.ByName("id"), params.ByName("sanction"), claims.Id)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// Get sanction status
+// @Summary Get active sanctions of the user. Services check it before letting the user play
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Tags Sanctions
+// @Success 200 {object} SanctionStatus
+// @Failure 403 {object} apperror.AppError
+// @Router /api/users/internal/sanctions/{id} [get]
+func (h *Handler) GetSanctionStatus(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("GET SANCTION STATUS")
+	w.Header().Set("Content-Type", "application/json")
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+
+	status, err := h.UserService.GetSanctionStatus(r.Context(), params.ByName("id"))
+	if err != nil {
+		return err
+	}
+
+	statusBytes, err := json.Marshal(status)
+	if err != nil {
+		return fmt.Errorf("failed to marshall sanction status. error: %w", err)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(statusBytes)
+	return nil
+}
+
+// Get shadow banned users
+// @Summary Get ids of users with active shadow ban, leaderboards hide their results
+// @Accept json
+// @Produce json
+// @Tags Sanctions
+// @Success 200 {object} ShadowBannedDTO
+// @Failure 403 {object} apperror.AppError
+// @Router /api/users/internal/shadow-banned [get]
+func (h *Handler) GetShadowBanned(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("GET SHADOW BANNED")
+	w.Header().Set("Content-Type", "application/json")
+
+	ids, err := h.UserService.GetShadowBanned(r.Context())
+	if err != nil {
+		return err
+	}
+	if ids == nil {
+		ids = []string{}
+	}
+
+	idsBytes, err := json.Marshal(ShadowBannedDTO{UserIDs: ids})
+	if err != nil {
+		return fmt.Errorf("failed to marshall shadow banned users. error: %w", err)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(idsBytes)
+	return nil
+}
+
+// Get friends
+// @Summary Get profiles of friends of the signed in user, the latest friends first
+// @Accept json
+// @Produce json
+// @Tags Friends
+// @Success 200 {array} Friend
+// @Failure 401 {object} apperror.AppError
+// @Failure 403 {object} apperror.AppError
+// @Router /api/users/friends [get]
+func (h *Handler) GetFriends(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("GET FRIENDS")
+	w.Header().Set("Content-Type", "application/json")
+	claims, _ := apperror.Claims(r.Context())
+
+	friends, err := h.UserService.GetFriends(r.Context(), claims.Id)
+	if err != nil {
+		return err
+	}
+
+	friendsBytes, err := json.Marshal(friends)
+	if err != nil {
+		return fmt.Errorf("failed to marshall friends. error: %w", err)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(friendsBytes)
+	return nil
+}
+
+// Remove friend
+// @Summary Remove the user from friends of the signed in user
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID of the friend"
+// @Tags Friends
+// @Success 204
+// @Failure 403 {object} apperror.AppError
+// @Failure 404 {object} apperror.AppError "the users are not friends"
+// @Router /api/users/friends/id/{id} [delete]
+func (h *Handler) RemoveFriend(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("REMOVE FRIEND")
+	return h.changeRelation(w, r, h.UserService.RemoveFriend)
+}
+
+// Get friend requests
+// @Summary Get pending friend requests sent to and by the signed in user
+// @Accept json
+// @Produce json
+// @Tags Friends
+// @Success 200 {object} FriendRequests
+// @Failure 403 {object} apperror.AppError
+// @Router /api/users/friends/requests [get]
+func (h *Handler) GetFriendRequests(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("GET FRIEND REQUESTS")
+	w.Header().Set("Content-Type", "application/json")
+	claims, _ := apperror.Claims(r.Context())
+
+	requests, err := h.UserService.GetFriendRequests(r.Context(), claims.Id)
+	if err != nil {
+		return err
+	}
+
+	requestsBytes, err := json.Marshal(requests)
+	if err != nil {
+		return fmt.Errorf("failed to marshall friend requests. error: %w", err)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(requestsBytes)
+	return nil
+}
+
+// Send friend request
+// @Summary Send friend request to the user. If the user has sent a request to the signed in user, it is accepted
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Tags Friends
+// @Success 201 {object} Relation "status is pending or accepted"
+// @Failure 400 {object} apperror.AppError "the user is a guest or blocked"
+// @Failure 403 {object} apperror.AppError
+// @Failure 404 {object} apperror.AppError
+// @Failure 409 {object} apperror.AppError "the request is sent already or the users are friends"
+// @Router /api/users/friends/requests/{id} [post]
+func (h *Handler) SendFriendRequest(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("SEND FRIEND REQUEST")
+	w.Header().Set("Content-Type", "application/json")
+	claims, _ := apperror.Claims(r.Context())
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+
+	relation, err := h.UserService.SendFriendRequest(r.Context(), claims.Id, params.ByName("id"))
+	if err != nil {
+		return err
+	}
+
+	relationBytes, err := json.Marshal(relation)
+	if err != nil {
+		return fmt.Errorf("failed to marshall friend request. error: %w", err)
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write(relationBytes)
+	return nil
+}
+
+// Accept friend request
+// @Summary Accept friend request the user sent to the signed in user
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID of the sender"
+// @Tags Friends
+// @Success 204
+// @Failure 403 {object} apperror.AppError
+// @Failure 404 {object} apperror.AppError "there is no such request"
+// @Router /api/users/friends/requests/{id} [put]
+func (h *Handler) AcceptFriendRequest(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("ACCEPT FRIEND REQUEST")
+	return h.changeRelation(w, r, h.UserService.AcceptFriendRequest)
+}
+
+// Decline friend request
+// @Summary Decline friend request of the user or cancel the request sent to the user
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Tags Friends
+// @Success 204
+// @Failure 403 {object} apperror.AppError
+// @Failure 404 {object} apperror.AppError "there is no such request"
+// @Router /api/users/friends/requests/{id} [delete]
+func (h *Handler) DeleteFriendRequest(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("DELETE FRIEND REQUEST")
+	return h.changeRelation(w, r, h.UserService.DeleteFriendRequest)
+}
+
+// Get blocked users
+// @Summary Get profiles of users blocked by the signed in user
+// @Accept json
+// @Produce json
+// @Tags Friends
+// @Success 200 {array} PublicProfile
+// @Failure 403 {object} apperror.AppError
+// @Router /api/users/friends/blocks [get]
+func (h *Handler) GetBlocked(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("GET BLOCKED USERS")
+	w.Header().Set("Content-Type", "application/json")
+	claims, _ := apperror.Claims(r.Context())
+
+	blocked, err := h.UserService.GetBlocked(r.Context(), claims.Id)
+	if err != nil {
+		return err
+	}
+
+	blockedBytes, err := json.Marshal(blocked)
+	if err != nil {
+		return fmt.Errorf("failed to marshall blocked users. error: %w", err)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(blockedBytes)
+	return nil
+}
+
+// Block user
+// @Summary Block the user: friendship and pending requests are removed, the user can't send friend requests
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Tags Friends
+// @Success 204
+// @Failure 400 {object} apperror.AppError
+// @Failure 403 {object} apperror.AppError
+// @Failure 404 {object} apperror.AppError
+// @Router /api/users/friends/blocks/{id} [put]
+func (h *Handler) Block(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("BLOCK USER")
+	return h.changeRelation(w, r, h.UserService.Block)
+}
+
+// Unblock user
+// @Summary Unblock the user
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Tags Friends
+// @Success 204
+// @Failure 403 {object} apperror.AppError
+// @Failure 404 {object} apperror.AppError "the user isn't blocked"
+// @Router /api/users/friends/blocks/{id} [delete]
+func (h *Handler) Unblock(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("UNBLOCK USER")
+	return h.changeRelation(w, r, h.UserService.Unblock)
+}
+
+// changeRelation applies the operation to the signed in user and the user of the path
+func (h *Handler) changeRelation(w http.ResponseWriter, r *http.Request, operation func(ctx context.Context, userID, otherID string) error) error {
+	w.Header().Set("Content-Type", "application/json")
+	claims, _ := apperror.Claims(r.Context())
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+
+	err := operation(r.Context(), claims.Id, params.ByName("id"))
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// Get friend ids
+// @Summary Get ids of friends of the user, leaderboards use them for the friends filter
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Tags Friends
+// @Success 200 {object} FriendIDsDTO
+// @Failure 403 {object} apperror.AppError
+// @Router /api/users/internal/friends/{id} [get]
+func (h *Handler) GetFriendIDs(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("GET FRIEND IDS")
+	w.Header().Set("Content-Type", "application/json")
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+
+	ids, err := h.UserService.GetFriendIDs(r.Context(), params.ByName("id"))
+	if err != nil {
+		return err
+	}
+
+	idsBytes, err := json.Marshal(FriendIDsDTO{UserIDs: ids})
+	if err != nil {
+		return fmt.Errorf("failed to marshall friend ids. error: %w", err)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(idsBytes)
+	return nil
+}
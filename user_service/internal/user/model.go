@@ -1,22 +1,101 @@
 package user
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golang.org/x/crypto/bcrypt"
+	"net/url"
+	"strconv"
+	"time"
+	"user_service/internal/apperror"
 )
 
+const guestUsernamePrefix = "guest-"
+
 type User struct {
 	ID            string        `json:"id" bson:"_id,omitempty"`
 	Username      string        `json:"username" bson:"username"`
 	Password      string        `json:"-" bson:"password"`
 	HasFreeTicket bool          `json:"has_free_ticket" bson:"has_free_ticket"`
 	Tickets       []GameTickets `json:"tickets" bson:"tickets"`
+	Roles         []string      `json:"roles" bson:"roles"`
+	// IsGuest is set for accounts created by device id, guest has no password until the account is claimed
+	IsGuest  bool   `json:"is_guest" bson:"is_guest"`
+	DeviceID string `json:"-" bson:"device_id,omitempty"`
+	// DeviceSecret is sha256 of the secret issued to the device, the guest signs in with device id and the secret
+	DeviceSecret string `json:"-" bson:"device_secret,omitempty"`
+	// Identities are accounts of external identity providers the user signs in with
+	Identities []Identity `json:"identities,omitempty" bson:"identities,omitempty"`
+	// Profile fields are shown to other players
+	DisplayName string `json:"display_name" bson:"display_name,omitempty"`
+	AvatarURL   string `json:"avatar_url" bson:"avatar_url,omitempty"`
+	Country     string `json:"country" bson:"country,omitempty"`
+	// CreatedAt of users created before it was stored is taken from the id
+	CreatedAt time.Time `json:"created_at" bson:"created_at,omitempty"`
+	// LastSeen is updated on sign in, token refresh and lobby join
+	LastSeen time.Time `json:"last_seen" bson:"last_seen,omitempty"`
+	// Version is incremented by every update of tickets or the user, requests with version
+	// are applied only if the user wasn't changed since it was read
+	Version int64 `json:"version" bson:"version"`
+}
+
+// PublicProfile is what other players see about the user
+type PublicProfile struct {
+	ID          string    `json:"id"`
+	Username    string    `json:"username"`
+	IsGuest     bool      `json:"is_guest"`
+	DisplayName string    `json:"display_name"`
+	AvatarURL   string    `json:"avatar_url"`
+	Country     string    `json:"country"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// ProfileDTO updates profile fields which are set, empty string clears the field
+type ProfileDTO struct {
+	DisplayName *string `json:"display_name"`
+	AvatarURL   *string `json:"avatar_url"`
+	Country     *string `json:"country"`
+}
+
+type Identity struct {
+	Provider string `json:"provider" bson:"provider"`
+	Subject  string `json:"subject" bson:"subject"`
+}
+
+// IdentityDTO is an identity of external provider, username is a hint for a new user
+type IdentityDTO struct {
+	Provider string `json:"provider"`
+	Subject  string `json:"subject"`
+	Username string `json:"username"`
 }
 
+// TicketDTO grants, consumes or deletes a ticket of the user, ticket id is optional for consume.
+// Without version the operation is applied to any version of the user
 type TicketDTO struct {
 	ID       string `json:"id"`
 	GameType string `json:"game_type"`
 	TicketID string `json:"ticket_id"`
+	Version  *int64 `json:"version,omitempty"`
+}
+
+// TicketBalance is the user's tickets after the operation
+type TicketBalance struct {
+	ID      string        `json:"id"`
+	Version int64         `json:"version"`
+	Tickets []GameTickets `json:"tickets"`
+}
+
+func NewTicketBalance(user User) TicketBalance {
+	return TicketBalance{
+		ID:      user.ID,
+		Version: user.Version,
+		Tickets: user.Tickets,
+	}
 }
 
 type CreateUserDTO struct {
@@ -29,6 +108,48 @@ type UpdateUserDTO struct {
 	Username      string        `json:"username" bson:"username"`
 	HasFreeTicket bool          `json:"has_free_ticket" bson:"has_free_ticket"`
 	Tickets       []GameTickets `json:"tickets" bson:"tickets"`
+	Version       *int64        `json:"version,omitempty" bson:"-"`
+}
+
+type GuestDTO struct {
+	DeviceID     string `json:"device_id"`
+	DeviceSecret string `json:"device_secret"`
+}
+
+// GuestSecretDTO is the response of guest creation, the secret is returned only when it's issued
+type GuestSecretDTO struct {
+	DeviceSecret string `json:"device_secret,omitempty"`
+}
+
+// ClaimDTO sets credentials of the guest account
+type ClaimDTO struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type ChangePasswordDTO struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+// ResetCodeDTO requests a reset code, the code is sent to the user by the notifier
+type ResetCodeDTO struct {
+	Username string `json:"username"`
+}
+
+type ResetPasswordDTO struct {
+	Username    string `json:"username"`
+	Code        string `json:"code"`
+	NewPassword string `json:"new_password"`
+}
+
+// DeleteAccountDTO confirms the deletion with the password, accounts without password don't need it
+type DeleteAccountDTO struct {
+	Password string `json:"password"`
+}
+
+type RolesDTO struct {
+	Roles []string `json:"roles"`
 }
 
 type GameTickets struct {
@@ -43,7 +164,123 @@ func NewUser(dto CreateUserDTO) User {
 		Password:      dto.Password,
 		HasFreeTicket: true,
 		Tickets:       []GameTickets{},
+		Roles:         []string{apperror.RolePlayer},
+		CreatedAt:     time.Now(),
+		LastSeen:      time.Now(),
+	}
+}
+
+// NewDeviceSecret returns random secret of the guest's device and its hash, only the hash is stored
+func NewDeviceSecret() (secret, hash string, err error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", fmt.Errorf("failed to generate device secret due to: %v", err)
+	}
+	secret = hex.EncodeToString(bytes)
+	return secret, hashDeviceSecret(secret), nil
+}
+
+// hashDeviceSecret is sha256 of the secret, the secret is random, so it doesn't need a slow hash
+func hashDeviceSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidDeviceSecret reports whether the secret is the one issued to the guest's device
+func (u User) ValidDeviceSecret(secret string) bool {
+	return secret != "" && subtle.ConstantTimeCompare([]byte(hashDeviceSecret(secret)), []byte(u.DeviceSecret)) == 1
+}
+
+// NewGuest creates guest account of the device. Guest gets generated username, so it stays unique
+func NewGuest(deviceID, secretHash string) (User, error) {
+	bytes := make([]byte, 6)
+	if _, err := rand.Read(bytes); err != nil {
+		return User{}, fmt.Errorf("failed to generate guest username due to: %v", err)
+	}
+	return User{
+		Username:      fmt.Sprintf("%s%s", guestUsernamePrefix, hex.EncodeToString(bytes)),
+		HasFreeTicket: true,
+		Tickets:       []GameTickets{},
+		Roles:         []string{apperror.RoleGuest},
+		IsGuest:       true,
+		DeviceID:      deviceID,
+		DeviceSecret:  secretHash,
+		CreatedAt:     time.Now(),
+		LastSeen:      time.Now(),
+	}, nil
+}
+
+// withRandomSuffix makes another username from the taken one, the result fits 32 characters
+func withRandomSuffix(username string) (string, error) {
+	bytes := make([]byte, 3)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate username due to: %v", err)
+	}
+	if len(username) > 25 {
+		username = username[:25]
 	}
+	return fmt.Sprintf("%s-%s", username, hex.EncodeToString(bytes)), nil
+}
+
+func (u *User) PublicProfile() PublicProfile {
+	return PublicProfile{
+		ID:          u.ID,
+		Username:    u.Username,
+		IsGuest:     u.IsGuest,
+		DisplayName: u.DisplayName,
+		AvatarURL:   u.AvatarURL,
+		Country:     u.Country,
+		CreatedAt:   u.CreatedAt,
+		LastSeen:    u.LastSeen,
+	}
+}
+
+// SortFields can be used in sort parameter of the users list, id sort is the order of creation
+var SortFields = []string{"username", "last_seen"}
+
+// Filter of the users list, empty fields match any user
+type Filter struct {
+	Role    string
+	Country string
+	IsGuest *bool
+}
+
+func NewFilter(query url.Values) (Filter, error) {
+	filter := Filter{
+		Role:    query.Get("role"),
+		Country: query.Get("country"),
+	}
+	if isGuest := query.Get("is_guest"); isGuest != "" {
+		value, err := strconv.ParseBool(isGuest)
+		if err != nil {
+			return filter, fmt.Errorf("is_guest must be true or false")
+		}
+		filter.IsGuest = &value
+	}
+	return filter, nil
+}
+
+// fillCreatedAt takes creation time of old users from their object id
+func (u *User) fillCreatedAt() {
+	if !u.CreatedAt.IsZero() {
+		return
+	}
+	if oid, err := primitive.ObjectIDFromHex(u.ID); err == nil {
+		u.CreatedAt = oid.Timestamp()
+	}
+}
+
+// HasRole reports whether the user has the role. Users created before roles were introduced are players
+func (u *User) HasRole(role string) bool {
+	if len(u.Roles) == 0 {
+		return role == apperror.RolePlayer
+	}
+	for _, r := range u.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
 }
 
 //func UpdatedUser(dto UpdateUserDTO) User {
@@ -61,8 +298,8 @@ func (u *User) CheckPassword(password string) error {
 	return nil
 }
 
-func (u *User) GeneratePasswordHash() error {
-	pwd, err := generatePasswordHash(u.Password)
+func (u *User) GeneratePasswordHash(cost int) error {
+	pwd, err := generatePasswordHash(u.Password, cost)
 	if err != nil {
 		return err
 	}
@@ -70,8 +307,17 @@ func (u *User) GeneratePasswordHash() error {
 	return nil
 }
 
-func generatePasswordHash(password string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+// NeedsRehash reports whether the stored hash is weaker than the configured cost
+func (u *User) NeedsRehash(cost int) bool {
+	hashCost, err := bcrypt.Cost([]byte(u.Password))
+	if err != nil {
+		return false
+	}
+	return hashCost < cost
+}
+
+func generatePasswordHash(password string, cost int) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
 	if err != nil {
 		return "", fmt.Errorf("failed to hash password due to error %w", err)
 	}
@@ -0,0 +1,76 @@
+package user
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+	"user_service/internal/apperror"
+)
+
+const (
+	passwordMinLength    = 8
+	passwordMaxLength    = 72 // bcrypt ignores bytes after 72
+	displayNameMaxLength = 32
+	avatarURLMaxLength   = 512
+	reasonMaxLength      = 500
+)
+
+// validatePassword checks the same password policy as sign up in the auth service
+func validatePassword(password string) error {
+	if len(password) < passwordMinLength || len(password) > passwordMaxLength {
+		return apperror.BadRequestError(fmt.Sprintf("password must be from %d to %d characters long", passwordMinLength, passwordMaxLength))
+	}
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return apperror.BadRequestError("password must contain at least one letter and one digit")
+	}
+	return nil
+}
+
+// validateProfile checks and normalizes profile fields, empty fields are allowed
+func validateProfile(displayName, avatarURL, country string) (string, string, string, error) {
+	displayName = strings.TrimSpace(displayName)
+	if utf8.RuneCountInString(displayName) > displayNameMaxLength {
+		return "", "", "", apperror.BadRequestError(fmt.Sprintf("display name must be at most %d characters long", displayNameMaxLength))
+	}
+	for _, r := range displayName {
+		if unicode.IsControl(r) {
+			return "", "", "", apperror.BadRequestError("display name must not contain control characters")
+		}
+	}
+
+	if avatarURL != "" {
+		u, err := url.Parse(avatarURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" || len(avatarURL) > avatarURLMaxLength {
+			return "", "", "", apperror.BadRequestError(fmt.Sprintf("avatar url must be an https url up to %d characters long", avatarURLMaxLength))
+		}
+	}
+
+	// country is ISO 3166-1 alpha-2 code
+	country = strings.ToUpper(country)
+	if country != "" {
+		if len(country) != 2 || country[0] < 'A' || country[0] > 'Z' || country[1] < 'A' || country[1] > 'Z' {
+			return "", "", "", apperror.BadRequestError("country must be a two letter ISO 3166-1 code")
+		}
+	}
+	return displayName, avatarURL, country, nil
+}
+
+// validateReason requires the reason of the sanction, it is shown to the banned user
+func validateReason(reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" || utf8.RuneCountInString(reason) > reasonMaxLength {
+		return apperror.BadRequestError(fmt.Sprintf("reason is required and must be at most %d characters long", reasonMaxLength))
+	}
+	return nil
+}
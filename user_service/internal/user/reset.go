@@ -0,0 +1,61 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// resetCodeAlphabet has no look-alike characters, its length divides 256 so every character is equally likely
+	resetCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	resetCodeLength   = 8
+)
+
+// ResetCode lets the user set a new password without the old one. Only hash of the code is stored,
+// the user has one code at a time
+type ResetCode struct {
+	UserID   string    `bson:"user_id"`
+	Hash     string    `bson:"hash"`
+	Attempts int       `bson:"attempts"`
+	ExpireAt time.Time `bson:"expire_at"`
+}
+
+type ResetPolicy struct {
+	CodeTTL     time.Duration
+	MaxAttempts int
+}
+
+type ResetCodeStorage interface {
+	// Replace stores the code instead of the previous code of the user
+	Replace(ctx context.Context, code ResetCode) error
+	// Take deletes the code of the user if the hash matches and the code isn't expired, returns ErrNotFound otherwise
+	Take(ctx context.Context, userID, hash string) error
+	// Fail counts a wrong code, the code is deleted after maxAttempts wrong ones
+	Fail(ctx context.Context, userID string, maxAttempts int) error
+	DeleteByUserID(ctx context.Context, userID string) error
+}
+
+// newResetCode returns the code for the user and its hash to store
+func newResetCode() (code, hash string, err error) {
+	bytes := make([]byte, resetCodeLength)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", fmt.Errorf("failed to generate reset code due to: %v", err)
+	}
+	for i, b := range bytes {
+		bytes[i] = resetCodeAlphabet[int(b)%len(resetCodeAlphabet)]
+	}
+	code = string(bytes)
+	return code, hashResetCode(code), nil
+}
+
+// hashResetCode ignores case, spaces and dashes the user could type
+func hashResetCode(code string) string {
+	code = strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(code))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
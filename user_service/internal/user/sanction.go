@@ -0,0 +1,127 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"user_service/pkg/servicetoken"
+)
+
+const (
+	// SanctionBan refuses sign in, joining lobbies and new records
+	SanctionBan = "ban"
+	// SanctionShadowBan hides results of the user from leaderboards, the user doesn't notice it
+	SanctionShadowBan = "shadow_ban"
+
+	revokeTimeout = 5 * time.Second
+)
+
+// Sanction is kept after it expires or is lifted, so moderators see the history of the user
+type Sanction struct {
+	ID       string    `json:"id" bson:"_id,omitempty"`
+	UserID   string    `json:"user_id" bson:"user_id"`
+	Type     string    `json:"type" bson:"type"`
+	Reason   string    `json:"reason" bson:"reason"`
+	IssuedBy string    `json:"issued_by" bson:"issued_by"`
+	IssuedAt time.Time `json:"issued_at" bson:"issued_at"`
+	// ExpireAt is nil for permanent sanctions
+	ExpireAt *time.Time `json:"expire_at,omitempty" bson:"expire_at,omitempty"`
+	LiftedBy string     `json:"lifted_by,omitempty" bson:"lifted_by,omitempty"`
+	LiftedAt *time.Time `json:"lifted_at,omitempty" bson:"lifted_at,omitempty"`
+}
+
+func (s Sanction) Active(now time.Time) bool {
+	return s.LiftedAt == nil && (s.ExpireAt == nil || now.Before(*s.ExpireAt))
+}
+
+// SanctionDTO applies a sanction, duration is a Go duration (e.g. 72h), without it the sanction is permanent
+type SanctionDTO struct {
+	Type     string `json:"type"`
+	Reason   string `json:"reason"`
+	Duration string `json:"duration"`
+}
+
+// SanctionStatus is what other services check before letting the user play
+type SanctionStatus struct {
+	UserID       string    `json:"user_id"`
+	Banned       bool      `json:"banned"`
+	ShadowBanned bool      `json:"shadow_banned"`
+	Ban          *Sanction `json:"ban,omitempty"`
+	// Message explains the ban to the user
+	Message string `json:"message,omitempty"`
+}
+
+func NewSanctionStatus(userID string, active []Sanction) SanctionStatus {
+	status := SanctionStatus{UserID: userID}
+	for i, sanction := range active {
+		switch sanction.Type {
+		case SanctionBan:
+			// the ban which ends last is shown, permanent one ends never
+			if status.Ban == nil || status.Ban.ExpireAt != nil && (sanction.ExpireAt == nil || sanction.ExpireAt.After(*status.Ban.ExpireAt)) {
+				status.Ban = &active[i]
+			}
+			status.Banned = true
+		case SanctionShadowBan:
+			status.ShadowBanned = true
+		}
+	}
+	if status.Ban != nil {
+		status.Message = banMessage(*status.Ban)
+	}
+	return status
+}
+
+func banMessage(ban Sanction) string {
+	if ban.ExpireAt == nil {
+		return fmt.Sprintf("user is banned: %s", ban.Reason)
+	}
+	return fmt.Sprintf("user is banned until %s: %s", ban.ExpireAt.UTC().Format(time.RFC3339), ban.Reason)
+}
+
+// ShadowBannedDTO lists users whose results are hidden from leaderboards
+type ShadowBannedDTO struct {
+	UserIDs []string `json:"user_ids"`
+}
+
+type SanctionStorage interface {
+	Create(ctx context.Context, sanction Sanction) (string, error)
+	// FindByUserID returns all sanctions of the user, the latest first
+	FindByUserID(ctx context.Context, userID string) ([]Sanction, error)
+	FindActive(ctx context.Context, userID string, now time.Time) ([]Sanction, error)
+	// FindShadowBanned returns ids of users with active shadow ban
+	FindShadowBanned(ctx context.Context, now time.Time) ([]string, error)
+	// Lift ends the active sanction, returns ErrNotFound if the user has no such active sanction
+	Lift(ctx context.Context, userID, id, liftedBy string, at time.Time) error
+}
+
+// revokeTokens revokes access and refresh tokens of the user with the service token, so a ban or
+// a password change works before the access tokens expire
+func revokeTokens(ctx context.Context, userID string) error {
+	bytes, err := json.Marshal(map[string]string{"user_id": userID})
+	if err != nil {
+		return fmt.Errorf("failed to marshal data due to: %v", err)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, revokeTokensURL, strings.NewReader(string(bytes)))
+	if err != nil {
+		return fmt.Errorf("failed to make request due to: %v", err)
+	}
+	err = servicetoken.Authorize(ctx, request)
+	if err != nil {
+		return fmt.Errorf("failed to authorize request due to: %v", err)
+	}
+	client := http.Client{Timeout: revokeTimeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to revoke tokens due to: %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 4096))
+		return fmt.Errorf("failed to revoke tokens, status: %d, response body: %s", response.StatusCode, string(body))
+	}
+	return nil
+}
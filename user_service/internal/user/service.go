@@ -4,43 +4,120 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"time"
 	"user_service/internal/apperror"
+	"user_service/internal/notifier"
 	"user_service/pkg/logging"
+	"user_service/pkg/pagination"
 )
 
 var _ Service = &service{}
 
 type service struct {
-	storage Storage
-	logger  logging.Logger
+	storage     Storage
+	resetCodes  ResetCodeStorage
+	sanctions   SanctionStorage
+	relations   RelationStorage
+	notifier    notifier.Notifier
+	admins      []string
+	bcryptCost  int
+	resetPolicy ResetPolicy
+	logger      logging.Logger
 }
 
-func NewService(userStorage Storage, logger logging.Logger) (Service, error) {
+func NewService(userStorage Storage, resetCodes ResetCodeStorage, sanctions SanctionStorage, relations RelationStorage,
+	userNotifier notifier.Notifier, admins []string, bcryptCost int, resetPolicy ResetPolicy, logger logging.Logger) (Service, error) {
+	if bcryptCost < bcrypt.MinCost || bcryptCost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost must be between %d and %d, got %d", bcrypt.MinCost, bcrypt.MaxCost, bcryptCost)
+	}
+	if resetPolicy.CodeTTL <= 0 || resetPolicy.MaxAttempts <= 0 {
+		return nil, fmt.Errorf("reset code ttl and max attempts must be positive")
+	}
 	return &service{
-		storage: userStorage,
-		logger:  logger,
+		storage:     userStorage,
+		resetCodes:  resetCodes,
+		sanctions:   sanctions,
+		relations:   relations,
+		notifier:    userNotifier,
+		admins:      admins,
+		bcryptCost:  bcryptCost,
+		resetPolicy: resetPolicy,
+		logger:      logger,
 	}, nil
 }
 
 type Service interface {
 	Create(ctx context.Context, dto CreateUserDTO) (string, error)
-	GetAll(ctx context.Context) ([]User, error)
+	GetPage(ctx context.Context, filter Filter, params pagination.Params) (pagination.Page[User], error)
 	GetById(ctx context.Context, uuid string) (User, error)
 	GetByUsername(ctx context.Context, username string) (User, error)
+	GetProfile(ctx context.Context, id string) (PublicProfile, error)
+	UpdateProfile(ctx context.Context, id string, dto ProfileDTO) error
+	GetStats(ctx context.Context, id string) (Stats, error)
+	Seen(ctx context.Context, id string)
 	GetByUsernameAndPassword(ctx context.Context, username, password string) (u User, err error)
 	Update(ctx context.Context, dto UpdateUserDTO) error
 	Delete(ctx context.Context, uuid string) error
-	AddTicket(ctx context.Context, dto TicketDTO) error
-	DeleteTicket(ctx context.Context, dto TicketDTO) error
+	GrantTicket(ctx context.Context, dto TicketDTO) (TicketBalance, error)
+	ConsumeTicket(ctx context.Context, dto TicketDTO) (TicketBalance, error)
+	DeleteTicket(ctx context.Context, dto TicketDTO) (TicketBalance, error)
+	SetRoles(ctx context.Context, id string, dto RolesDTO) error
+	EnsureAdmins(ctx context.Context) error
+	CreateGuest(ctx context.Context, dto GuestDTO) (id, secret string, created bool, err error)
+	Claim(ctx context.Context, id string, dto ClaimDTO) error
+	SignInWithIdentity(ctx context.Context, dto IdentityDTO) (id string, created bool, err error)
+	LinkIdentity(ctx context.Context, id string, dto IdentityDTO) error
+	ChangePassword(ctx context.Context, id string, dto ChangePasswordDTO) error
+	RequestPasswordReset(ctx context.Context, dto ResetCodeDTO) error
+	ResetPassword(ctx context.Context, dto ResetPasswordDTO) error
+	DeleteAccount(ctx context.Context, id string, dto DeleteAccountDTO) error
+	Sanction(ctx context.Context, userID, issuedBy string, dto SanctionDTO) (Sanction, error)
+	LiftSanction(ctx context.Context, userID, sanctionID, liftedBy string) error
+	GetSanctions(ctx context.Context, userID string) ([]Sanction, error)
+	GetSanctionStatus(ctx context.Context, userID string) (SanctionStatus, error)
+	GetShadowBanned(ctx context.Context) ([]string, error)
+	SendFriendRequest(ctx context.Context, userID, otherID string) (Relation, error)
+	AcceptFriendRequest(ctx context.Context, userID, otherID string) error
+	DeleteFriendRequest(ctx context.Context, userID, otherID string) error
+	RemoveFriend(ctx context.Context, userID, otherID string) error
+	Block(ctx context.Context, userID, otherID string) error
+	Unblock(ctx context.Context, userID, otherID string) error
+	GetFriends(ctx context.Context, userID string) ([]Friend, error)
+	GetFriendIDs(ctx context.Context, userID string) ([]string, error)
+	GetFriendRequests(ctx context.Context, userID string) (FriendRequests, error)
+	GetBlocked(ctx context.Context, userID string) ([]PublicProfile, error)
+}
+
+const (
+	// lastSeenPrecision limits writes of last seen time of active users
+	lastSeenPrecision = time.Minute
+	maxDeviceIDLength = 128
+	// identityUsernameAttempts is how many usernames are tried for a new user of an identity
+	identityUsernameAttempts = 5
+)
+
+// grantableRoles can be granted to users, service role is issued only to services
+var grantableRoles = map[string]bool{
+	apperror.RolePlayer:   true,
+	apperror.RoleOperator: true,
+	apperror.RoleAdmin:    true,
 }
 
 func (s service) Create(ctx context.Context, dto CreateUserDTO) (userID string, err error) {
-	s.logger.Debug("check password")
+	s.logger.Debug("check username")
+	_, err = s.storage.FindByUsername(ctx, dto.Username)
+	if err == nil {
+		return "", apperror.ErrConflict
+	}
+	if !errors.Is(err, apperror.ErrNotFound) {
+		return "", fmt.Errorf("failed to find user by username. error: %w", err)
+	}
 
 	user := NewUser(dto)
 
 	s.logger.Debug("generate password hash")
-	err = user.GeneratePasswordHash()
+	err = user.GeneratePasswordHash(s.bcryptCost)
 	if err != nil {
 		s.logger.Errorf("failed to create user due to error %v", err)
 		return
@@ -50,7 +127,7 @@ func (s service) Create(ctx context.Context, dto CreateUserDTO) (userID string,
 	userID, err = s.storage.Create(ctx, user)
 
 	if err != nil {
-		if errors.Is(err, apperror.ErrNotFound) {
+		if errors.Is(err, apperror.ErrConflict) {
 			return userID, err
 		}
 		return userID, fmt.Errorf("failed to create user. error: %w", err)
@@ -69,9 +146,64 @@ func (s service) GetById(ctx context.Context, id string) (u User, err error) {
 		}
 		return u, fmt.Errorf("failed to find user by uuid. error: %w", err)
 	}
+	u.fillCreatedAt()
 	return u, nil
 }
 
+func (s service) GetProfile(ctx context.Context, id string) (PublicProfile, error) {
+	u, err := s.GetById(ctx, id)
+	if err != nil {
+		return PublicProfile{}, err
+	}
+	return u.PublicProfile(), nil
+}
+
+// UpdateProfile changes profile fields which are set in the dto
+func (s service) UpdateProfile(ctx context.Context, id string, dto ProfileDTO) error {
+	u, err := s.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if dto.DisplayName != nil {
+		u.DisplayName = *dto.DisplayName
+	}
+	if dto.AvatarURL != nil {
+		u.AvatarURL = *dto.AvatarURL
+	}
+	if dto.Country != nil {
+		u.Country = *dto.Country
+	}
+	displayName, avatarURL, country, err := validateProfile(u.DisplayName, u.AvatarURL, u.Country)
+	if err != nil {
+		return err
+	}
+	err = s.storage.SetProfile(ctx, u.ID, displayName, avatarURL, country)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return err
+		}
+		return fmt.Errorf("failed to update profile. error: %w", err)
+	}
+	return nil
+}
+
+// GetStats returns stats of existing user, unavailable sources don't fail the request
+func (s service) GetStats(ctx context.Context, id string) (Stats, error) {
+	u, err := s.GetById(ctx, id)
+	if err != nil {
+		return Stats{}, err
+	}
+	return collectStats(ctx, u.ID, s.logger), nil
+}
+
+// Seen updates last seen time of the user, failures are only logged
+func (s service) Seen(ctx context.Context, id string) {
+	err := s.storage.SetLastSeen(ctx, id, time.Now(), lastSeenPrecision)
+	if err != nil {
+		s.logger.Warnf("failed to update last seen of user %s due to: %v", id, err)
+	}
+}
+
 func (s service) GetByUsername(ctx context.Context, username string) (u User, err error) {
 	u, err = s.storage.FindByUsername(ctx, username)
 	if err != nil {
@@ -80,35 +212,57 @@ func (s service) GetByUsername(ctx context.Context, username string) (u User, er
 		}
 		return u, fmt.Errorf("failed to find user by username. error: %w", err)
 	}
+	u.fillCreatedAt()
 	return u, nil
 }
 
+// GetByUsernameAndPassword returns ErrUnauthorized both for unknown username and wrong password.
+// Password hashed with lower cost than configured is rehashed, so old hashes get stronger on login
 func (s service) GetByUsernameAndPassword(ctx context.Context, username, password string) (u User, err error) {
 	u, err = s.storage.FindByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return User{}, apperror.ErrUnauthorized
+		}
+		return u, fmt.Errorf("failed to find user by username. error: %w", err)
+	}
 
 	err = u.CheckPassword(password)
 	if err != nil {
-		return User{}, err
+		return User{}, apperror.ErrUnauthorized
 	}
-	if err != nil {
-		return u, err
+
+	if u.NeedsRehash(s.bcryptCost) {
+		s.rehashPassword(ctx, u, password)
 	}
+	s.Seen(ctx, u.ID)
+	return u, nil
+}
 
+// rehashPassword doesn't fail the login, the password is rehashed on the next one
+func (s service) rehashPassword(ctx context.Context, u User, password string) {
+	hash, err := generatePasswordHash(password, s.bcryptCost)
 	if err != nil {
-		if errors.Is(err, apperror.ErrNotFound) {
-			return u, err
-		}
-		return u, fmt.Errorf("failed to find user by username. error: %w", err)
+		s.logger.Errorf("failed to rehash password of user %s due to error %v", u.ID, err)
+		return
 	}
-	return u, nil
+	err = s.storage.SetPassword(ctx, u.ID, hash)
+	if err != nil {
+		s.logger.Errorf("failed to save rehashed password of user %s due to error %v", u.ID, err)
+		return
+	}
+	s.logger.Infof("password of user %s is rehashed with cost %d", u.ID, s.bcryptCost)
 }
 
-func (s service) GetAll(ctx context.Context) ([]User, error) {
-	users, err := s.storage.FindAll(ctx)
+func (s service) GetPage(ctx context.Context, filter Filter, params pagination.Params) (pagination.Page[User], error) {
+	users, err := s.storage.FindPage(ctx, filter, params)
 	if err != nil {
-		return users, fmt.Errorf("failed to find users. error: %v", err)
+		return pagination.Page[User]{}, fmt.Errorf("failed to find users. error: %v", err)
+	}
+	for i := range users {
+		users[i].fillCreatedAt()
 	}
-	return users, nil
+	return pagination.NewPage(users, params)
 }
 
 func (s service) Update(ctx context.Context, dto UpdateUserDTO) error {
@@ -117,9 +271,9 @@ func (s service) Update(ctx context.Context, dto UpdateUserDTO) error {
 		Username: dto.Username,
 		Tickets:  dto.Tickets,
 	}
-	err := s.storage.Update(ctx, user)
+	err := s.storage.Update(ctx, user, dto.Version)
 	if err != nil {
-		if errors.Is(err, apperror.ErrNotFound) {
+		if errors.Is(err, apperror.ErrNotFound) || errors.Is(err, apperror.ErrVersionConflict) {
 			return err
 		}
 		return fmt.Errorf("failed to update user. error: %w", err)
@@ -139,85 +293,701 @@ func (s service) Delete(ctx context.Context, uuid string) error {
 	return err
 }
 
-func getGameTickets(gameType string, gameTicketsArr []GameTickets) (index int, found bool) {
-	for i := 0; i < len(gameTicketsArr); i++ {
-		if gameTicketsArr[i].GameType == gameType {
-			return i, true
+// SetRoles replaces roles of the user. New roles get into tokens issued after sign in or refresh
+func (s service) SetRoles(ctx context.Context, id string, dto RolesDTO) error {
+	if len(dto.Roles) == 0 {
+		return apperror.BadRequestError("user must have at least one role")
+	}
+	for _, role := range dto.Roles {
+		if !grantableRoles[role] {
+			return apperror.BadRequestError(fmt.Sprintf("unknown role: %s", role))
+		}
+	}
+	err := s.storage.SetRoles(ctx, id, dto.Roles)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return err
 		}
+		return fmt.Errorf("failed to set user roles. error: %w", err)
 	}
-	return -1, false
+	return nil
 }
 
-func getTicketID(ticketID string, gameTickets GameTickets) (index int, found bool) {
-	for i := 0; i < len(gameTickets.IDsOfGT); i++ {
-		if gameTickets.IDsOfGT[i] == ticketID {
-			return i, true
+// CreateGuest creates guest account of the device and returns the device secret issued to it. The existing
+// guest is returned only for the secret of the device, otherwise ErrUnauthorized is returned. Guests created
+// before secrets were issued get the secret on the next sign in
+func (s service) CreateGuest(ctx context.Context, dto GuestDTO) (id, secret string, created bool, err error) {
+	if dto.DeviceID == "" || len(dto.DeviceID) > maxDeviceIDLength {
+		return "", "", false, apperror.BadRequestError(fmt.Sprintf("device id must be 1-%d characters", maxDeviceIDLength))
+	}
+	u, err := s.storage.FindGuestByDeviceID(ctx, dto.DeviceID)
+	if err == nil {
+		return s.signInGuest(ctx, u, dto.DeviceSecret)
+	}
+	if !errors.Is(err, apperror.ErrNotFound) {
+		return "", "", false, fmt.Errorf("failed to find guest. error: %w", err)
+	}
+
+	secret, hash, err := NewDeviceSecret()
+	if err != nil {
+		return "", "", false, err
+	}
+	guest, err := NewGuest(dto.DeviceID, hash)
+	if err != nil {
+		return "", "", false, err
+	}
+	id, err = s.storage.Create(ctx, guest)
+	if err != nil {
+		if errors.Is(err, apperror.ErrConflict) {
+			// the device got its guest account concurrently
+			u, err = s.storage.FindGuestByDeviceID(ctx, dto.DeviceID)
+			if err != nil {
+				return "", "", false, fmt.Errorf("failed to find guest. error: %w", err)
+			}
+			return s.signInGuest(ctx, u, dto.DeviceSecret)
 		}
+		return "", "", false, fmt.Errorf("failed to create guest. error: %w", err)
 	}
-	return -1, false
+	return id, secret, true, nil
 }
 
-func (s service) AddTicket(ctx context.Context, dto TicketDTO) error {
-	user, err := s.GetById(ctx, dto.ID)
+// signInGuest checks the device secret of the existing guest, a guest without the secret gets a new one
+func (s service) signInGuest(ctx context.Context, u User, deviceSecret string) (id, secret string, created bool, err error) {
+	if u.DeviceSecret == "" {
+		secret, hash, err := NewDeviceSecret()
+		if err != nil {
+			return "", "", false, err
+		}
+		if err = s.storage.SetDeviceSecret(ctx, u.ID, hash); err != nil {
+			if errors.Is(err, apperror.ErrConflict) {
+				// another request got the secret first
+				return "", "", false, apperror.ErrUnauthorized
+			}
+			return "", "", false, fmt.Errorf("failed to set device secret. error: %w", err)
+		}
+		s.Seen(ctx, u.ID)
+		return u.ID, secret, false, nil
+	}
+	if !u.ValidDeviceSecret(deviceSecret) {
+		return "", "", false, apperror.ErrUnauthorized
+	}
+	s.Seen(ctx, u.ID)
+	return u.ID, "", false, nil
+}
+
+// Claim sets username and password of the guest account and makes it a player.
+// Tickets and free ticket stay with the account, records of other services are bound to its id
+func (s service) Claim(ctx context.Context, id string, dto ClaimDTO) error {
+	u, err := s.storage.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return err
+		}
+		return fmt.Errorf("failed to find guest. error: %w", err)
+	}
+	if !u.IsGuest {
+		return apperror.BadRequestError("account is already claimed")
+	}
+	if dto.Username == "" || dto.Password == "" {
+		return apperror.BadRequestError("username and password are required")
+	}
+
+	hash, err := generatePasswordHash(dto.Password, s.bcryptCost)
 	if err != nil {
 		return err
 	}
+	err = s.storage.Claim(ctx, id, dto.Username, hash, []string{apperror.RolePlayer})
+	if err != nil {
+		if errors.Is(err, apperror.ErrConflict) || errors.Is(err, apperror.ErrNotFound) {
+			return err
+		}
+		return fmt.Errorf("failed to claim guest. error: %w", err)
+	}
+	return nil
+}
+
+// SignInWithIdentity returns user of the identity. New user is created for unknown identity,
+// if username hint is taken a random suffix is added to it
+func (s service) SignInWithIdentity(ctx context.Context, dto IdentityDTO) (string, bool, error) {
+	if dto.Provider == "" || dto.Subject == "" || dto.Username == "" {
+		return "", false, apperror.BadRequestError("provider, subject and username are required")
+	}
+	identity := Identity{Provider: dto.Provider, Subject: dto.Subject}
+	u, err := s.storage.FindByIdentity(ctx, identity)
+	if err == nil {
+		s.Seen(ctx, u.ID)
+		return u.ID, false, nil
+	}
+	if !errors.Is(err, apperror.ErrNotFound) {
+		return "", false, fmt.Errorf("failed to find user by identity. error: %w", err)
+	}
+
+	username := dto.Username
+	for i := 0; i < identityUsernameAttempts; i++ {
+		user := NewUser(CreateUserDTO{Username: username})
+		user.Identities = []Identity{identity}
+		id, err := s.storage.Create(ctx, user)
+		if err == nil {
+			return id, true, nil
+		}
+		if !errors.Is(err, apperror.ErrConflict) {
+			return "", false, fmt.Errorf("failed to create user. error: %w", err)
+		}
+		// conflict is either on username or on the identity created concurrently
+		if u, err := s.storage.FindByIdentity(ctx, identity); err == nil {
+			return u.ID, false, nil
+		}
+		username, err = withRandomSuffix(dto.Username)
+		if err != nil {
+			return "", false, err
+		}
+	}
+	return "", false, apperror.ErrConflict
+}
+
+// LinkIdentity lets existing user sign in with the identity. Returns ErrConflict if the identity belongs to another user
+func (s service) LinkIdentity(ctx context.Context, id string, dto IdentityDTO) error {
+	if dto.Provider == "" || dto.Subject == "" {
+		return apperror.BadRequestError("provider and subject are required")
+	}
+	identity := Identity{Provider: dto.Provider, Subject: dto.Subject}
+	u, err := s.storage.FindByIdentity(ctx, identity)
+	if err == nil {
+		if u.ID == id {
+			return nil
+		}
+		return apperror.ErrConflict
+	}
+	if !errors.Is(err, apperror.ErrNotFound) {
+		return fmt.Errorf("failed to find user by identity. error: %w", err)
+	}
+	err = s.storage.AddIdentity(ctx, id, identity)
+	if err != nil {
+		if errors.Is(err, apperror.ErrConflict) || errors.Is(err, apperror.ErrNotFound) {
+			return err
+		}
+		return fmt.Errorf("failed to link identity. error: %w", err)
+	}
+	return nil
+}
+
+// ChangePassword sets a new password after checking the old one. Accounts without password
+// (guests, users of identity providers) get one with the reset code
+func (s service) ChangePassword(ctx context.Context, id string, dto ChangePasswordDTO) error {
+	u, err := s.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if u.Password == "" {
+		return apperror.BadRequestError("account has no password, request a reset code to set it")
+	}
+	if err = u.CheckPassword(dto.OldPassword); err != nil {
+		return apperror.ErrUnauthorized
+	}
+	if err = validatePassword(dto.NewPassword); err != nil {
+		return err
+	}
+	return s.setPassword(ctx, u.ID, dto.NewPassword)
+}
+
+// RequestPasswordReset sends a new reset code to the user, the previous code stops working.
+// It doesn't tell whether the username exists, unknown users and guests get nothing
+func (s service) RequestPasswordReset(ctx context.Context, dto ResetCodeDTO) error {
+	u, err := s.storage.FindByUsername(ctx, dto.Username)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			s.logger.Debugf("reset code is requested for unknown user %s", dto.Username)
+			return nil
+		}
+		return fmt.Errorf("failed to find user by username. error: %w", err)
+	}
+	if u.IsGuest {
+		return nil
+	}
+
+	code, hash, err := newResetCode()
+	if err != nil {
+		return err
+	}
+	expireAt := time.Now().Add(s.resetPolicy.CodeTTL)
+	err = s.resetCodes.Replace(ctx, ResetCode{UserID: u.ID, Hash: hash, ExpireAt: expireAt})
+	if err != nil {
+		return err
+	}
+	err = s.notifier.Notify(ctx, notifier.Message{
+		UserID:   u.ID,
+		Username: u.Username,
+		Subject:  "password_reset",
+		Text: fmt.Sprintf("your password reset code is %s, it expires at %s",
+			code, expireAt.UTC().Format(time.RFC3339)),
+	})
+	if err != nil {
+		s.logger.Errorf("failed to send reset code to user %s due to: %v", u.ID, err)
+	}
+	return nil
+}
+
+// ResetPassword sets a new password with the reset code. The code is used once,
+// after too many wrong codes the user requests a new one
+func (s service) ResetPassword(ctx context.Context, dto ResetPasswordDTO) error {
+	errInvalidCode := apperror.BadRequestError("invalid or expired reset code")
+	if err := validatePassword(dto.NewPassword); err != nil {
+		return err
+	}
+	u, err := s.storage.FindByUsername(ctx, dto.Username)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return errInvalidCode
+		}
+		return fmt.Errorf("failed to find user by username. error: %w", err)
+	}
+
+	err = s.resetCodes.Take(ctx, u.ID, hashResetCode(dto.Code))
+	if err != nil {
+		if !errors.Is(err, apperror.ErrNotFound) {
+			return err
+		}
+		if err = s.resetCodes.Fail(ctx, u.ID, s.resetPolicy.MaxAttempts); err != nil {
+			s.logger.Error(err)
+		}
+		return errInvalidCode
+	}
+	return s.setPassword(ctx, u.ID, dto.NewPassword)
+}
 
-	i, gtFound := getGameTickets(dto.GameType, user.Tickets)
-	if gtFound {
-		_, found := getTicketID(dto.TicketID, user.Tickets[i])
-		if found {
-			return fmt.Errorf("user is already in list")
+func (s service) setPassword(ctx context.Context, id, password string) error {
+	hash, err := generatePasswordHash(password, s.bcryptCost)
+	if err != nil {
+		return err
+	}
+	err = s.storage.SetPassword(ctx, id, hash)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return err
 		}
-		user.Tickets[i].IDsOfGT = append(user.Tickets[i].IDsOfGT, dto.TicketID)
-		user.Tickets[i].Amount += 1
-	} else {
-		user.Tickets = append(user.Tickets, GameTickets{
-			GameType: dto.GameType,
-			Amount:   1,
-			IDsOfGT:  []string{dto.TicketID},
-		})
+		return fmt.Errorf("failed to set password. error: %w", err)
 	}
+	s.logger.Infof("password of user %s is changed", id)
 
-	update := UpdateUserDTO{
-		ID:            user.ID,
-		Username:      user.Username,
-		HasFreeTicket: user.HasFreeTicket,
-		Tickets:       user.Tickets,
+	// sessions signed in with the old password, possibly stolen ones, end with the change
+	if err := revokeTokens(ctx, id); err != nil {
+		return fmt.Errorf("password is changed, but tokens of the user weren't revoked. error: %w", err)
 	}
-	err = s.Update(ctx, update)
+	return nil
+}
+
+// DeleteAccount deletes the user with tickets and leaderboard records and revokes tokens of the user. Data of other
+// services is deleted first, if a service or the revocation fails the account is kept and the deletion can be repeated.
+// Sanctions are kept as the moderation record of the user
+func (s service) DeleteAccount(ctx context.Context, id string, dto DeleteAccountDTO) error {
+	u, err := s.GetById(ctx, id)
 	if err != nil {
 		return err
 	}
+	if u.Password != "" && u.CheckPassword(dto.Password) != nil {
+		return apperror.ErrUnauthorized
+	}
+
+	if err = deleteUserData(ctx, u.ID); err != nil {
+		return fmt.Errorf("failed to delete data of user. error: %w", err)
+	}
+	// tokens of the deleted user must not work until they expire
+	if err = revokeTokens(ctx, u.ID); err != nil {
+		return fmt.Errorf("failed to revoke tokens of user. error: %w", err)
+	}
+	if err = s.Delete(ctx, u.ID); err != nil {
+		return err
+	}
+	if err = s.resetCodes.DeleteByUserID(ctx, u.ID); err != nil {
+		s.logger.Error(err)
+	}
+	if err = s.relations.DeleteByUserID(ctx, u.ID); err != nil {
+		s.logger.Error(err)
+	}
+	s.logger.ExtraFields(map[string]interface{}{
+		"audit":   "account_deleted",
+		"user_id": u.ID,
+	}).Info("user deleted the account")
+	return nil
+}
+
+// Sanction applies the sanction to the user, tokens of a banned user are revoked. Admins can't be sanctioned
+func (s service) Sanction(ctx context.Context, userID, issuedBy string, dto SanctionDTO) (sanction Sanction, err error) {
+	if dto.Type != SanctionBan && dto.Type != SanctionShadowBan {
+		return sanction, apperror.BadRequestError(fmt.Sprintf("type must be %s or %s", SanctionBan, SanctionShadowBan))
+	}
+	if err = validateReason(dto.Reason); err != nil {
+		return sanction, err
+	}
+	now := time.Now()
+	sanction = Sanction{
+		UserID:   userID,
+		Type:     dto.Type,
+		Reason:   dto.Reason,
+		IssuedBy: issuedBy,
+		IssuedAt: now,
+	}
+	if dto.Duration != "" {
+		duration, err := time.ParseDuration(dto.Duration)
+		if err != nil || duration <= 0 {
+			return sanction, apperror.BadRequestError("duration must be a positive duration, e.g. 72h")
+		}
+		expireAt := now.Add(duration)
+		sanction.ExpireAt = &expireAt
+	}
+
+	u, err := s.GetById(ctx, userID)
+	if err != nil {
+		return sanction, err
+	}
+	if u.HasRole(apperror.RoleAdmin) {
+		return sanction, apperror.BadRequestError("admins can't be sanctioned")
+	}
+	sanction.ID, err = s.sanctions.Create(ctx, sanction)
+	if err != nil {
+		return sanction, fmt.Errorf("failed to create sanction. error: %w", err)
+	}
+	s.logger.ExtraFields(map[string]interface{}{
+		"audit":    "sanction_applied",
+		"user_id":  userID,
+		"by":       issuedBy,
+		"sanction": sanction.Type,
+	}).Warn(sanction.Reason)
+
+	if sanction.Type == SanctionBan {
+		if err := revokeTokens(ctx, userID); err != nil {
+			// refresh is refused to banned users anyway, issued access tokens live until they expire
+			s.logger.Errorf("failed to revoke tokens of banned user %s due to: %v", userID, err)
+		}
+	}
+	return sanction, nil
+}
+
+func (s service) LiftSanction(ctx context.Context, userID, sanctionID, liftedBy string) error {
+	err := s.sanctions.Lift(ctx, userID, sanctionID, liftedBy, time.Now())
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return err
+		}
+		return fmt.Errorf("failed to lift sanction. error: %w", err)
+	}
+	s.logger.ExtraFields(map[string]interface{}{
+		"audit":       "sanction_lifted",
+		"user_id":     userID,
+		"by":          liftedBy,
+		"sanction_id": sanctionID,
+	}).Warn("sanction lifted")
 	return nil
 }
 
-func (s service) DeleteTicket(ctx context.Context, dto TicketDTO) error {
-	user, err := s.GetById(ctx, dto.ID)
+func (s service) GetSanctions(ctx context.Context, userID string) ([]Sanction, error) {
+	list, err := s.sanctions.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find sanctions. error: %w", err)
+	}
+	if list == nil {
+		list = []Sanction{}
+	}
+	return list, nil
+}
+
+func (s service) GetSanctionStatus(ctx context.Context, userID string) (SanctionStatus, error) {
+	active, err := s.sanctions.FindActive(ctx, userID, time.Now())
+	if err != nil {
+		return SanctionStatus{}, fmt.Errorf("failed to find active sanctions. error: %w", err)
+	}
+	return NewSanctionStatus(userID, active), nil
+}
+
+func (s service) GetShadowBanned(ctx context.Context) ([]string, error) {
+	ids, err := s.sanctions.FindShadowBanned(ctx, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to find shadow banned users. error: %w", err)
+	}
+	return ids, nil
+}
+
+// SendFriendRequest sends the request to the other user. If the other user has sent a request already,
+// it is accepted. Guests can't have friends
+func (s service) SendFriendRequest(ctx context.Context, userID, otherID string) (Relation, error) {
+	if userID == otherID {
+		return Relation{}, apperror.BadRequestError("can't send friend request to yourself")
+	}
+	other, err := s.GetById(ctx, otherID)
+	if err != nil {
+		return Relation{}, err
+	}
+	if other.IsGuest {
+		return Relation{}, apperror.BadRequestError("guests can't have friends")
+	}
+
+	existing, err := s.relations.FindBetween(ctx, userID, otherID)
 	if err != nil {
+		return Relation{}, fmt.Errorf("failed to find relations. error: %w", err)
+	}
+	now := time.Now()
+	for _, r := range existing {
+		switch {
+		case r.Status == RelationBlocked && r.From == userID:
+			return Relation{}, apperror.BadRequestError("unblock the user to send a friend request")
+		case r.Status == RelationBlocked:
+			return Relation{}, apperror.BadRequestError("the user doesn't accept friend requests from you")
+		case r.Status == RelationAccepted:
+			return Relation{}, apperror.ErrConflict
+		case r.From == userID:
+			// the request is sent already
+			return Relation{}, apperror.ErrConflict
+		default:
+			if err := s.relations.SetStatus(ctx, otherID, userID, RelationPending, RelationAccepted, now); err != nil {
+				return Relation{}, friendError(err)
+			}
+			r.Status, r.UpdatedAt = RelationAccepted, now
+			return r, nil
+		}
+	}
+
+	relation := Relation{From: userID, To: otherID, Status: RelationPending, CreatedAt: now, UpdatedAt: now}
+	if err = s.relations.Create(ctx, relation); err != nil {
+		return Relation{}, friendError(err)
+	}
+	return relation, nil
+}
+
+// AcceptFriendRequest accepts the request the other user sent to the user
+func (s service) AcceptFriendRequest(ctx context.Context, userID, otherID string) error {
+	err := s.relations.SetStatus(ctx, otherID, userID, RelationPending, RelationAccepted, time.Now())
+	return friendError(err)
+}
+
+// DeleteFriendRequest declines the request sent to the user or cancels the request sent by the user
+func (s service) DeleteFriendRequest(ctx context.Context, userID, otherID string) error {
+	err := s.relations.Delete(ctx, otherID, userID, RelationPending)
+	if errors.Is(err, apperror.ErrNotFound) {
+		err = s.relations.Delete(ctx, userID, otherID, RelationPending)
+	}
+	return friendError(err)
+}
+
+func (s service) RemoveFriend(ctx context.Context, userID, otherID string) error {
+	err := s.relations.Delete(ctx, userID, otherID, RelationAccepted)
+	if errors.Is(err, apperror.ErrNotFound) {
+		err = s.relations.Delete(ctx, otherID, userID, RelationAccepted)
+	}
+	return friendError(err)
+}
+
+// Block removes the friendship and pending requests between the users, the blocked user can't send requests
+// to the user. Blocking twice isn't an error
+func (s service) Block(ctx context.Context, userID, otherID string) error {
+	if userID == otherID {
+		return apperror.BadRequestError("can't block yourself")
+	}
+	if _, err := s.GetById(ctx, otherID); err != nil {
 		return err
 	}
+	for _, pair := range [][2]string{{userID, otherID}, {otherID, userID}} {
+		err := s.relations.Delete(ctx, pair[0], pair[1], RelationPending, RelationAccepted)
+		if err != nil && !errors.Is(err, apperror.ErrNotFound) {
+			return fmt.Errorf("failed to delete relation. error: %w", err)
+		}
+	}
+	now := time.Now()
+	err := s.relations.Create(ctx, Relation{From: userID, To: otherID, Status: RelationBlocked, CreatedAt: now, UpdatedAt: now})
+	if err != nil && !errors.Is(err, apperror.ErrConflict) {
+		return fmt.Errorf("failed to create relation. error: %w", err)
+	}
+	return nil
+}
+
+func (s service) Unblock(ctx context.Context, userID, otherID string) error {
+	err := s.relations.Delete(ctx, userID, otherID, RelationBlocked)
+	return friendError(err)
+}
+
+// GetFriends returns profiles of friends, the latest friends first
+func (s service) GetFriends(ctx context.Context, userID string) ([]Friend, error) {
+	accepted, err := s.relations.FindOf(ctx, userID, RelationAccepted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find friends. error: %w", err)
+	}
+	profiles, err := s.profilesOf(ctx, userID, accepted)
+	if err != nil {
+		return nil, err
+	}
+	friends := make([]Friend, 0, len(accepted))
+	for _, r := range accepted {
+		if profile, ok := profiles[r.Other(userID)]; ok {
+			friends = append(friends, Friend{PublicProfile: profile, Since: r.UpdatedAt})
+		}
+	}
+	return friends, nil
+}
+
+func (s service) GetFriendIDs(ctx context.Context, userID string) ([]string, error) {
+	accepted, err := s.relations.FindOf(ctx, userID, RelationAccepted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find friends. error: %w", err)
+	}
+	ids := make([]string, 0, len(accepted))
+	for _, r := range accepted {
+		ids = append(ids, r.Other(userID))
+	}
+	return ids, nil
+}
 
-	i, gtFound := getGameTickets(dto.GameType, user.Tickets)
-	if gtFound {
-		j, found := getTicketID(dto.TicketID, user.Tickets[i])
-		if !found {
-			return fmt.Errorf("user with id: %s not found", dto.TicketID)
+func (s service) GetFriendRequests(ctx context.Context, userID string) (FriendRequests, error) {
+	requests := FriendRequests{Incoming: []FriendRequest{}, Outgoing: []FriendRequest{}}
+	pending, err := s.relations.FindOf(ctx, userID, RelationPending)
+	if err != nil {
+		return requests, fmt.Errorf("failed to find friend requests. error: %w", err)
+	}
+	profiles, err := s.profilesOf(ctx, userID, pending)
+	if err != nil {
+		return requests, err
+	}
+	for _, r := range pending {
+		profile, ok := profiles[r.Other(userID)]
+		if !ok {
+			continue
+		}
+		request := FriendRequest{User: profile, SentAt: r.CreatedAt}
+		if r.To == userID {
+			requests.Incoming = append(requests.Incoming, request)
+		} else {
+			requests.Outgoing = append(requests.Outgoing, request)
 		}
-		user.Tickets[i].IDsOfGT = append(user.Tickets[i].IDsOfGT[:j], user.Tickets[i].IDsOfGT[j+1:]...)
-		user.Tickets[i].Amount -= 1
-	} else {
-		return fmt.Errorf("user has no tickets of game type: %s", dto.GameType)
 	}
+	return requests, nil
+}
 
-	update := UpdateUserDTO{
-		ID:       user.ID,
-		Username: user.Username,
-		Tickets:  user.Tickets,
+// GetBlocked returns profiles of users blocked by the user
+func (s service) GetBlocked(ctx context.Context, userID string) ([]PublicProfile, error) {
+	blocked, err := s.relations.FindOf(ctx, userID, RelationBlocked)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find blocked users. error: %w", err)
 	}
-	err = s.Update(ctx, update)
+	var own []Relation
+	for _, r := range blocked {
+		// the user doesn't see who blocked them
+		if r.From == userID {
+			own = append(own, r)
+		}
+	}
+	profiles, err := s.profilesOf(ctx, userID, own)
 	if err != nil {
+		return nil, err
+	}
+	list := make([]PublicProfile, 0, len(own))
+	for _, r := range own {
+		if profile, ok := profiles[r.To]; ok {
+			list = append(list, profile)
+		}
+	}
+	return list, nil
+}
+
+// profilesOf returns public profiles of the other users of the relations by id, deleted users are missing
+func (s service) profilesOf(ctx context.Context, userID string, relations []Relation) (map[string]PublicProfile, error) {
+	ids := make([]string, 0, len(relations))
+	for _, r := range relations {
+		ids = append(ids, r.Other(userID))
+	}
+	users, err := s.storage.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find users. error: %w", err)
+	}
+	profiles := make(map[string]PublicProfile, len(users))
+	for _, u := range users {
+		profiles[u.ID] = u.PublicProfile()
+	}
+	return profiles, nil
+}
+
+// friendError keeps not found and conflict errors of relations, so they get their statuses
+func friendError(err error) error {
+	if err == nil || errors.Is(err, apperror.ErrNotFound) || errors.Is(err, apperror.ErrConflict) {
 		return err
 	}
+	return fmt.Errorf("failed to update relation. error: %w", err)
+}
+
+// EnsureAdmins grants admin role to existing users listed in ADMIN_USERNAMES. New accounts never get roles
+// by their username, otherwise whoever registers the name first would become admin
+func (s service) EnsureAdmins(ctx context.Context) error {
+	for _, username := range s.admins {
+		u, err := s.storage.FindByUsername(ctx, username)
+		if err != nil {
+			if errors.Is(err, apperror.ErrNotFound) {
+				continue
+			}
+			return fmt.Errorf("failed to find admin %s. error: %w", username, err)
+		}
+		if u.HasRole(apperror.RoleAdmin) {
+			continue
+		}
+		roles := append(u.Roles, apperror.RoleAdmin)
+		if len(u.Roles) == 0 {
+			roles = []string{apperror.RolePlayer, apperror.RoleAdmin}
+		}
+		err = s.storage.SetRoles(ctx, u.ID, roles)
+		if err != nil {
+			return fmt.Errorf("failed to grant admin role to %s. error: %w", username, err)
+		}
+		s.logger.Infof("granted admin role to %s", username)
+	}
 	return nil
 }
+
+func validateTicketDTO(dto TicketDTO, ticketRequired bool) error {
+	if dto.ID == "" || dto.GameType == "" {
+		return apperror.BadRequestError("id and game_type are required")
+	}
+	if ticketRequired && dto.TicketID == "" {
+		return apperror.BadRequestError("ticket_id is required")
+	}
+	return nil
+}
+
+func (s service) GrantTicket(ctx context.Context, dto TicketDTO) (balance TicketBalance, err error) {
+	if err = validateTicketDTO(dto, true); err != nil {
+		return balance, err
+	}
+	u, err := s.storage.GrantTicket(ctx, dto.ID, dto.GameType, dto.TicketID, dto.Version)
+	if err != nil {
+		return balance, ticketError(err, "grant")
+	}
+	return NewTicketBalance(u), nil
+}
+
+// ConsumeTicket takes one ticket of the game type, the user is seen as playing
+func (s service) ConsumeTicket(ctx context.Context, dto TicketDTO) (balance TicketBalance, err error) {
+	if err = validateTicketDTO(dto, false); err != nil {
+		return balance, err
+	}
+	u, err := s.storage.ConsumeTicket(ctx, dto.ID, dto.GameType, dto.TicketID, dto.Version)
+	if err != nil {
+		return balance, ticketError(err, "consume")
+	}
+	s.Seen(ctx, u.ID)
+	return NewTicketBalance(u), nil
+}
+
+func (s service) DeleteTicket(ctx context.Context, dto TicketDTO) (balance TicketBalance, err error) {
+	if err = validateTicketDTO(dto, true); err != nil {
+		return balance, err
+	}
+	u, err := s.storage.RemoveTicket(ctx, dto.ID, dto.GameType, dto.TicketID, dto.Version)
+	if err != nil {
+		return balance, ticketError(err, "delete")
+	}
+	return NewTicketBalance(u), nil
+}
+
+// ticketError keeps app errors, so the handler responds with 404 or 409
+func ticketError(err error, operation string) error {
+	var appErr *apperror.AppError
+	if errors.As(err, &appErr) {
+		return err
+	}
+	return fmt.Errorf("failed to %s ticket. error: %w", operation, err)
+}
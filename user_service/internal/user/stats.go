@@ -0,0 +1,128 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+	"user_service/pkg/logging"
+	"user_service/pkg/servicetoken"
+)
+
+const statsTimeout = 3 * time.Second
+
+// GameStats of the user in a game or a qualification table
+type GameStats struct {
+	Game        string `json:"game"`
+	GamesPlayed int    `json:"games_played"`
+	Wins        int    `json:"wins"`
+	BestScore   int    `json:"best_score"`
+}
+
+// Stats are aggregated from game results and qualification records. Sources which didn't respond
+// are listed in Unavailable, their stats are missing
+type Stats struct {
+	UserID         string      `json:"user_id"`
+	Games          []GameStats `json:"games"`
+	Qualifications []GameStats `json:"qualifications"`
+	Unavailable    []string    `json:"unavailable,omitempty"`
+}
+
+type tableStats struct {
+	TableName   string `json:"table_name"`
+	GamesPlayed int    `json:"games_played"`
+	Wins        int    `json:"wins"`
+	BestScore   int    `json:"best_score"`
+}
+
+// collectStats asks game services and qualifications for stats of the user concurrently
+func collectStats(ctx context.Context, userID string, logger logging.Logger) Stats {
+	stats := Stats{
+		UserID:         userID,
+		Games:          []GameStats{},
+		Qualifications: []GameStats{},
+	}
+	games := map[string]string{
+		"snake": snakeStatsURL,
+		"quiz":  quizStatsURL,
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	unavailable := func(source string, err error) {
+		logger.Warnf("stats of user %s from %s are unavailable due to: %v", userID, source, err)
+		mu.Lock()
+		stats.Unavailable = append(stats.Unavailable, source)
+		mu.Unlock()
+	}
+	for game, u := range games {
+		wg.Add(1)
+		go func(game, u string) {
+			defer wg.Done()
+			var gameStats GameStats
+			if err := getStats(ctx, u+userID, &gameStats); err != nil {
+				unavailable(game, err)
+				return
+			}
+			gameStats.Game = game
+			mu.Lock()
+			stats.Games = append(stats.Games, gameStats)
+			mu.Unlock()
+		}(game, u)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		var tables []tableStats
+		if err := getStats(ctx, qualificationsStatsURL+userID, &tables); err != nil {
+			unavailable("qualifications", err)
+			return
+		}
+		mu.Lock()
+		for _, t := range tables {
+			stats.Qualifications = append(stats.Qualifications, GameStats{
+				Game:        t.TableName,
+				GamesPlayed: t.GamesPlayed,
+				Wins:        t.Wins,
+				BestScore:   t.BestScore,
+			})
+		}
+		mu.Unlock()
+	}()
+	wg.Wait()
+	sort.Slice(stats.Games, func(i, j int) bool { return stats.Games[i].Game < stats.Games[j].Game })
+	sort.Strings(stats.Unavailable)
+	return stats
+}
+
+func getStats(ctx context.Context, u string, v interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, statsTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("failed to make request due to: %v", err)
+	}
+	err = servicetoken.Authorize(ctx, request)
+	if err != nil {
+		return fmt.Errorf("failed to authorize request due to: %v", err)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to get stats from %s due to: %v", u, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		bytes, _ := io.ReadAll(io.LimitReader(response.Body, 4096))
+		return fmt.Errorf("failed to get stats from %s, status: %d, response body: %s", u, response.StatusCode, string(bytes))
+	}
+	if err = json.NewDecoder(response.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode stats from %s due to: %v", u, err)
+	}
+	return nil
+}
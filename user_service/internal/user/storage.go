@@ -1,12 +1,36 @@
 package user
 
-import "context"
+import (
+	"context"
+	"time"
+	"user_service/pkg/pagination"
+)
 
 type Storage interface {
 	Create(ctx context.Context, user User) (string, error)
 	FindById(ctx context.Context, id string) (User, error)
 	FindByUsername(ctx context.Context, id string) (User, error)
-	FindAll(ctx context.Context) ([]User, error)
-	Update(ctx context.Context, user User) error
+	FindPage(ctx context.Context, filter Filter, params pagination.Params) ([]User, error)
+	// FindByIDs returns existing users of the ids, unknown and malformed ids are skipped
+	FindByIDs(ctx context.Context, ids []string) ([]User, error)
+	// Update returns ErrVersionConflict if version is given and the user has another one
+	Update(ctx context.Context, user User, version *int64) error
+	// GrantTicket adds the ticket to tickets of the game type, returns ErrConflict if the user has it already
+	GrantTicket(ctx context.Context, id, gameType, ticketID string, version *int64) (User, error)
+	// ConsumeTicket decrements amount of tickets of the game type, if ticket id is given the user must own it
+	// and it is removed from the user's tickets. Returns ErrNoTickets if the user has no tickets left
+	ConsumeTicket(ctx context.Context, id, gameType, ticketID string, version *int64) (User, error)
+	// RemoveTicket deletes the ticket from tickets of the game type, returns ErrNoTickets if the user doesn't own it
+	RemoveTicket(ctx context.Context, id, gameType, ticketID string, version *int64) (User, error)
+	SetRoles(ctx context.Context, id string, roles []string) error
+	SetPassword(ctx context.Context, id string, hash string) error
+	SetProfile(ctx context.Context, id string, displayName, avatarURL, country string) error
+	SetLastSeen(ctx context.Context, id string, at time.Time, precision time.Duration) error
+	FindGuestByDeviceID(ctx context.Context, deviceID string) (User, error)
+	// SetDeviceSecret sets the secret hash of the guest which has none, returns ErrConflict if the guest has one
+	SetDeviceSecret(ctx context.Context, id, hash string) error
+	Claim(ctx context.Context, id string, username, hash string, roles []string) error
+	FindByIdentity(ctx context.Context, identity Identity) (User, error)
+	AddIdentity(ctx context.Context, id string, identity Identity) error
 	Delete(ctx context.Context, id string) error
 }
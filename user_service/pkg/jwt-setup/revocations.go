@@ -0,0 +1,94 @@
+package jwt_setup
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const revocationsRequestTimeout = 5 * time.Second
+
+type revocationList struct {
+	JTIs  []string         `json:"jtis"`
+	Users map[string]int64 `json:"users"`
+}
+
+// Revocations caches revoked tokens of the auth service. The list is refreshed every interval,
+// if the auth service is unavailable the last fetched list is used
+type Revocations struct {
+	url      string
+	interval time.Duration
+	client   *http.Client
+	mu       sync.RWMutex
+	jtis     map[string]struct{}
+	users    map[string]int64
+}
+
+// NewRevocations fetches the list and starts refreshing it in background
+func NewRevocations(url string, interval time.Duration) *Revocations {
+	r := &Revocations{
+		url:      url,
+		interval: interval,
+		client:   &http.Client{Timeout: revocationsRequestTimeout},
+		jtis:     map[string]struct{}{},
+		users:    map[string]int64{},
+	}
+	if err := r.fetch(); err != nil {
+		log.Printf("failed to fetch revoked tokens: %v", err)
+	}
+	go r.run()
+	return r
+}
+
+// Revoked reports whether the token is revoked by its jti or all tokens of its subject issued at or before some time are revoked
+func (r *Revocations) Revoked(claims jwt.RegisteredClaims) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, found := r.jtis[claims.ID]; found && claims.ID != "" {
+		return true
+	}
+	before, found := r.users[claims.Subject]
+	return found && claims.IssuedAt != nil && claims.IssuedAt.Unix() <= before
+}
+
+func (r *Revocations) run() {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := r.fetch(); err != nil {
+			log.Printf("failed to refresh revoked tokens: %v", err)
+		}
+	}
+}
+
+func (r *Revocations) fetch() error {
+	response, err := r.client.Get(r.url)
+	if err != nil {
+		return fmt.Errorf("failed to fetch revocations due to: %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch revocations, status: %d", response.StatusCode)
+	}
+	var list revocationList
+	if err := json.NewDecoder(response.Body).Decode(&list); err != nil {
+		return fmt.Errorf("failed to decode revocations due to: %v", err)
+	}
+
+	jtis := make(map[string]struct{}, len(list.JTIs))
+	for _, jti := range list.JTIs {
+		jtis[jti] = struct{}{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jtis = jtis
+	r.users = list.Users
+	if r.users == nil {
+		r.users = map[string]int64{}
+	}
+	return nil
+}